@@ -32,3 +32,7 @@ require (
 	golang.org/x/text v0.32.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
 )
+
+require neuroiq/shared v0.0.0
+
+replace neuroiq/shared => ../shared
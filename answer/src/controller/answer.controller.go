@@ -5,8 +5,11 @@ import (
 	"answer/src/dto"
 	"answer/src/middleware"
 	"answer/src/models"
+	"answer/src/service"
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
@@ -59,6 +62,19 @@ func SubmitExamAnswers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The course is taken from the exam, not the request, so a submission
+	// cannot be filed under a course its teacher would then be refused
+	course, err := service.GetExamCourse(r.Context(), r.Header.Get("Authorization"), req.ExamID)
+	if errors.Is(err, service.ErrExamNotFound) {
+		respondError(w, http.StatusNotFound, "Exam not found")
+		return
+	}
+	if err != nil {
+		log.Printf("failed to load exam %s: %v", req.ExamID, err)
+		respondError(w, http.StatusBadGateway, "Failed to load the exam")
+		return
+	}
+
 	studentID := authCtx.UserID
 
 	var theoryAnswers []models.TheoryAnswer
@@ -104,8 +120,11 @@ func SubmitExamAnswers(w http.ResponseWriter, r *http.Request) {
 		ExamID:        examID,
 		StudentID:     studentID,
 		ExamSessionID: req.SessionID,
-		Subject:       req.Subject,
-		Semester:      req.Semester,
+		InstitutionID: authCtx.InstitutionID,
+		Subject:       course.Subject,
+		Branch:        course.Branch,
+		Semester:      course.Semester,
+		Section:       course.Section,
 		ExamType:      req.ExamType,
 
 		Answers: models.AnswerSection{
@@ -135,6 +154,12 @@ func SubmitExamAnswers(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetExamSubmission(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	examIDStr := chi.URLParam(r, "exam_id")
 	examID, err := primitive.ObjectIDFromHex(examIDStr)
 	if err != nil {
@@ -147,8 +172,9 @@ func GetExamSubmission(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	filter := bson.M{
-		"exam_id":    examID,
-		"student_id": studentID,
+		"exam_id":        examID,
+		"student_id":     studentID,
+		"institution_id": authCtx.InstitutionID,
 	}
 
 	var submissionData map[string]interface{}
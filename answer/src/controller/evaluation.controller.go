@@ -3,6 +3,7 @@ package controller
 import (
 	"answer/src/db"
 	"answer/src/dto"
+	"answer/src/middleware"
 	"answer/src/models"
 	"answer/src/service"
 	"context"
@@ -40,6 +41,10 @@ func EvaluateSingleTheoryAnswer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !middleware.RequireCourse(w, r, req.Subject, req.Branch, req.Semester, req.Section) {
+		return
+	}
+
 	ctx := r.Context()
 
 	result, err := service.EvaluateSingleTheory(
@@ -70,6 +75,12 @@ func EvaluateSingleTheoryAnswer(w http.ResponseWriter, r *http.Request) {
 
 func StoreExamEvaluation(w http.ResponseWriter, r *http.Request) {
 
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	var req dto.SubmitEvaluationRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -87,6 +98,23 @@ func StoreExamEvaluation(w http.ResponseWriter, r *http.Request) {
 	submissionID, _ := primitive.ObjectIDFromHex(req.SubmissionID)
 	examID, _ := primitive.ObjectIDFromHex(req.ExamID)
 
+	// The course comes from the stored submission, not the request, so
+	// teachers can only grade the subjects they are assigned
+	var submission models.StudentExamAnswer
+	err := db.GetAnswerCollection().FindOne(r.Context(), bson.M{
+		"_id":            submissionID,
+		"exam_id":        examID,
+		"student_id":     req.StudentID,
+		"institution_id": authCtx.InstitutionID,
+	}).Decode(&submission)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Submission not found")
+		return
+	}
+	if !middleware.RequireCourse(w, r, submission.Subject, submission.Branch, submission.Semester, submission.Section) {
+		return
+	}
+
 	var theoryEvaluations []models.TheoryEvaluation
 	var mcqEvaluations []models.MCQEvaluation
 
@@ -127,13 +155,14 @@ func StoreExamEvaluation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	evaluation := models.StudentExamEvaluation{
-		ID:           primitive.NewObjectID(),
-		SubmissionID: submissionID,
-		ExamID:       examID,
-		StudentID:    req.StudentID,
-
-		Subject:  req.Subject,
-		Semester: req.Semester,
+		ID:            primitive.NewObjectID(),
+		SubmissionID:  submissionID,
+		ExamID:        examID,
+		StudentID:     req.StudentID,
+		InstitutionID: authCtx.InstitutionID,
+
+		Subject:  submission.Subject,
+		Semester: submission.Semester,
 		ExamType: req.ExamType,
 
 		Evaluation: models.EvaluationSection{
@@ -166,13 +195,19 @@ func StoreExamEvaluation(w http.ResponseWriter, r *http.Request) {
 
 func GetExamEvaluation(w http.ResponseWriter, r *http.Request) {
 
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	examID := chi.URLParam(r, "exam_id")
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	var evaluation map[string]interface{}
-	err := db.GetEvaluationCollection().FindOne(ctx, bson.M{"exam_id": examID}).Decode(&evaluation)
+	err := db.GetEvaluationCollection().FindOne(ctx, bson.M{"exam_id": examID, "institution_id": authCtx.InstitutionID}).Decode(&evaluation)
 
 	if err != nil {
 		respondError(w, http.StatusNotFound, "Evaluation not found for the given exam ID")
@@ -183,6 +218,12 @@ func GetExamEvaluation(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetStudentExamEvaluation(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	examIDStr := chi.URLParam(r, "exam_id")
 	studentID := chi.URLParam(r, "student_id")
 
@@ -197,8 +238,9 @@ func GetStudentExamEvaluation(w http.ResponseWriter, r *http.Request) {
 
 	var evaluation map[string]interface{}
 	err = db.GetEvaluationCollection().FindOne(ctx, bson.M{
-		"exam_id":    examID,
-		"student_id": studentID,
+		"exam_id":        examID,
+		"student_id":     studentID,
+		"institution_id": authCtx.InstitutionID,
 	}).Decode(&evaluation)
 
 	if err != nil {
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"answer/src/db"
+	"answer/src/dto"
+	"answer/src/middleware"
+	"answer/src/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// decodePrivacySubject reads the subject of an export or erasure sent by auth
+func decodePrivacySubject(w http.ResponseWriter, r *http.Request, erase bool) (middleware.AuthContext, dto.PrivacySubjectDTO, bool) {
+	var subject dto.PrivacySubjectDTO
+
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return authCtx, subject, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return authCtx, subject, false
+	}
+	if err := validator.New().Struct(&subject); err != nil {
+		respondError(w, http.StatusBadRequest, "Validation error: "+err.Error())
+		return authCtx, subject, false
+	}
+	if erase && !strings.HasPrefix(subject.Pseudonym, "erased-") {
+		respondError(w, http.StatusBadRequest, "pseudonym is required")
+		return authCtx, subject, false
+	}
+
+	return authCtx, subject, true
+}
+
+// ExportStudentData returns every submission and evaluation of a student
+func ExportStudentData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r, false)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"student_id": subject.UserID, "institution_id": authCtx.InstitutionID}
+
+	answers := []models.StudentExamAnswer{}
+	cursor, err := db.GetAnswerCollection().Find(ctx, filter)
+	if err == nil {
+		err = cursor.All(ctx, &answers)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch submissions")
+		return
+	}
+
+	evaluations := []models.StudentExamEvaluation{}
+	cursor, err = db.GetEvaluationCollection().Find(ctx, filter)
+	if err == nil {
+		err = cursor.All(ctx, &evaluations)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch evaluations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"submissions": answers,
+		"evaluations": evaluations,
+	})
+}
+
+// EraseStudentData moves a student's submissions and evaluations to their
+// pseudonym and blanks the answer texts and feedback. Marks, options chosen
+// and correctness stay, so exam results and statistics do not change.
+func EraseStudentData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r, true)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"student_id": subject.UserID, "institution_id": authCtx.InstitutionID}
+
+	answers, err := pseudonymise(ctx, db.GetAnswerCollection(), filter, "answers.theory_answers", "answer_text", subject.Pseudonym)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to erase submissions")
+		return
+	}
+
+	evaluations, err := pseudonymise(ctx, db.GetEvaluationCollection(), filter, "evaluation.theory_evaluations", "feedback", subject.Pseudonym)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to erase evaluations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"submissions": answers,
+		"evaluations": evaluations,
+	})
+}
+
+// pseudonymise blanks field in every element of the array at arrayPath, then
+// moves the documents to pseudonym. The text goes first: "$[]" fails on
+// documents without the array, so those are left out of that step, and a
+// retry after a partial failure still finds the rest by the old ID.
+func pseudonymise(ctx context.Context, collection *mongo.Collection, filter bson.M, arrayPath string, field string, pseudonym string) (int64, error) {
+	withArray := bson.M{arrayPath: bson.M{"$type": "array"}}
+	for k, v := range filter {
+		withArray[k] = v
+	}
+	_, err := collection.UpdateMany(ctx, withArray, bson.M{"$set": bson.M{arrayPath + ".$[]." + field: ""}})
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"student_id": pseudonym,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
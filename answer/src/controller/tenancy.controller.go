@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"answer/src/db"
+	"answer/src/middleware"
+	"net/http"
+
+	"neuroiq/shared/tenancy"
+)
+
+// BackfillInstitution assigns answers and evaluations written before tenancy
+// to the institution of the calling token
+func BackfillInstitution(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	tenancy.Serve(w, r, authCtx.InstitutionID, db.AssignInstitution)
+}
@@ -34,4 +34,6 @@ func MongoInit() {
 
 	answerCollection = Client.Database("NeuroIQ_AnswerDB").Collection("answers")
 	evaluationCollection = Client.Database("NeuroIQ_AnswerDB").Collection("evaluations")
-}
\ No newline at end of file
+
+	ensureIndexes(ctx)
+}
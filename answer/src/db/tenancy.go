@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+
+	"neuroiq/shared/tenancy"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AssignInstitution gives the answers and evaluations of owners written
+// before tenancy their institution; both are keyed by the student's user ID
+func AssignInstitution(ctx context.Context, institutionID string, owners tenancy.Owners) (tenancy.Result, error) {
+	var result tenancy.Result
+
+	for _, collection := range []*mongo.Collection{answerCollection, evaluationCollection} {
+		if len(owners.UserIDs) > 0 {
+			res, err := collection.UpdateMany(ctx, tenancy.Unassigned("student_id", owners.UserIDs), tenancy.Assign(institutionID))
+			if err != nil {
+				return result, err
+			}
+			result.Assigned += res.ModifiedCount
+		}
+
+		remaining, err := collection.CountDocuments(ctx, tenancy.Untagged())
+		if err != nil {
+			return result, err
+		}
+		result.Remaining += remaining
+	}
+	return result, nil
+}
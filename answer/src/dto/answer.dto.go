@@ -6,12 +6,21 @@ import (
 )
 
 type AccessClaim struct {
-	ID    string
-	Email string
-	Role  string
+	ID            string
+	Email         string
+	Role          string
+	InstitutionID string
+	Scope         string `json:"scope,omitempty"` // service tokens only, space separated
+	Actor         *ActorClaim `json:"act,omitempty"` // set when an admin impersonates the user
 	jwt.RegisteredClaims
 }
 
+// ActorClaim names the admin holding an impersonation token
+type ActorClaim struct {
+	ID    string `json:"sub"`
+	Email string `json:"email,omitempty"`
+}
+
 
 
 // ============ Error Response ============
@@ -40,8 +49,6 @@ type MCQAnswerInput struct {
 type SubmitExamAnswersRequest struct {
 	ExamID        string             `json:"exam_id" validate:"required"`
 	SessionID     string             `json:"session_id" validate:"required"`
-	Subject       string             `json:"subject"`
-	Semester      string             `json:"semester"`
 	ExamType      string             `json:"exam_type"`
 	TheoryAnswers []TheoryAnswerInput `json:"theory_answers,omitempty"`
 	MCQAnswers    []MCQAnswerInput    `json:"mcq_answers,omitempty"`
@@ -54,3 +61,13 @@ type SubmitExamAnswersResponse struct {
 }
 
 
+
+// ============ Privacy ============
+
+// PrivacySubjectDTO names the user whose data auth exports or erases;
+// pseudonym replaces their ID on erasure
+type PrivacySubjectDTO struct {
+	UserID    string `json:"user_id" validate:"required"`
+	StudentID string `json:"student_id"`
+	Pseudonym string `json:"pseudonym"`
+}
@@ -40,6 +40,9 @@ type EvaluateTheoryRequest struct {
 	QuestionText string `json:"question_text" validate:"required,min=5"`
 	AnswerText   string `json:"answer_text" validate:"required,min=5"`
 	Subject      string `json:"subject" validate:"required"`
+	Branch       string `json:"branch" validate:"required"`
+	Semester     string `json:"semester" validate:"required"`
+	Section      string `json:"section"` // empty for every section
 	MaxMarks     int    `json:"max_marks" validate:"required,gt=0"`
 }
 
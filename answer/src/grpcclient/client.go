@@ -113,6 +113,12 @@ func EvaluateMCQAnswer(
 // InitGRPC initializes the gRPC connection at service startup
 func InitGRPC() error {
 
+	// Every evaluation needs a service token; without credentials the service
+	// would start and then fail each call
+	if _, _, _, err := credentialsEnv(); err != nil {
+		return err
+	}
+
 	addr := GetLLMServiceAddress()
 
 	fmt.Printf("🔗 Initializing gRPC connection to %s\n", addr)
@@ -126,6 +132,7 @@ func InitGRPC() error {
 		ctx,
 		addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(&serviceCredentials{}),
 		grpc.WithBlock(), // IMPORTANT: wait until connection is established
 	)
 
@@ -0,0 +1,100 @@
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scopeLLMEvaluate is the service scope the LLM service requires for evaluation
+const scopeLLMEvaluate = "llm:evaluate"
+
+// serviceCredentials attaches a service token from the auth service's client
+// credentials grant to every call. The token is cached until shortly before
+// it expires.
+type serviceCredentials struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (c *serviceCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.serviceToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity is false because the LLM service is reached over
+// the internal network without TLS
+func (c *serviceCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// credentialsEnv reads the answer service client that auth provisions with
+// `service-client create answer llm:evaluate`
+func credentialsEnv() (authURI, clientID, clientSecret string, err error) {
+	authURI = os.Getenv("AUTH_URI")
+	clientID = os.Getenv("SERVICE_CLIENT_ID")
+	clientSecret = os.Getenv("SERVICE_CLIENT_SECRET")
+	if authURI == "" || clientID == "" || clientSecret == "" {
+		return "", "", "", fmt.Errorf("AUTH_URI, SERVICE_CLIENT_ID and SERVICE_CLIENT_SECRET must be set")
+	}
+	return authURI, clientID, clientSecret, nil
+}
+
+func (c *serviceCredentials) serviceToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	authURI, clientID, clientSecret, err := credentialsEnv()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", scopeLLMEvaluate)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", authURI+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch service token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch service token: auth service returned %s", resp.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("fetch service token: %w", err)
+	}
+
+	// Renew a minute early so a token never expires mid-call
+	c.token = body.AccessToken
+	c.expiresAt = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
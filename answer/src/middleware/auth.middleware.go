@@ -6,19 +6,33 @@ import (
 	"strings"
 
 	jwtutil "answer/src/util"
+	"neuroiq/shared/authctx"
 )
 
 type contextKey string
 
 const AuthKey contextKey = "auth_context"
 
-type AuthContext struct {
-	UserID string
-	Email  string
-	Role   string
-}
+// AuthContext is the caller stored under AuthKey
+type AuthContext = authctx.Context
 
+// AuthMiddleware admits signed-in users; service tokens are refused
 func AuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, func(a AuthContext) bool {
+		return !a.Service
+	})
+}
+
+// ServiceMiddleware admits service tokens granted scope and nothing else
+func ServiceMiddleware(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authenticate(next, func(a AuthContext) bool {
+			return a.Service && a.HasScope(scope)
+		})
+	}
+}
+
+func authenticate(next http.Handler, allowed func(a AuthContext) bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		authHeader := r.Header.Get("Authorization")
@@ -39,10 +53,28 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Every query is scoped to the caller's institution, so a token without one is useless
+		if claims.InstitutionID == "" {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
 		authCtx := AuthContext{
-			UserID: claims.ID,
-			Email:  claims.Email,
-			Role:   claims.Role,
+			UserID:        claims.ID,
+			Email:         claims.Email,
+			Role:          claims.Role,
+			InstitutionID: claims.InstitutionID,
+			Service:       claims.Role == "service",
+			Scopes:        strings.Fields(claims.Scope),
+			ActorID:       actorID(claims),
+		}
+
+		if !allowed(authCtx) {
+			http.Error(w, "Not Authorized for the service", http.StatusUnauthorized)
+			return
+		}
+		if !allowImpersonation(w, r, authCtx, parts[1]) {
+			return
 		}
 
 		ctx := context.WithValue(r.Context(), AuthKey, authCtx)
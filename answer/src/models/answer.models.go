@@ -11,9 +11,12 @@ type StudentExamAnswer struct {
 	ExamID        primitive.ObjectID `bson:"exam_id" json:"exam_id"`
 	StudentID     string             `bson:"student_id" json:"student_id"`
 	ExamSessionID string             `bson:"exam_session_id" json:"exam_session_id"`
+	InstitutionID string             `bson:"institution_id" json:"institution_id"`
 
 	Subject  string `bson:"subject" json:"subject"`
+	Branch   string `bson:"branch,omitempty" json:"branch,omitempty"`
 	Semester string `bson:"semester" json:"semester"`
+	Section  string `bson:"section,omitempty" json:"section,omitempty"` // empty for every section
 	ExamType string `bson:"exam_type" json:"exam_type"`
 
 	Answers AnswerSection `bson:"answers" json:"answers"`
@@ -3,6 +3,7 @@ package routes
 import (
 	"answer/src/controller"
 	"answer/src/middleware"
+	"neuroiq/shared/authctx"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -38,5 +39,14 @@ func SetupAnswerRoutes() chi.Router {
 		
 	})
 
+	// Called by auth to export or erase a student's data
+	r.Group(func(internal chi.Router) {
+		internal.With(middleware.ServiceMiddleware(authctx.ScopePrivacyExport)).Post("/internal/privacy/export", controller.ExportStudentData)
+		internal.With(middleware.ServiceMiddleware(authctx.ScopePrivacyErase)).Post("/internal/privacy/erase", controller.EraseStudentData)
+	})
+
+	// Called by auth to assign records written before tenancy
+	r.With(middleware.ServiceMiddleware(authctx.ScopeTenancyBackfill)).Post("/internal/tenancy/backfill", controller.BackfillInstitution)
+
 	return r
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrExamNotFound means the question service has no such exam for the caller
+var ErrExamNotFound = errors.New("exam not found")
+
+var examClient = &http.Client{Timeout: 5 * time.Second}
+
+// ExamCourse is the course an exam was generated for in the question service
+type ExamCourse struct {
+	Subject  string `json:"subject"`
+	Branch   string `json:"branch"`
+	Semester string `json:"semester"`
+	Section  string `json:"section"`
+}
+
+// GetExamCourse asks QUESTION_URI for exam examID with the caller's
+// Authorization header, so the exam is looked up within their institution
+func GetExamCourse(ctx context.Context, authorization string, examID string) (*ExamCourse, error) {
+	base := strings.TrimSuffix(os.Getenv("QUESTION_URI"), "/")
+	if base == "" {
+		return nil, fmt.Errorf("QUESTION_URI not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", base+"/exam/"+url.PathEscape(examID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := examClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusBadRequest:
+		return nil, ErrExamNotFound
+	default:
+		return nil, fmt.Errorf("exam request returned %s", resp.Status)
+	}
+
+	var body struct {
+		Exam ExamCourse `json:"exam"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &body.Exam, nil
+}
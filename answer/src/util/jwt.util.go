@@ -3,7 +3,7 @@ package utils
 import (
 	"answer/src/dto"
 	"fmt"
-	"os"
+	"neuroiq/shared/jwks"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -11,26 +11,25 @@ import (
 
 
 func ValidateToken(tokenString string) (*dto.AccessClaim, error) {
-	secret := os.Getenv("JWT_ACCESS_SECRET")
-	if secret == "" {
-		return nil, fmt.Errorf("missing JWT_ACCESS_SECRET")
-	}
-
 	var claim dto.AccessClaim
 
+	// Parse the token into our custom claim struct
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&claim,
 		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			// Check if signing method is RS256
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
 				return nil, fmt.Errorf("invalid signing method")
 			}
-			return []byte(secret), nil
+			kid, _ := t.Header["kid"].(string)
+			return jwks.PublicKey(kid)
 		},
+		jwt.WithValidMethods([]string{"RS256"}),
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, err // parsing or validation failed
 	}
 
 	if !token.Valid {
@@ -38,4 +37,4 @@ func ValidateToken(tokenString string) (*dto.AccessClaim, error) {
 	}
 
 	return &claim, nil
-}
\ No newline at end of file
+}
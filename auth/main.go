@@ -2,7 +2,10 @@ package main
 
 import (
 	"auth/src/db"
+	"auth/src/jwtutil"
+	"auth/src/mailer"
 	"auth/src/routes"
+	"auth/src/service"
 	"log"
 	"net/http"
 	"os"
@@ -18,7 +21,41 @@ func main() {
 	// 	log.Fatal("⚠️ Error loading .env file:", err)
 	// }
 
+	// `auth migrate up|down [steps]|status` manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db.Connect()
+		if err := db.RunMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatal("❌ Migration failed: ", err)
+		}
+		return
+	}
+
+	// `auth service-client create <name> <scope>...` provisions a service
+	// client, e.g. answer's, and exits
+	if len(os.Args) > 1 && os.Args[1] == "service-client" {
+		db.ConnectDB()
+		if err := service.RunServiceClientCommand(os.Args[2:]); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		return
+	}
+
+	// `auth institution create <code> <name> --admin-email <email>` creates an
+	// institution and its first admin, bootstrapping an empty database;
+	// `auth institution backfill` assigns the services' pre-tenancy records to
+	// their owners' institutions. Both exit when done.
+	if len(os.Args) > 1 && os.Args[1] == "institution" {
+		db.ConnectDB()
+		if err := service.RunInstitutionCommand(os.Args[2:]); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		return
+	}
+
 	db.ConnectDB()
+	jwtutil.InitSigningKeys()
+	jwtutil.StartKeyRotation()
+	mailer.InitMailer()
 
 	router := chi.NewRouter()
 
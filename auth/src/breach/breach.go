@@ -0,0 +1,126 @@
+// Package breach tells whether a password is known from public breaches,
+// without network access. A short list of the most common passwords is
+// compiled in; PASSWORD_BREACH_FILE can point at a much larger list in the
+// Pwned Passwords format: one "SHA1HEX:count" per line, sorted by hash
+// (the count is optional). The file is searched in place, so multi-gigabyte
+// downloads work without loading them into memory.
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+//go:embed common_passwords.sha1
+var commonPasswords string
+
+var (
+	commonOnce sync.Once
+	common     map[string]bool
+)
+
+// hashLen is the length of an upper-case hex SHA-1
+const hashLen = 40
+
+// Breached reports whether password appears in the bundled list or in
+// PASSWORD_BREACH_FILE. A missing or unreadable file only skips that check.
+func Breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	commonOnce.Do(func() {
+		common = map[string]bool{}
+		for _, line := range strings.Fields(commonPasswords) {
+			common[line] = true
+		}
+	})
+	if common[hash] {
+		return true, nil
+	}
+
+	path := os.Getenv("PASSWORD_BREACH_FILE")
+	if path == "" {
+		return false, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return searchSorted(f, info.Size(), hash)
+}
+
+// searchSorted binary searches a file of lines sorted by their leading hash.
+// lo always sits on a line start; a matching line must start in [lo, hi).
+func searchSorted(r io.ReaderAt, size int64, hash string) (bool, error) {
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		line, start, err := lineAfter(r, size, mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi || line == "" {
+			// No line starts in [mid, hi); look before mid
+			hi = mid
+			continue
+		}
+
+		switch key := lineHash(line); {
+		case key == hash:
+			return true, nil
+		case key < hash:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineAfter returns the first full line starting at or after offset (the
+// line at offset 0 counts as starting there) and where it starts
+func lineAfter(r io.ReaderAt, size int64, offset int64) (string, int64, error) {
+	start := offset
+	if offset > 0 {
+		// Skip the rest of the line offset falls into
+		reader := bufio.NewReader(io.NewSectionReader(r, offset-1, size-offset+1))
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return "", size, nil
+		}
+		if err != nil {
+			return "", 0, err
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+	if start >= size {
+		return "", size, nil
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(r, start, size-start))
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	return strings.TrimRight(line, "\r\n"), start, nil
+}
+
+func lineHash(line string) string {
+	if len(line) > hashLen {
+		line = line[:hashLen]
+	}
+	return strings.ToUpper(line)
+}
@@ -0,0 +1,102 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachFile writes passwords in the Pwned Passwords format, sorted by hash
+func writeBreachFile(t *testing.T, passwords []string, lineEnd string) string {
+	t.Helper()
+	var lines []string
+	for i, p := range passwords {
+		line := sha1Hex(p)
+		if i%2 == 0 {
+			line += ":" + strings.Repeat("7", i+1) // counts of varying length
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, lineEnd)+lineEnd), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreachedFile(t *testing.T) {
+	var listed []string
+	for _, w := range strings.Fields("amber basalt cobalt dune ember fjord garnet harbor indigo jasper") {
+		listed = append(listed, "Zq-"+w+"-2931")
+	}
+
+	for _, lineEnd := range []string{"\n", "\r\n"} {
+		t.Setenv("PASSWORD_BREACH_FILE", writeBreachFile(t, listed, lineEnd))
+
+		// Every entry is found, wherever it sorts: first, last and between
+		for _, p := range listed {
+			if ok, err := Breached(p); err != nil || !ok {
+				t.Errorf("Breached(%q) = %v, %v; want listed", p, ok, err)
+			}
+		}
+		for _, p := range []string{"Zq-onyx-2931", "Zq-amber-2932", ""} {
+			if ok, err := Breached(p); err != nil || ok {
+				t.Errorf("Breached(%q) = %v, %v; want not listed", p, ok, err)
+			}
+		}
+	}
+}
+
+func TestBreachedBundledList(t *testing.T) {
+	t.Setenv("PASSWORD_BREACH_FILE", "")
+	if ok, _ := Breached("password1"); !ok {
+		t.Error("password1 not in the bundled list")
+	}
+	if ok, _ := Breached("Zq-amber-2931"); ok {
+		t.Error("unlisted password reported breached")
+	}
+}
+
+func TestBreachedMissingFile(t *testing.T) {
+	t.Setenv("PASSWORD_BREACH_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	if ok, err := Breached("Zq-amber-2931"); ok || err == nil {
+		t.Fatalf("Breached = %v, %v; want false with an error", ok, err)
+	}
+}
+
+func TestSearchSortedEdges(t *testing.T) {
+	a, b := strings.Repeat("A", hashLen), strings.Repeat("B", hashLen)
+	tests := []struct {
+		name    string
+		content string
+		hash    string
+		want    bool
+	}{
+		{"empty file", "", a, false},
+		{"single line without newline", a, a, true},
+		{"lowercase hash in file", strings.ToLower(b) + ":3\n", b, true},
+		{"before first", b + "\n", a, false},
+		{"after last", a + "\n", b, false},
+		{"blank trailing lines", a + "\n" + b + "\n\n", b, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := strings.NewReader(tt.content)
+			got, err := searchSorted(r, int64(len(tt.content)), tt.hash)
+			if err != nil || got != tt.want {
+				t.Fatalf("searchSorted = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
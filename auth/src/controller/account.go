@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/mailer"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 24 * time.Hour
+)
+
+// emailVerificationRequired is on unless REQUIRE_EMAIL_VERIFICATION=false
+func emailVerificationRequired() bool {
+	return os.Getenv("REQUIRE_EMAIL_VERIFICATION") != "false"
+}
+
+// issueMailedToken stores a new single-use token for user and returns the raw value
+func issueMailedToken(userID string, purpose string, ttl time.Duration) (string, error) {
+	raw, err := jwtutil.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = repository.CreateUserToken(&models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: jwtutil.HashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func sendVerificationEmail(user *models.User) error {
+	token, err := issueMailedToken(user.ID, models.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your NeuroIQ email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm your email address to activate your NeuroIQ account:\n\n%s\n\nThis link expires in 24 hours.\n",
+			user.Name, service.AppLink("/verify-email", token),
+		),
+	})
+}
+
+func sendPasswordResetEmail(user *models.User) error {
+	token, err := issueMailedToken(user.ID, models.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your NeuroIQ password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password:\n\n%s\n\nThis link expires in 1 hour. If you did not ask for a reset, ignore this email.\n",
+			user.Name, service.AppLink("/reset-password", token),
+		),
+	})
+}
+
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.EmailRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if wait := service.MailRetryAfter(req.Email, clientIP(r)); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	// Same answer whether or not the email exists, and mail is sent off the
+	// request path, so the endpoint cannot be used to enumerate accounts
+	user, _ := repository.GetUserByEmail(req.Email)
+	if user != nil && user.DeletedAt == nil {
+		go func() {
+			if err := sendPasswordResetEmail(user); err != nil {
+				log.Printf("failed to send password reset email: %v", err)
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the email is registered, a reset link has been sent",
+	})
+}
+
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	setPasswordWithToken(w, r, models.TokenPurposePasswordReset, "Invalid or expired reset token", "Password reset successfully")
+}
+
+// AcceptInvite sets the first password of an account created by a roster import
+func AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	setPasswordWithToken(w, r, models.TokenPurposeAccountInvite, "Invalid or expired invite token", "Account activated successfully")
+}
+
+// setPasswordWithToken consumes a mailed token of the given purpose and sets the owner's password
+func setPasswordWithToken(w http.ResponseWriter, r *http.Request, purpose string, invalidMsg string, successMsg string) {
+	var req dto.ResetPasswordDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Check the password before spending the token, so a refused password
+	// can be retried with the same link
+	tokenHash := jwtutil.HashToken(req.Token)
+	userID, err := repository.GetUserTokenOwner(tokenHash, purpose)
+	if err != nil {
+		http.Error(w, invalidMsg, http.StatusBadRequest)
+		return
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil || user.DeletedAt != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err := service.CheckPassword(user, req.NewPassword); err != nil {
+		passwordRefused(w, err)
+		return
+	}
+
+	if _, err := repository.ConsumeUserToken(tokenHash, purpose); err != nil {
+		http.Error(w, invalidMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := service.SavePassword(user, req.NewPassword); err != nil {
+		http.Error(w, "Failed to update password", http.StatusInternalServerError)
+		return
+	}
+
+	if user.PasswordResetRequired {
+		if err := repository.SetPasswordResetRequired(user.ID, false); err != nil {
+			http.Error(w, "Failed to update password", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// The reset link reached the inbox, so the address is proven too
+	if err := repository.MarkEmailVerified(user.ID); err != nil {
+		log.Printf("failed to mark email verified: %v", err)
+	}
+
+	// Sessions opened with the old password should not survive the reset
+	if err := repository.RevokeUserRefreshTokens(user.ID); err != nil {
+		log.Printf("failed to revoke refresh tokens after reset: %v", err)
+	}
+	recordEvent(r, userEvent(user, models.EventPasswordSet, models.OutcomeSuccess, purpose))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": successMsg,
+	})
+}
+
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req dto.VerifyEmailDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := repository.ConsumeUserToken(jwtutil.HashToken(req.Token), models.TokenPurposeEmailVerification)
+	if err != nil {
+		http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	if err := repository.MarkEmailVerified(userID); err != nil {
+		http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Email verified successfully",
+	})
+}
+
+func ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var req dto.EmailRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if wait := service.MailRetryAfter(req.Email, clientIP(r)); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	user, _ := repository.GetUserByEmail(req.Email)
+	if user != nil && user.EmailVerifiedAt == nil {
+		go func() {
+			if err := sendVerificationEmail(user); err != nil {
+				log.Printf("failed to send verification email: %v", err)
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the account is awaiting verification, a new link has been sent",
+	})
+}
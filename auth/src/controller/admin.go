@@ -0,0 +1,340 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// tenantUser loads a user of the caller's institution and writes 404 for
+// anyone else, so admins cannot act on (or probe) other institutions' users
+func tenantUser(w http.ResponseWriter, r *http.Request, userID string) (*models.User, bool) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil || user.InstitutionID != authData.InstitutionID {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// UnlockUser clears login lockouts for a user and reactivates a blocked account
+func UnlockUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := tenantUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	if err := service.ResetLoginFailures(user.Email); err != nil {
+		http.Error(w, "Failed to clear login failures", http.StatusInternalServerError)
+		return
+	}
+
+	if user.Status == models.StatusBlocked {
+		if err := repository.SetUserStatus(user.ID, models.StatusActive); err != nil {
+			http.Error(w, "Failed to update user status", http.StatusInternalServerError)
+			return
+		}
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventUserUnlock, TargetID: user.ID})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User unlocked successfully",
+	})
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pagination reads ?page= and ?limit= (1-based page)
+func pagination(r *http.Request) (page int, limit int) {
+	page, _ = strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return page, limit
+}
+
+func toAdminUserResponse(u models.User) dto.AdminUserResponseDTO {
+	return dto.AdminUserResponseDTO{
+		ID:                    u.ID,
+		Name:                  u.Name,
+		Email:                 u.Email,
+		Role:                  u.Role,
+		Institution:           u.Institution,
+		InstitutionID:         u.InstitutionID,
+		Status:                u.Status,
+		EmailVerifiedAt:       u.EmailVerifiedAt,
+		PasswordResetRequired: u.PasswordResetRequired,
+		DeletedAt:             u.DeletedAt,
+		CreatedAt:             u.CreatedAt,
+		UpdatedAt:             u.UpdatedAt,
+	}
+}
+
+// ListUsers supports ?role=&status=&q=&include_deleted=&page=&limit= within the admin's institution
+func ListUsers(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	page, limit := pagination(r)
+	includeDeleted, _ := strconv.ParseBool(q.Get("include_deleted"))
+
+	users, total, err := repository.ListUsers(repository.UserFilter{
+		InstitutionID:  authData.InstitutionID,
+		Role:           q.Get("role"),
+		Status:         q.Get("status"),
+		Search:         q.Get("q"),
+		IncludeDeleted: includeDeleted,
+		Limit:          limit,
+		Offset:         (page - 1) * limit,
+	})
+	if err != nil {
+		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]dto.AdminUserResponseDTO, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, toAdminUserResponse(u))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"users": resp,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// ListStudents supports ?branch=&semester=&section=&academic_status=&active=&q=&include_deleted=&page=&limit= within the admin's institution
+func ListStudents(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	page, limit := pagination(r)
+	includeDeleted, _ := strconv.ParseBool(q.Get("include_deleted"))
+
+	filter := repository.StudentFilter{
+		InstitutionID:  authData.InstitutionID,
+		Branch:         q.Get("branch"),
+		Section:        q.Get("section"),
+		AcademicStatus: q.Get("academic_status"),
+		Search:         q.Get("q"),
+		IncludeDeleted: includeDeleted,
+		Limit:          limit,
+		Offset:         (page - 1) * limit,
+	}
+	if v := q.Get("semester"); v != "" {
+		semester, err := strconv.Atoi(v)
+		if err != nil || semester < 1 || semester > 8 {
+			http.Error(w, "semester must be between 1 and 8", http.StatusBadRequest)
+			return
+		}
+		filter.Semester = semester
+	}
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "active must be true or false", http.StatusBadRequest)
+			return
+		}
+		filter.Active = &active
+	}
+
+	students, total, err := repository.ListStudents(filter)
+	if err != nil {
+		http.Error(w, "Failed to fetch students", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"students": students,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// BulkSetUserStatus activates or deactivates many users; deactivated users lose their sessions
+func BulkSetUserStatus(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.BulkUserStatusDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, id := range req.UserIDs {
+		if id == authData.UserID {
+			http.Error(w, "Forbidden: cannot change your own status", http.StatusForbidden)
+			return
+		}
+	}
+
+	updated, err := repository.SetUsersStatus(authData.InstitutionID, req.UserIDs, req.Status)
+	if err != nil {
+		http.Error(w, "Failed to update user status", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Status == models.StatusInactive {
+		for _, id := range updated {
+			if err := repository.RevokeUserRefreshTokens(id); err != nil {
+				log.Printf("failed to revoke refresh tokens for %s: %v", id, err)
+			}
+		}
+	}
+	for _, id := range updated {
+		recordEvent(r, models.AuthEvent{EventType: models.EventUserStatus, TargetID: id, Detail: req.Status})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "User status updated successfully",
+		"updated": updated,
+	})
+}
+
+// DeleteUser soft-deletes a user and their student record
+func DeleteUser(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID := chi.URLParam(r, "id")
+	if userID == authData.UserID {
+		http.Error(w, "Forbidden: cannot delete your own account", http.StatusForbidden)
+		return
+	}
+
+	if _, ok := tenantUser(w, r, userID); !ok {
+		return
+	}
+
+	if err := repository.SoftDeleteUser(userID); err != nil {
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := repository.RevokeUserRefreshTokens(userID); err != nil {
+		log.Printf("failed to revoke refresh tokens after delete: %v", err)
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventUserDelete, TargetID: userID})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User deleted successfully",
+	})
+}
+
+// DeleteStudent soft-deletes a student together with their login
+func DeleteStudent(w http.ResponseWriter, r *http.Request) {
+	student, err := repository.GetStudentByID(chi.URLParam(r, "id"))
+	if err != nil || student.UserID == "" {
+		http.Error(w, "Student not found", http.StatusNotFound)
+		return
+	}
+
+	// The login decides which institution the student belongs to
+	if _, ok := tenantUser(w, r, student.UserID); !ok {
+		return
+	}
+
+	err = repository.SoftDeleteUser(student.UserID)
+	if err == nil {
+		err = repository.RevokeUserRefreshTokens(student.UserID)
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete student", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventStudentDelete, TargetID: student.ID})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Student deleted successfully",
+	})
+}
+
+// ForcePasswordReset blocks login until the user sets a new password through the emailed link
+func ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	user, ok := tenantUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	if user.DeletedAt != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err := repository.SetPasswordResetRequired(user.ID, true); err != nil {
+		http.Error(w, "Failed to flag password reset", http.StatusInternalServerError)
+		return
+	}
+
+	if err := repository.RevokeUserRefreshTokens(user.ID); err != nil {
+		log.Printf("failed to revoke refresh tokens after forced reset: %v", err)
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventPasswordResetForced, TargetID: user.ID})
+
+	go func() {
+		if err := sendPasswordResetEmail(user); err != nil {
+			log.Printf("failed to send password reset email: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Password reset required; a reset link has been sent to the user",
+	})
+}
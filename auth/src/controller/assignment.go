@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// decodeAssignment validates an assignment body and checks that the teacher
+// is an active teacher of the admin's institution
+func decodeAssignment(w http.ResponseWriter, r *http.Request, a *models.TeachingAssignment) bool {
+	var req dto.AssignmentDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if req.AcademicYear == "" {
+		req.AcademicYear = service.CurrentAcademicYear(time.Now())
+	}
+	if !service.ValidAcademicYear(req.AcademicYear) {
+		http.Error(w, "academic_year must look like 2025-26", http.StatusBadRequest)
+		return false
+	}
+
+	teacher, ok := tenantUser(w, r, req.TeacherID)
+	if !ok {
+		return false
+	}
+	if teacher.Role != models.RoleTeacher || teacher.Status != models.StatusActive {
+		http.Error(w, "User is not an active teacher", http.StatusBadRequest)
+		return false
+	}
+
+	a.TeacherID = req.TeacherID
+	a.Subject = strings.TrimSpace(req.Subject)
+	a.Branch = req.Branch
+	a.Semester = req.Semester
+	a.Section = strings.TrimSpace(req.Section)
+	a.AcademicYear = req.AcademicYear
+
+	exists, err := repository.AssignmentExists(a)
+	if err != nil {
+		http.Error(w, "Failed to check assignments", http.StatusInternalServerError)
+		return false
+	}
+	if exists {
+		http.Error(w, "Teacher is already assigned to this course", http.StatusConflict)
+		return false
+	}
+
+	return true
+}
+
+// assignmentEvent describes an assignment change for the audit log
+func assignmentEvent(a *models.TeachingAssignment, action string) models.AuthEvent {
+	return models.AuthEvent{
+		EventType: models.EventAssignmentChange,
+		TargetID:  a.ID,
+		Detail: fmt.Sprintf("%s: teacher %s, %s %s semester %d %s", action, a.TeacherID,
+			a.Subject, a.Branch, a.Semester, a.AcademicYear),
+	}
+}
+
+// CreateAssignment assigns a teacher a subject of one cohort
+func CreateAssignment(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	assignment := models.TeachingAssignment{
+		InstitutionID: authData.InstitutionID,
+		CreatedBy:     authData.UserID,
+	}
+	if !decodeAssignment(w, r, &assignment) {
+		return
+	}
+
+	if err := repository.CreateAssignment(&assignment); err != nil {
+		http.Error(w, "Failed to save assignment", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, assignmentEvent(&assignment, "created"))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Assignment created successfully",
+		"assignment": assignment,
+	})
+}
+
+// ListAssignments supports ?teacher_id=&subject=&branch=&semester=&academic_year=&page=&limit= within the admin's institution
+func ListAssignments(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	page, limit := pagination(r)
+
+	filter := repository.AssignmentFilter{
+		InstitutionID: authData.InstitutionID,
+		TeacherID:     q.Get("teacher_id"),
+		Subject:       q.Get("subject"),
+		Branch:        q.Get("branch"),
+		AcademicYear:  q.Get("academic_year"),
+		Limit:         limit,
+		Offset:        (page - 1) * limit,
+	}
+	if v := q.Get("semester"); v != "" {
+		semester, err := strconv.Atoi(v)
+		if err != nil || semester < 1 || semester > 8 {
+			http.Error(w, "semester must be between 1 and 8", http.StatusBadRequest)
+			return
+		}
+		filter.Semester = semester
+	}
+
+	assignments, total, err := repository.ListAssignments(filter)
+	if err != nil {
+		http.Error(w, "Failed to fetch assignments", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"assignments": assignments,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// UpdateAssignment replaces the teacher or course of an assignment
+func UpdateAssignment(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	assignment, err := repository.GetAssignmentByID(authData.InstitutionID, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Assignment not found", http.StatusNotFound)
+		return
+	}
+	if !decodeAssignment(w, r, assignment) {
+		return
+	}
+
+	if err := repository.UpdateAssignment(assignment); err != nil {
+		http.Error(w, "Failed to update assignment", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, assignmentEvent(assignment, "updated"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Assignment updated successfully",
+		"assignment": assignment,
+	})
+}
+
+// DeleteAssignment removes an assignment; the teacher loses access to the course
+func DeleteAssignment(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	assignment, err := repository.GetAssignmentByID(authData.InstitutionID, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Assignment not found", http.StatusNotFound)
+		return
+	}
+
+	if err := repository.DeleteAssignment(authData.InstitutionID, assignment.ID); err != nil {
+		http.Error(w, "Failed to delete assignment", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, assignmentEvent(assignment, "deleted"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Assignment deleted successfully",
+	})
+}
+
+// GetMyAssignments lists the caller's assignments for ?academic_year= (default
+// the current one). Other services call it with the user's token to decide
+// which courses a teacher may manage.
+func GetMyAssignments(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	academicYear := r.URL.Query().Get("academic_year")
+	if academicYear == "" {
+		academicYear = service.CurrentAcademicYear(time.Now())
+	}
+	if !service.ValidAcademicYear(academicYear) {
+		http.Error(w, "academic_year must look like 2025-26", http.StatusBadRequest)
+		return
+	}
+
+	assignments, err := repository.GetTeacherAssignments(authData.UserID, academicYear)
+	if err != nil {
+		http.Error(w, "Failed to fetch assignments", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"academic_year": academicYear,
+		"assignments":   assignments,
+	})
+}
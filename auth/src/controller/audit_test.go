@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"auth/src/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEventTime(t *testing.T) {
+	cases := []struct {
+		value    string
+		endOfDay bool
+		want     string
+	}{
+		{"2026-03-04", false, "2026-03-04T00:00:00Z"},
+		{"2026-03-04", true, "2026-03-05T00:00:00Z"},
+		{"2026-12-31", true, "2027-01-01T00:00:00Z"},
+		{"2026-03-04T10:30:00Z", true, "2026-03-04T10:30:00Z"},
+		{"2026-03-04T10:30:00+05:30", false, "2026-03-04T05:00:00Z"},
+	}
+	for _, c := range cases {
+		got, ok := parseEventTime(c.value, c.endOfDay)
+		if !ok || got == nil {
+			t.Errorf("parseEventTime(%q, %v) failed", c.value, c.endOfDay)
+			continue
+		}
+		if s := got.UTC().Format(time.RFC3339); s != c.want {
+			t.Errorf("parseEventTime(%q, %v) = %s, want %s", c.value, c.endOfDay, s, c.want)
+		}
+	}
+
+	if got, ok := parseEventTime("", true); !ok || got != nil {
+		t.Errorf("empty value = %v, %v", got, ok)
+	}
+	if _, ok := parseEventTime("04/03/2026", false); ok {
+		t.Error("accepted a date in another layout")
+	}
+}
+
+func TestAuthEventRecord(t *testing.T) {
+	e := models.AuthEvent{
+		CreatedAt:  time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC),
+		EventType:  models.EventServiceToken,
+		Outcome:    models.OutcomeFailure,
+		ActorID:    "=HYPERLINK(\"x\")",
+		ActorEmail: "+a@b.test",
+		TargetID:   "-1",
+		Detail:     "@SUM(A1)",
+		IPAddress:  "=1+1",
+		UserAgent:  "\tcurl",
+	}
+	got := authEventRecord(e)
+	want := []string{
+		"2026-03-04T10:30:00Z", models.EventServiceToken, models.OutcomeFailure, "'=HYPERLINK(\"x\")", "'+a@b.test",
+		"'-1", "'@SUM(A1)", "'=1+1", "'\tcurl",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("authEventRecord = %q, want %q", got, want)
+	}
+}
+
+func TestRejectedClientID(t *testing.T) {
+	if got := rejectedClientID("svc-answer"); got != `"svc-answer"` {
+		t.Errorf("short id = %s", got)
+	}
+	if got := rejectedClientID("a\nb"); got != `"a\nb"` {
+		t.Errorf("control characters = %s", got)
+	}
+	long := strings.Repeat("é", maxRejectedClientID+10)
+	if got := rejectedClientID(long); got != strconv.Quote(strings.Repeat("é", maxRejectedClientID)+"…") {
+		t.Errorf("long id = %s", got)
+	}
+}
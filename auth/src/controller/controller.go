@@ -6,14 +6,21 @@ import (
 	"auth/src/middleware"
 	"auth/src/models"
 	"auth/src/repository"
+	"auth/src/service"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -40,32 +47,67 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err := service.CheckPassword(&models.User{Name: user.Name, Email: user.Email}, user.Password); err != nil {
+		passwordRefused(w, err)
+		return
+	}
+	passwordHash, err := service.HashPassword(user.Password)
 	if err != nil {
 		http.Error(w, "failed to hash password", http.StatusInternalServerError)
 		return
 	}
-	user.Password = string(passwordHash)
+	user.Password = passwordHash
+
+	// Self-service signup is student only; other roles need an admin-issued invite
+	if user.Role == "" {
+		user.Role = models.RoleStudent
+	}
 
 	userDB := models.User{
 		Name:         user.Name,
 		Email:        user.Email,
 		PasswordHash: user.Password,
 		Role:         user.Role,
-		Institution:  user.Institution,
 	}
 
-	err = repository.CreateUser(&userDB)
+	if user.Role == models.RoleStudent {
+		// Students join an existing institution; they cannot create one
+		institution, lookupErr := repository.FindActiveInstitution(user.Institution)
+		if lookupErr != nil {
+			http.Error(w, "Unknown institution: "+user.Institution, http.StatusBadRequest)
+			return
+		}
+		userDB.Institution = institution.Name
+		userDB.InstitutionID = institution.ID
+		err = repository.CreateUser(&userDB)
+	} else {
+		err = repository.CreateUserWithInvite(&userDB, jwtutil.HashToken(user.InviteCode))
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Invalid or expired invite code for role "+user.Role, http.StatusForbidden)
+			return
+		}
+	}
 	if err != nil {
 		http.Error(w, "failed to save user data in db", http.StatusInternalServerError)
 		return
 	}
+	if err := repository.AddPasswordHistory(userDB.ID, userDB.PasswordHash, service.CurrentPasswordPolicy().History); err != nil {
+		log.Printf("failed to record password history: %v", err)
+	}
+
+	// Mail is sent off the request path, so a slow or down relay neither
+	// delays nor fails signup; the user can ask for a resend
+	go func() {
+		if err := sendVerificationEmail(&userDB); err != nil {
+			log.Printf("failed to send verification email: %v", err)
+		}
+	}()
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated) // 201 Created
 
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "User data saved successfully",
+		"message": "User data saved successfully, check your email to verify the account",
 	})
 
 }
@@ -100,6 +142,16 @@ func RegisterStudent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A login registers once; branch, semester and section are then managed by admins
+	if registered, _ := repository.GetStudentByUserID(authData.UserID); registered != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict) // 409 Conflict
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Student details already registered",
+		})
+		return
+	}
+
 	// Check if roll number already exists
 	existingStudent, _ := repository.GetStudentByRoll(student.RollNumber)
 	if existingStudent != nil {
@@ -185,14 +237,14 @@ func UpdateStudentProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if student profile exists
-	_, err := repository.GetStudentByUserID(authData.UserID)
+	student, err := repository.GetStudentByUserID(authData.UserID)
 	if err != nil {
 		http.Error(w, "Student not found", http.StatusNotFound)
 		return
 	}
 
-	// 2. Decode request body
-	var studentUpdate dto.StudentRegisterDTO
+	// 2. Decode request body; academic fields are locked and only changed by admins
+	var studentUpdate dto.StudentProfileUpdateDTO
 	if err := json.NewDecoder(r.Body).Decode(&studentUpdate); err != nil {
 		http.Error(w, "Failed to decode student update details", http.StatusBadRequest)
 		return
@@ -205,27 +257,13 @@ func UpdateStudentProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	studentUpdateData := models.Student{
-		FirstName:    studentUpdate.FirstName,
-		LastName:     studentUpdate.LastName,
-		RollNumber:   studentUpdate.RollNumber,
-		EnrollmentNo: studentUpdate.EnrollmentNo,
-		Branch:       studentUpdate.Branch,
-		Semester:     studentUpdate.Semester,
-		Section:      studentUpdate.Section,
-		Email:        studentUpdate.Email,
-		Phone:        studentUpdate.Phone,
-		UserID:       authData.UserID, // Set UserID for WHERE clause
-		Active:       true,
-		UpdatedAt:    time.Now(),
-	}
-
 	// 4. Update student in DB (uses user_id in WHERE clause)
-	err = repository.UpdateStudent(authData.UserID, &studentUpdateData)
+	err = repository.UpdateStudentContact(authData.UserID, studentUpdate.FirstName, studentUpdate.LastName, studentUpdate.Phone)
 	if err != nil {
 		http.Error(w, "Failed to update student profile", http.StatusInternalServerError)
 		return
 	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventStudentProfileUpdate, TargetID: student.ID})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -243,9 +281,20 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Throttle before doing any bcrypt work
+	ip := clientIP(r)
+	if wait := service.LoginRetryAfter(req.Email, ip); wait > 0 {
+		recordEvent(r, models.AuthEvent{EventType: models.EventLogin, Outcome: models.OutcomeFailure, ActorEmail: req.Email, Detail: "throttled"})
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+
 	// Fetch user by email
 	user, err := repository.GetUserByEmail(req.Email)
 	if err != nil || user == nil {
+		service.RecordLoginFailure(req.Email, ip)
+		recordEvent(r, models.AuthEvent{EventType: models.EventLogin, Outcome: models.OutcomeFailure, ActorEmail: req.Email, Detail: "unknown_email"})
 		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
 		return
 	}
@@ -253,21 +302,90 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	// Compare password hash
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
+		service.RecordLoginFailure(req.Email, ip)
+		recordEvent(r, userEvent(user, models.EventLogin, models.OutcomeFailure, "bad_password"))
 		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
 		return
 	}
+	if err := service.ResetLoginFailures(req.Email); err != nil {
+		log.Printf("failed to reset login failures: %v", err)
+	}
+	// Hashes made before BCRYPT_COST was raised are upgraded while the plain password is at hand
+	service.UpgradePasswordHash(user, req.Password)
+
+	if user.Status != models.StatusActive {
+		recordEvent(r, userEvent(user, models.EventLogin, models.OutcomeFailure, "account_"+user.Status))
+		http.Error(w, "Account is "+user.Status, http.StatusForbidden)
+		return
+	}
+
+	if user.PasswordResetRequired {
+		recordEvent(r, userEvent(user, models.EventLogin, models.OutcomeFailure, "password_reset_required"))
+		http.Error(w, "Password reset required, check your email for the reset link", http.StatusForbidden)
+		return
+	}
+
+	if user.EmailVerifiedAt == nil && emailVerificationRequired() {
+		recordEvent(r, userEvent(user, models.EventLogin, models.OutcomeFailure, "email_unverified"))
+		http.Error(w, "Email not verified", http.StatusForbidden)
+		return
+	}
+
+	continueLogin(w, r, user)
+}
+
+// continueLogin finishes a login whose first factor (password or SSO) passed:
+// it either starts the MFA challenge or issues the token pair
+func continueLogin(w http.ResponseWriter, r *http.Request, user *models.User) {
+	mfa, err := repository.GetUserMFA(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load MFA settings", http.StatusInternalServerError)
+		return
+	}
+	if mfa.Enabled() || service.MFARequired(user.Role) {
+		startMFAChallenge(w, user, mfa)
+		return
+	}
+
+	issueLoginTokens(w, r, user, nil)
+}
+
+// issueLoginTokens finishes a successful login; extra fields are merged into the response
+func issueLoginTokens(w http.ResponseWriter, r *http.Request, user *models.User, extra map[string]interface{}) {
+	// Students may be capped to a few devices while their exam runs. The cap
+	// fails open: if management cannot be asked the login goes ahead, since
+	// locking a student out of a running exam is worse than an extra device.
+	// Such logins are logged and audited as exam_session_limit_unchecked.
+	loginDetail := ""
+	limited, err := service.ExamSessionLimitReached(r.Context(), user)
+	if err != nil {
+		log.Printf("⚠️ Exam session limit not enforced for %s: %v", user.ID, err)
+		loginDetail = "exam_session_limit_unchecked"
+	}
+	if limited {
+		recordEvent(r, userEvent(user, models.EventLogin, models.OutcomeFailure, "exam_session_limit"))
+		http.Error(w, "Session limit reached while your exam is in progress, sign out on another device first", http.StatusConflict)
+		return
+	}
 
 	// Generate JWT token
-	accessToken, refreshToken, err := jwtutil.GenerateToken(user.ID, user.Email, user.Role)
+	sessionID := uuid.New().String()
+	accessToken, refreshToken, err := jwtutil.GenerateToken(user.ID, user.Email, user.Role, user.InstitutionID, sessionID)
 	if err != nil {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	// Persist the session and its refresh token so they can be rotated and revoked
+	err = repository.CreateSession(newSession(r, sessionID, user.ID), newRefreshTokenRecord(r, user.ID, refreshToken))
+	if err != nil {
+		http.Error(w, "Failed to store refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	recordEvent(r, userEvent(user, models.EventLogin, models.OutcomeSuccess, loginDetail))
+
+	resp := map[string]interface{}{
 		"message":      "User logged in successfully",
 		"accessToken":  accessToken,
 		"refreshToken": refreshToken,
@@ -275,7 +393,15 @@ func Login(w http.ResponseWriter, r *http.Request) {
 			Name: user.Name,
 			Role: user.Role,
 		},
-	})
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+
+	// Send response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
 }
 
 func RefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -307,18 +433,58 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
 		return
 	}
+
+	// Refresh tokens are single use: it must be on record and not yet rotated
+	stored, err := repository.GetRefreshTokenByHash(jwtutil.HashToken(refreshToken.RefreshToken))
+	if err != nil || stored == nil || stored.UserID != claim.ID {
+		recordUserEvent(r, claim.ID, models.EventTokenRefresh, models.OutcomeFailure, "unknown_token")
+		http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+	if stored.RevokedAt != nil {
+		// A rotated token came back: assume it leaked and kill the whole chain
+		repository.RevokeRefreshTokenFamily(stored.FamilyID)
+		recordUserEvent(r, claim.ID, models.EventTokenRefresh, models.OutcomeFailure, "reuse_detected")
+		http.Error(w, "Refresh token reuse detected", http.StatusUnauthorized)
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+
 	user, err := repository.GetUserByID(claim.ID)
 	if err != nil || user == nil {
 		http.Error(w, "User not found", http.StatusUnauthorized)
 		return
 	}
+	if user.Status != models.StatusActive {
+		recordEvent(r, userEvent(user, models.EventTokenRefresh, models.OutcomeFailure, "account_"+user.Status))
+		http.Error(w, "Account is "+user.Status, http.StatusForbidden)
+		return
+	}
 	// Generate new access token
-	newAccessToken, newRefreshToken, err := jwtutil.GenerateToken(claim.ID, user.Email, user.Role)
+	newAccessToken, newRefreshToken, err := jwtutil.GenerateToken(claim.ID, user.Email, user.Role, user.InstitutionID, stored.FamilyID)
 	if err != nil {
 		http.Error(w, "Failed to generate new token", http.StatusInternalServerError)
 		return
 	}
 
+	rotated, err := repository.RotateRefreshToken(stored, newRefreshTokenRecord(r, user.ID, newRefreshToken))
+	if err != nil {
+		http.Error(w, "Failed to rotate refresh token", http.StatusInternalServerError)
+		return
+	}
+	if !rotated {
+		// Lost a race with another request presenting the same token
+		repository.RevokeRefreshTokenFamily(stored.FamilyID)
+		recordEvent(r, userEvent(user, models.EventTokenRefresh, models.OutcomeFailure, "reuse_detected"))
+		http.Error(w, "Refresh token reuse detected", http.StatusUnauthorized)
+		return
+	}
+
+	recordEvent(r, userEvent(user, models.EventTokenRefresh, models.OutcomeSuccess, ""))
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -352,6 +518,7 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		Email:       user.Email,
 		Role:        user.Role,
 		Institution: user.Institution,
+		InstitutionID: user.InstitutionID,
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
 	}
@@ -394,12 +561,6 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	if req.Name != nil {
 		user.Name = *req.Name
 	}
-	if req.Role != nil {
-		user.Role = *req.Role
-	}
-	if req.Institution != nil {
-		user.Institution = *req.Institution
-	}
 
 	user.UpdatedAt = time.Now()
 
@@ -408,6 +569,7 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventUserUpdate, TargetID: user.ID})
 
 	// Response
 	w.Header().Set("Content-Type", "application/json")
@@ -415,36 +577,3 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		"message": "User updated successfully",
 	})
 }
-
-func GetStudentsByPrefixBranchSemester(w http.ResponseWriter, r *http.Request) {
-	var filter dto.StudentFilterDTO
-
-	// Decode JSON body
-	err := json.NewDecoder(r.Body).Decode(&filter)
-	if err != nil {
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
-		return
-	}
-
-	// Validate
-	validate := validator.New()
-	if err := validate.Struct(&filter); err != nil {
-		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Call repository
-	students, err := repository.GetStudentsByPrefixBranchSemester(
-		filter.Prefix,
-		filter.Branch,
-		filter.Semester,
-	)
-	if err != nil {
-		http.Error(w, "Failed to fetch students", http.StatusInternalServerError)
-		return
-	}
-
-	// Respond
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(students)
-}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/middleware"
+	"auth/src/models"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// ImpersonateUser gives an admin a short-lived, read-only access token acting
+// as a user of their institution, so support staff can see what the user
+// sees. Admins cannot be impersonated, and every service logs each request
+// made with the token.
+func ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID := chi.URLParam(r, "id")
+	if userID == authData.UserID {
+		http.Error(w, "Forbidden: cannot impersonate yourself", http.StatusForbidden)
+		return
+	}
+	user, ok := tenantUser(w, r, userID)
+	if !ok {
+		return
+	}
+	if user.Role == models.RoleAdmin {
+		http.Error(w, "Forbidden: admins cannot be impersonated", http.StatusForbidden)
+		return
+	}
+	if user.Status != models.StatusActive {
+		http.Error(w, "Account is "+user.Status, http.StatusConflict)
+		return
+	}
+
+	var req dto.ImpersonationDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	impersonationID := uuid.New().String()
+	token, err := jwtutil.GenerateImpersonationToken(user, dto.ActorClaim{ID: authData.UserID, Email: authData.Email}, impersonationID)
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	recordEvent(r, models.AuthEvent{
+		EventType: models.EventImpersonationStart,
+		TargetID:  user.ID,
+		Detail:    impersonationID + ": " + req.Reason,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":          "Impersonation token issued",
+		"accessToken":      token,
+		"expires_in":       int(jwtutil.ImpersonationTokenTTL.Seconds()),
+		"impersonation_id": impersonationID,
+		"User": dto.LoginResponseDTO{
+			Name: user.Name,
+			Role: user.Role,
+		},
+	})
+}
+
+// RecordImpersonatedRequest is called by the other services, with the
+// impersonation token itself, for every request it makes there
+func RecordImpersonatedRequest(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.ImpersonatedRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	readOnly := req.Method == http.MethodGet || req.Method == http.MethodHead
+	middleware.RecordImpersonatedRequest(r, authData, req.Service, req.Method, req.Path, readOnly)
+
+	w.WriteHeader(http.StatusNoContent)
+}
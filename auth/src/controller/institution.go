@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const institutionAdminInviteTTL = 72 * time.Hour
+
+// ListInstitutions is public so the signup form can offer the active institutions
+func ListInstitutions(w http.ResponseWriter, r *http.Request) {
+	institutions, err := repository.ListActiveInstitutions()
+	if err != nil {
+		http.Error(w, "Failed to fetch institutions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"institutions": institutions,
+	})
+}
+
+// isPlatformAdmin reports whether the caller is an admin of the platform
+// institution (PLATFORM_INSTITUTION_CODE)
+func isPlatformAdmin(authData middleware.AuthContext) bool {
+	platformCode := os.Getenv("PLATFORM_INSTITUTION_CODE")
+	if platformCode == "" || authData.Role != models.RoleAdmin {
+		return false
+	}
+	caller, err := repository.GetInstitutionByID(authData.InstitutionID)
+	return err == nil && strings.EqualFold(caller.Code, platformCode)
+}
+
+// CreateInstitution onboards a new tenant. Only admins of the platform
+// institution (PLATFORM_INSTITUTION_CODE) may call it; the response carries a
+// one-time admin invite code for the new institution.
+func CreateInstitution(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !isPlatformAdmin(authData) {
+		http.Error(w, "Forbidden: only platform admins can create institutions", http.StatusForbidden)
+		return
+	}
+
+	var req dto.InstitutionCreateDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Code = strings.ToUpper(req.Code)
+
+	exists, err := repository.InstitutionExists(req.Name, req.Code)
+	if err != nil {
+		http.Error(w, "Failed to check institution", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		http.Error(w, "Institution name or code already registered", http.StatusConflict)
+		return
+	}
+
+	institution := models.Institution{Name: req.Name, Code: req.Code}
+	if err := repository.CreateInstitution(&institution); err != nil {
+		http.Error(w, "Failed to save institution", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventInstitutionCreate, TargetID: institution.ID, Detail: institution.Code})
+
+	code, err := jwtutil.GenerateOpaqueToken()
+	if err != nil {
+		http.Error(w, "Failed to generate invite code", http.StatusInternalServerError)
+		return
+	}
+
+	invite := models.InviteCode{
+		CodeHash:      jwtutil.HashToken(code),
+		Role:          models.RoleAdmin,
+		InstitutionID: institution.ID,
+		MaxUses:       1,
+		CreatedBy:     authData.UserID,
+		ExpiresAt:     time.Now().Add(institutionAdminInviteTTL),
+	}
+	if err := repository.CreateInviteCode(&invite); err != nil {
+		http.Error(w, "Failed to save invite code", http.StatusInternalServerError)
+		return
+	}
+
+	// The first admin signs up with this code and lands in the new institution
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Institution created successfully",
+		"institution": institution,
+		"invite_code": code,
+	})
+}
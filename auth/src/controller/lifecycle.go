@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// finalSemester students graduate instead of being promoted
+const finalSemester = 8
+
+// tenantStudent loads a student whose login belongs to the caller's
+// institution and writes 404 for anyone else
+func tenantStudent(w http.ResponseWriter, r *http.Request) (*models.Student, bool) {
+	student, err := repository.GetStudentByID(chi.URLParam(r, "id"))
+	if err != nil || student.UserID == "" || student.DeletedAt != nil {
+		http.Error(w, "Student not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if _, ok := tenantUser(w, r, student.UserID); !ok {
+		return nil, false
+	}
+
+	return student, true
+}
+
+// effectiveDate parses an optional YYYY-MM-DD date, defaulting to today.
+// Changes cannot be scheduled ahead, only recorded once they happened.
+func effectiveDate(value string) (time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if value == "" {
+		return today, true
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil || date.After(today) {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// PromoteStudents moves an enrolled cohort of the admin's institution to the next semester
+func PromoteStudents(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.StudentPromotionDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	effective, ok := effectiveDate(req.EffectiveDate)
+	if !ok {
+		http.Error(w, "effective_date must be a past or current date", http.StatusBadRequest)
+		return
+	}
+
+	filter := repository.CohortFilter{
+		InstitutionID: authData.InstitutionID,
+		Branch:        req.Branch,
+		Semester:      req.Semester,
+		Section:       req.Section,
+		Exclude:       req.ExcludeStudentIDs,
+	}
+	result, err := repository.PromoteStudents(filter, finalSemester, effective, req.Reason, authData.UserID)
+	if err != nil {
+		http.Error(w, "Failed to promote students", http.StatusInternalServerError)
+		return
+	}
+
+	recordEvent(r, models.AuthEvent{
+		EventType: models.EventStudentPromotion,
+		TargetID:  result.BatchID,
+		Detail: fmt.Sprintf("%s semester %d: %d promoted, %d graduated",
+			req.Branch, req.Semester, len(result.Promoted), len(result.Graduated)),
+	})
+	log.Printf("🎓 Promotion %s: %d promoted, %d graduated from %s semester %d",
+		result.BatchID, len(result.Promoted), len(result.Graduated), req.Branch, req.Semester)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   "Students promoted successfully",
+		"promotion": result,
+	})
+}
+
+// ChangeStudentStatus marks a student enrolled, detained, graduated or withdrawn
+func ChangeStudentStatus(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	student, ok := tenantStudent(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.StudentStatusDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	effective, ok := effectiveDate(req.EffectiveDate)
+	if !ok {
+		http.Error(w, "effective_date must be a past or current date", http.StatusBadRequest)
+		return
+	}
+
+	if student.AcademicStatus == req.Status {
+		http.Error(w, "Student is already "+req.Status, http.StatusConflict)
+		return
+	}
+
+	updated, err := repository.ChangeStudentStatus(student.ID, req.Status, effective, req.Reason, authData.UserID)
+	if err != nil {
+		http.Error(w, "Failed to change student status", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventStudentStatus, TargetID: student.ID, Detail: student.AcademicStatus + " -> " + req.Status})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Student status changed successfully",
+		"student": updated,
+	})
+}
+
+// UpdateStudentAcademic corrects a student's branch, semester or section
+func UpdateStudentAcademic(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	student, ok := tenantStudent(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.StudentAcademicUpdateDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	effective, ok := effectiveDate(req.EffectiveDate)
+	if !ok {
+		http.Error(w, "effective_date must be a past or current date", http.StatusBadRequest)
+		return
+	}
+
+	branch, semester, section := student.Branch, student.Semester, student.Section
+	if req.Branch != nil {
+		branch = *req.Branch
+	}
+	if req.Semester != nil {
+		semester = *req.Semester
+	}
+	if req.Section != nil {
+		section = *req.Section
+	}
+	if branch == student.Branch && semester == student.Semester && section == student.Section {
+		http.Error(w, "Nothing to change", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := repository.UpdateStudentAcademic(student.ID, branch, semester, section, effective, req.Reason, authData.UserID)
+	if err != nil {
+		http.Error(w, "Failed to update student", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{
+		EventType: models.EventStudentAcademic,
+		TargetID:  student.ID,
+		Detail: fmt.Sprintf("%s/%d/%s -> %s/%d/%s", student.Branch, student.Semester, student.Section,
+			branch, semester, section),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Student updated successfully",
+		"student": updated,
+	})
+}
+
+// GetStudentHistory lists a student's semester, branch, section and status changes
+func GetStudentHistory(w http.ResponseWriter, r *http.Request) {
+	student, ok := tenantStudent(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := repository.GetStudentHistory(student.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch student history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"student": student,
+		"history": history,
+	})
+}
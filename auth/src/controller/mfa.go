@@ -0,0 +1,559 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaChallengeTTL  = 5 * time.Minute
+	mfaEnrollmentTTL = 10 * time.Minute
+)
+
+// startMFAChallenge answers a correct password with a short-lived challenge
+// token instead of the token pair. Users of a role that requires MFA but who
+// have not enrolled yet get an enrollment token instead: it is good only for
+// StartLoginMFAEnrollment and CompleteLoginMFAEnrollment, never for a session.
+func startMFAChallenge(w http.ResponseWriter, user *models.User, mfa *models.UserMFA) {
+	resp := map[string]interface{}{}
+
+	if mfa.Enabled() {
+		challenge, err := issueMailedToken(user.ID, models.TokenPurposeMFAChallenge, mfaChallengeTTL)
+		if err != nil {
+			http.Error(w, "Failed to start MFA challenge", http.StatusInternalServerError)
+			return
+		}
+		resp["message"] = "MFA code required"
+		resp["mfa_required"] = true
+		resp["mfa_token"] = challenge
+	} else {
+		enrollment, err := issueMailedToken(user.ID, models.TokenPurposeMFAEnrollment, mfaEnrollmentTTL)
+		if err != nil {
+			http.Error(w, "Failed to start MFA enrollment", http.StatusInternalServerError)
+			return
+		}
+		resp["message"] = "MFA enrollment required"
+		resp["mfa_enrollment_required"] = true
+		resp["enrollment_token"] = enrollment
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CompleteMFALogin exchanges an MFA challenge token and a TOTP or recovery code for the token pair
+func CompleteMFALogin(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFALoginDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	challengeHash := jwtutil.HashToken(req.MFAToken)
+	userID, err := repository.GetUserTokenOwner(challengeHash, models.TokenPurposeMFAChallenge)
+	if err != nil {
+		http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if mfaThrottled(w, r, user.Email, userEvent(user, models.EventMFAVerify, models.OutcomeFailure, "throttled")) {
+		return
+	}
+
+	mfa, err := repository.GetUserMFA(user.ID)
+	if err != nil || !mfa.Enabled() {
+		http.Error(w, "MFA is not set up for this account", http.StatusBadRequest)
+		return
+	}
+
+	if req.RecoveryCode != "" {
+		err = service.UseRecoveryCode(user.ID, req.RecoveryCode)
+	} else {
+		err = service.VerifyMFACode(mfa, req.Code, time.Now())
+	}
+	if errors.Is(err, service.ErrInvalidMFACode) {
+		service.RecordLoginFailure(user.Email, clientIP(r))
+		recordEvent(r, userEvent(user, models.EventMFAVerify, models.OutcomeFailure, "invalid_code"))
+		http.Error(w, "Invalid MFA code", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to verify MFA code", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := repository.ConsumeUserToken(challengeHash, models.TokenPurposeMFAChallenge); err != nil {
+		http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+	if err := service.ResetLoginFailures(user.Email); err != nil {
+		log.Printf("failed to reset login failures: %v", err)
+	}
+
+	issueLoginTokens(w, r, user, nil)
+}
+
+// mfaThrottled answers 429, recording event, while the account or address
+// is locked out. Codes are short, so every endpoint checking one counts
+// wrong codes towards the login lockout.
+func mfaThrottled(w http.ResponseWriter, r *http.Request, email string, event models.AuthEvent) bool {
+	wait := service.LoginRetryAfter(email, clientIP(r))
+	if wait <= 0 {
+		return false
+	}
+	recordEvent(r, event)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+	return true
+}
+
+// mfaCodeEvent is a failed code check by a signed-in user
+func mfaCodeEvent(userID string, detail string) models.AuthEvent {
+	return models.AuthEvent{EventType: models.EventMFAVerify, Outcome: models.OutcomeFailure, TargetID: userID, Detail: detail}
+}
+
+// enrollmentTokenUser resolves an unused enrollment token to its user, who
+// must not have MFA enabled yet
+func enrollmentTokenUser(w http.ResponseWriter, token string) (*models.User, bool) {
+	userID, err := repository.GetUserTokenOwner(jwtutil.HashToken(token), models.TokenPurposeMFAEnrollment)
+	if err != nil {
+		http.Error(w, "Invalid or expired enrollment token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	mfa, err := repository.GetUserMFA(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load MFA settings", http.StatusInternalServerError)
+		return nil, false
+	}
+	if mfa.Enabled() {
+		http.Error(w, "MFA is already enabled", http.StatusConflict)
+		return nil, false
+	}
+	return user, true
+}
+
+// StartLoginMFAEnrollment gives the holder of an enrollment token (see
+// startMFAChallenge) a fresh secret to add to their authenticator app
+func StartLoginMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFAEnrollmentDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := enrollmentTokenUser(w, req.EnrollmentToken)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := service.StartMFAEnrollment(user)
+	if err != nil {
+		http.Error(w, "Failed to start MFA enrollment", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// CompleteLoginMFAEnrollment enables MFA with the first code of the new
+// secret and finishes the login, returning the recovery codes once
+func CompleteLoginMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFAEnrollmentVerifyDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := enrollmentTokenUser(w, req.EnrollmentToken)
+	if !ok {
+		return
+	}
+
+	if mfaThrottled(w, r, user.Email, userEvent(user, models.EventMFAVerify, models.OutcomeFailure, "throttled")) {
+		return
+	}
+
+	mfa, err := repository.GetUserMFA(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load MFA settings", http.StatusInternalServerError)
+		return
+	}
+	if mfa == nil {
+		http.Error(w, "Start enrollment first", http.StatusBadRequest)
+		return
+	}
+
+	err = service.VerifyMFACode(mfa, req.Code, time.Now())
+	if errors.Is(err, service.ErrInvalidMFACode) {
+		service.RecordLoginFailure(user.Email, clientIP(r))
+		recordEvent(r, userEvent(user, models.EventMFAVerify, models.OutcomeFailure, "invalid_code"))
+		http.Error(w, "Invalid MFA code", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to verify MFA code", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := repository.ConsumeUserToken(jwtutil.HashToken(req.EnrollmentToken), models.TokenPurposeMFAEnrollment); err != nil {
+		http.Error(w, "Invalid or expired enrollment token", http.StatusUnauthorized)
+		return
+	}
+	if err := service.ResetLoginFailures(user.Email); err != nil {
+		log.Printf("failed to reset login failures: %v", err)
+	}
+
+	codes, err := enableMFA(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to enable MFA", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, userEvent(user, models.EventMFAChange, models.OutcomeSuccess, "enabled"))
+
+	issueLoginTokens(w, r, user, map[string]interface{}{"recovery_codes": codes})
+}
+
+// enableMFA activates the pending enrollment and returns the recovery codes to show once
+func enableMFA(userID string) ([]string, error) {
+	codes, hashes, err := service.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.EnableMFA(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mfa, err := repository.GetUserMFA(authData.UserID)
+	if err != nil {
+		http.Error(w, "Failed to load MFA settings", http.StatusInternalServerError)
+		return
+	}
+
+	remaining := 0
+	if mfa.Enabled() {
+		remaining, err = repository.CountRecoveryCodes(authData.UserID)
+		if err != nil {
+			http.Error(w, "Failed to load MFA settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":                  mfa.Enabled(),
+		"required":                 service.MFARequired(authData.Role),
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// EnrollMFA starts (or restarts) enrollment for the logged-in user
+func EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := repository.GetUserByID(authData.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	mfa, err := repository.GetUserMFA(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load MFA settings", http.StatusInternalServerError)
+		return
+	}
+	if mfa.Enabled() {
+		http.Error(w, "MFA is already enabled", http.StatusConflict)
+		return
+	}
+
+	secret, uri, err := service.StartMFAEnrollment(user)
+	if err != nil {
+		http.Error(w, "Failed to start MFA enrollment", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// ConfirmMFAEnrollment enables MFA once the user proves their app produces valid codes
+func ConfirmMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.MFACodeDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mfa, err := repository.GetUserMFA(authData.UserID)
+	if err != nil {
+		http.Error(w, "Failed to load MFA settings", http.StatusInternalServerError)
+		return
+	}
+	if mfa == nil {
+		http.Error(w, "Start enrollment first", http.StatusBadRequest)
+		return
+	}
+	if mfa.Enabled() {
+		http.Error(w, "MFA is already enabled", http.StatusConflict)
+		return
+	}
+	if mfaThrottled(w, r, authData.Email, mfaCodeEvent(authData.UserID, "throttled")) {
+		return
+	}
+
+	err = service.VerifyMFACode(mfa, req.Code, time.Now())
+	if errors.Is(err, service.ErrInvalidMFACode) {
+		service.RecordLoginFailure(authData.Email, clientIP(r))
+		recordEvent(r, mfaCodeEvent(authData.UserID, "invalid_code"))
+		http.Error(w, "Invalid MFA code", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to verify MFA code", http.StatusInternalServerError)
+		return
+	}
+
+	codes, err := enableMFA(authData.UserID)
+	if err != nil {
+		http.Error(w, "Failed to enable MFA", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventMFAChange, TargetID: authData.UserID, Detail: "enabled"})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":        "MFA enabled successfully",
+		"recovery_codes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes; a current TOTP code is required
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.MFACodeDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mfa, err := repository.GetUserMFA(authData.UserID)
+	if err != nil || !mfa.Enabled() {
+		http.Error(w, "MFA is not enabled", http.StatusBadRequest)
+		return
+	}
+	if mfaThrottled(w, r, authData.Email, mfaCodeEvent(authData.UserID, "throttled")) {
+		return
+	}
+
+	err = service.VerifyMFACode(mfa, req.Code, time.Now())
+	if errors.Is(err, service.ErrInvalidMFACode) {
+		service.RecordLoginFailure(authData.Email, clientIP(r))
+		recordEvent(r, mfaCodeEvent(authData.UserID, "invalid_code"))
+		http.Error(w, "Invalid MFA code", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to verify MFA code", http.StatusInternalServerError)
+		return
+	}
+
+	codes, hashes, err := service.GenerateRecoveryCodes()
+	if err == nil {
+		err = repository.ReplaceRecoveryCodes(authData.UserID, hashes)
+	}
+	if err != nil {
+		http.Error(w, "Failed to generate recovery codes", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventMFAChange, TargetID: authData.UserID, Detail: "recovery_codes_regenerated"})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+// DisableMFA turns MFA off after re-checking password and a TOTP code. Not
+// allowed for roles where MFA is required.
+func DisableMFA(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if service.MFARequired(authData.Role) {
+		http.Error(w, "Forbidden: MFA is required for role "+authData.Role, http.StatusForbidden)
+		return
+	}
+
+	var req dto.MFADisableDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := repository.GetUserByID(authData.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if mfaThrottled(w, r, user.Email, mfaCodeEvent(user.ID, "throttled")) {
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		service.RecordLoginFailure(user.Email, clientIP(r))
+		recordEvent(r, models.AuthEvent{EventType: models.EventMFAChange, Outcome: models.OutcomeFailure, TargetID: user.ID, Detail: "disable_bad_password"})
+		http.Error(w, "Invalid password", http.StatusUnauthorized)
+		return
+	}
+
+	mfa, err := repository.GetUserMFA(user.ID)
+	if err != nil || !mfa.Enabled() {
+		http.Error(w, "MFA is not enabled", http.StatusBadRequest)
+		return
+	}
+
+	err = service.VerifyMFACode(mfa, req.Code, time.Now())
+	if errors.Is(err, service.ErrInvalidMFACode) {
+		service.RecordLoginFailure(user.Email, clientIP(r))
+		recordEvent(r, mfaCodeEvent(user.ID, "invalid_code"))
+		http.Error(w, "Invalid MFA code", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to verify MFA code", http.StatusInternalServerError)
+		return
+	}
+
+	if err := repository.DeleteMFA(user.ID); err != nil {
+		http.Error(w, "Failed to disable MFA", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventMFAChange, TargetID: user.ID, Detail: "disabled"})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "MFA disabled successfully",
+	})
+}
+
+// ResetUserMFA removes a user's MFA (lost device); they enroll again at next login if their role requires it
+func ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	user, ok := tenantUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	userID := user.ID
+
+	if err := repository.DeleteMFA(userID); err != nil {
+		http.Error(w, "Failed to reset MFA", http.StatusInternalServerError)
+		return
+	}
+
+	if err := repository.RevokeUserRefreshTokens(userID); err != nil {
+		log.Printf("failed to revoke refresh tokens after MFA reset: %v", err)
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventMFAReset, TargetID: userID})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "MFA reset successfully",
+	})
+}
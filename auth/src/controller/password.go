@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordRefused writes the answer for a failed service.CheckPassword: the
+// policy's reason as a 400, or a 500 for anything else
+func passwordRefused(w http.ResponseWriter, err error) {
+	var policyErr *service.PolicyError
+	if errors.As(err, &policyErr) {
+		http.Error(w, policyErr.Reason, http.StatusBadRequest)
+		return
+	}
+	http.Error(w, "Failed to check password", http.StatusInternalServerError)
+}
+
+// GetPasswordPolicy lets clients show the rules before the user submits
+func GetPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.CurrentPasswordPolicy())
+}
+
+// ChangePassword replaces the caller's password after checking the current
+// one. Every other session is signed out; the one making the change stays.
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.ChangePasswordDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := repository.GetUserByID(authData.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
+		recordEvent(r, userEvent(user, models.EventPasswordChange, models.OutcomeFailure, "bad_password"))
+		http.Error(w, "Invalid password", http.StatusUnauthorized)
+		return
+	}
+
+	if err := service.CheckPassword(user, req.NewPassword); err != nil {
+		passwordRefused(w, err)
+		return
+	}
+	if err := service.SavePassword(user, req.NewPassword); err != nil {
+		http.Error(w, "Failed to update password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := repository.RevokeOtherSessions(user.ID, authData.Claims.SessionID); err != nil {
+		log.Printf("failed to revoke other sessions after password change: %v", err)
+	}
+	recordEvent(r, userEvent(user, models.EventPasswordChange, models.OutcomeSuccess, ""))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Password changed successfully",
+	})
+}
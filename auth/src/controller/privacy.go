@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"archive/zip"
+	"auth/src/dto"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// privacyTimeout bounds the whole fan-out to the other services
+const privacyTimeout = 2 * time.Minute
+
+// privacySubject is the user as the other services know them
+func privacySubject(user *models.User, pseudonym string) (service.PrivacySubject, error) {
+	subject := service.PrivacySubject{UserID: user.ID, Pseudonym: pseudonym}
+
+	student, err := repository.GetStudentByUserID(user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return subject, err
+	}
+	if student != nil {
+		subject.StudentID = student.ID
+		subject.RollNumber = student.RollNumber
+	}
+	return subject, nil
+}
+
+// ExportUserData downloads a zip of everything the platform holds about a
+// user: auth.json, one file per service and manifest.json, which lists the
+// services that could not be reached.
+func ExportUserData(w http.ResponseWriter, r *http.Request) {
+	user, ok := tenantUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	authExport, err := repository.GetUserDataExport(user)
+	if err != nil {
+		http.Error(w, "Failed to collect user data", http.StatusInternalServerError)
+		return
+	}
+	subject, err := privacySubject(user, "")
+	if err != nil {
+		http.Error(w, "Failed to collect user data", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), privacyTimeout)
+	defer cancel()
+
+	files := map[string]interface{}{"auth.json": authExport}
+	manifest := map[string]interface{}{
+		"user_id":      user.ID,
+		"student_id":   subject.StudentID,
+		"roll_number":  subject.RollNumber,
+		"generated_at": time.Now().UTC(),
+	}
+	serviceStatus := map[string]string{"auth": "included"}
+	for _, svc := range service.PrivacyServices() {
+		data, err := service.CallPrivacyService(ctx, svc, "export", models.ScopePrivacyExport, user.InstitutionID, subject)
+		if err != nil {
+			log.Printf("⚠️ Data export from %s failed: %v", svc.Name, err)
+			serviceStatus[svc.Name] = "failed: " + err.Error()
+			continue
+		}
+		files[svc.Name+".json"] = data
+		serviceStatus[svc.Name] = "included"
+	}
+	manifest["services"] = serviceStatus
+	files["manifest.json"] = manifest
+
+	recordEvent(r, models.AuthEvent{EventType: models.EventDataExport, TargetID: user.ID})
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="user-data-`+user.ID+`.zip"`)
+
+	archive := zip.NewWriter(w)
+	for name, content := range files {
+		f, err := archive.Create(name)
+		if err == nil {
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(content)
+		}
+		if err != nil {
+			// Headers are gone by now; a truncated archive is all we can signal
+			log.Printf("⚠️ Data export of %s aborted: %v", user.ID, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("⚠️ Data export of %s aborted: %v", user.ID, err)
+	}
+}
+
+// EraseUserData pseudonymises a user everywhere. Auth replaces their
+// personal fields and deletes their sessions at once; every other service
+// swaps the user and student IDs for the same pseudonym and drops free text
+// (answers, feedback), keeping marks so exam statistics are unchanged.
+// Services that fail stay pending and are retried by calling this again.
+func EraseUserData(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID := chi.URLParam(r, "id")
+	if userID == authData.UserID {
+		http.Error(w, "Forbidden: cannot erase your own account", http.StatusForbidden)
+		return
+	}
+	user, ok := tenantUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req dto.DataErasureDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	erasure, err := repository.GetDataErasure(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load erasure", http.StatusInternalServerError)
+		return
+	}
+	if erasure != nil && erasure.Status == models.ErasureStatusCompleted {
+		http.Error(w, "User data is already erased", http.StatusConflict)
+		return
+	}
+
+	// The student record must be looked up before auth pseudonymises it
+	subject, err := privacySubject(user, "")
+	if err != nil {
+		http.Error(w, "Failed to load student record", http.StatusInternalServerError)
+		return
+	}
+
+	if erasure == nil {
+		var pending []string
+		for _, svc := range service.PrivacyServices() {
+			pending = append(pending, svc.Name)
+		}
+		erasure = &models.DataErasure{
+			UserID:          user.ID,
+			InstitutionID:   user.InstitutionID,
+			Pseudonym:       "erased-" + uuid.New().String(),
+			RequestedBy:     authData.UserID,
+			Reason:          req.Reason,
+			Status:          models.ErasureStatusPartial,
+			PendingServices: strings.Join(pending, ","),
+			RollNumber:      subject.RollNumber,
+		}
+
+		if err := service.ResetLoginFailures(user.Email); err != nil {
+			log.Printf("failed to clear login failures before erasure: %v", err)
+		}
+		if err := repository.EraseUserData(user, erasure); err != nil {
+			http.Error(w, "Failed to erase user data", http.StatusInternalServerError)
+			return
+		}
+	}
+	subject.Pseudonym = erasure.Pseudonym
+	// On a retry the student row already holds the pseudonym as roll number
+	subject.RollNumber = erasure.RollNumber
+
+	ctx, cancel := context.WithTimeout(r.Context(), privacyTimeout)
+	defer cancel()
+
+	pending := map[string]bool{}
+	for _, name := range strings.Split(erasure.PendingServices, ",") {
+		pending[name] = true
+	}
+	results := map[string]interface{}{}
+	var stillPending []string
+	for _, svc := range service.PrivacyServices() {
+		if !pending[svc.Name] {
+			continue
+		}
+		data, err := service.CallPrivacyService(ctx, svc, "erase", models.ScopePrivacyErase, user.InstitutionID, subject)
+		if err != nil {
+			log.Printf("⚠️ Data erasure in %s failed: %v", svc.Name, err)
+			results[svc.Name] = map[string]string{"error": err.Error()}
+			stillPending = append(stillPending, svc.Name)
+			continue
+		}
+		results[svc.Name] = data
+	}
+
+	erasure.PendingServices = strings.Join(stillPending, ",")
+	if err := repository.UpdateDataErasureProgress(erasure); err != nil {
+		http.Error(w, "Failed to save erasure progress", http.StatusInternalServerError)
+		return
+	}
+
+	event := models.AuthEvent{EventType: models.EventDataErasure, TargetID: user.ID, Detail: erasure.Status}
+	if len(stillPending) > 0 {
+		event.Detail += ", pending " + erasure.PendingServices
+	}
+	recordEvent(r, event)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "User data erasure " + erasure.Status,
+		"erasure":  erasure,
+		"services": results,
+	})
+}
@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+func CreateInviteCode(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.InviteCodeCreateDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	if req.ExpiresInHours == 0 {
+		req.ExpiresInHours = 72
+	}
+
+	code, err := jwtutil.GenerateOpaqueToken()
+	if err != nil {
+		http.Error(w, "Failed to generate invite code", http.StatusInternalServerError)
+		return
+	}
+
+	invite := models.InviteCode{
+		CodeHash:  jwtutil.HashToken(code),
+		Role:      req.Role,
+		InstitutionID: authData.InstitutionID,
+		MaxUses:   req.MaxUses,
+		CreatedBy: authData.UserID,
+		ExpiresAt: time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour),
+	}
+	if err := repository.CreateInviteCode(&invite); err != nil {
+		http.Error(w, "Failed to save invite code", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventInviteCreate, TargetID: invite.ID, Detail: "role " + invite.Role})
+
+	// The raw code is only ever shown here
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Invite code created successfully",
+		"invite_code": code,
+		"invite":      invite,
+	})
+}
+
+func RequestRoleChange(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.RoleChangeRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := repository.GetUserByID(authData.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.Role == req.Role {
+		http.Error(w, "You already have role "+req.Role, http.StatusBadRequest)
+		return
+	}
+
+	pending, err := repository.GetPendingRoleChangeRequestByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to check pending requests", http.StatusInternalServerError)
+		return
+	}
+	if pending != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "A role change request is already pending",
+		})
+		return
+	}
+
+	roleRequest := models.RoleChangeRequest{
+		UserID:        user.ID,
+		FromRole:      user.Role,
+		RequestedRole: req.Role,
+		Reason:        req.Reason,
+	}
+	if err := repository.CreateRoleChangeRequest(&roleRequest); err != nil {
+		http.Error(w, "Failed to save role change request", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventRoleRequest, TargetID: roleRequest.ID, Detail: user.Role + " -> " + req.Role})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Role change request submitted for approval",
+		"request": roleRequest,
+	})
+}
+
+func ListRoleChangeRequests(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	requests, err := repository.ListRoleChangeRequests(authData.InstitutionID, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Failed to fetch role change requests", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requests": requests,
+	})
+}
+
+func ApproveRoleChangeRequest(w http.ResponseWriter, r *http.Request) {
+	decideRoleChangeRequest(w, r, true)
+}
+
+func RejectRoleChangeRequest(w http.ResponseWriter, r *http.Request) {
+	decideRoleChangeRequest(w, r, false)
+}
+
+func decideRoleChangeRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.RoleDecisionDTO
+	// Body is optional: an empty one just means no note
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	roleRequest, err := repository.GetRoleChangeRequestByID(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Role change request not found", http.StatusNotFound)
+		return
+	}
+	if roleRequest.UserID == authData.UserID {
+		http.Error(w, "Forbidden: cannot decide your own role change request", http.StatusForbidden)
+		return
+	}
+	if _, ok := tenantUser(w, r, roleRequest.UserID); !ok {
+		return
+	}
+
+	err = repository.DecideRoleChangeRequest(roleRequest, approve, authData.UserID, req.Note)
+	if errors.Is(err, repository.ErrRequestAlreadyDecided) {
+		http.Error(w, "Role change request already decided", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update role change request", http.StatusInternalServerError)
+		return
+	}
+
+	message := "Role change request rejected"
+	detail := "rejected " + roleRequest.FromRole + " -> " + roleRequest.RequestedRole
+	if approve {
+		detail = "approved " + roleRequest.FromRole + " -> " + roleRequest.RequestedRole
+		message = "Role change request approved"
+		// Force a fresh login so no token keeps the old role beyond its expiry
+		if err := repository.RevokeUserRefreshTokens(roleRequest.UserID); err != nil {
+			log.Printf("failed to revoke refresh tokens after role change: %v", err)
+		}
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventRoleRequestDecision, TargetID: roleRequest.UserID, Detail: detail})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": message,
+	})
+}
+
+func ChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID := chi.URLParam(r, "id")
+	if userID == authData.UserID {
+		http.Error(w, "Forbidden: cannot change your own role", http.StatusForbidden)
+		return
+	}
+
+	var req dto.RoleChangeDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := tenantUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := repository.ChangeUserRole(userID, req.Role, authData.UserID, req.Reason); err != nil {
+		http.Error(w, "Failed to change user role", http.StatusInternalServerError)
+		return
+	}
+
+	if err := repository.RevokeUserRefreshTokens(userID); err != nil {
+		log.Printf("failed to revoke refresh tokens after role change: %v", err)
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventRoleChange, TargetID: userID, Detail: user.Role + " -> " + req.Role})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User role changed successfully",
+	})
+}
+
+func GetRoleAudit(w http.ResponseWriter, r *http.Request) {
+	user, ok := tenantUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	audit, err := repository.GetRoleAuditByUser(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch role audit", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"audit": audit,
+	})
+}
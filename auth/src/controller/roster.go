@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/mailer"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	accountInviteTTL    = 7 * 24 * time.Hour
+	maxRosterUploadSize = 10 << 20 // 10 MB
+)
+
+// ImportStudents onboards a CSV/XLSX roster (multipart field "file").
+// With ?dry_run=true nothing is written and the per-row report is returned.
+// Otherwise all rows are written in one transaction, or none if any row fails.
+// ?send_invites=true also emails the invite links of newly created logins.
+func ImportStudents(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	sendInvites, _ := strconv.ParseBool(r.URL.Query().Get("send_invites"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRosterUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Roster file is required (multipart field \"file\", max 10 MB)", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	rows, err := service.ParseRoster(header.Filename, file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Imported logins belong to the importing admin's institution
+	importer, err := repository.GetUserByID(authData.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	entries, err := service.ValidateRoster(rows, authData.InstitutionID, importer.Institution)
+	if err != nil {
+		http.Error(w, "Failed to validate roster", http.StatusInternalServerError)
+		return
+	}
+
+	report := make([]dto.StudentImportRowDTO, len(rows))
+	summary := map[string]int{
+		service.RosterActionCreate: 0,
+		service.RosterActionUpdate: 0,
+		service.RosterActionError:  0,
+	}
+	valid := make([]repository.RosterEntry, 0, len(entries))
+	inviteTokens := map[int]string{} // index in valid -> raw invite token
+
+	for i, row := range rows {
+		report[i] = dto.StudentImportRowDTO{
+			Line:       row.Line,
+			RollNumber: row.Student.RollNumber,
+			Errors:     row.Errors,
+		}
+
+		entry := entries[i]
+		switch {
+		case entry == nil:
+			report[i].Action = service.RosterActionError
+		case entry.Existing != nil:
+			report[i].Action = service.RosterActionUpdate
+		default:
+			report[i].Action = service.RosterActionCreate
+		}
+		summary[report[i].Action]++
+
+		if entry == nil || dryRun {
+			continue
+		}
+		if entry.User != nil {
+			token, err := jwtutil.GenerateOpaqueToken()
+			if err != nil {
+				http.Error(w, "Failed to generate invite token", http.StatusInternalServerError)
+				return
+			}
+			entry.InviteHash = jwtutil.HashToken(token)
+			inviteTokens[len(valid)] = token
+			report[i].InviteLink = service.AppLink("/accept-invite", token)
+		}
+		valid = append(valid, *entry)
+	}
+
+	resp := map[string]interface{}{
+		"dry_run": dryRun,
+		"total":   len(rows),
+		"summary": summary,
+		"rows":    report,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if dryRun {
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	if summary[service.RosterActionError] > 0 {
+		// Nothing is written unless the whole roster is valid
+		for i := range report {
+			report[i].InviteLink = ""
+		}
+		resp["message"] = "Roster has errors; no students were imported"
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	if err := repository.ImportRoster(valid, accountInviteTTL, authData.UserID); err != nil {
+		w.Header().Del("Content-Type")
+		http.Error(w, "Failed to import roster", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{
+		EventType: models.EventStudentImport,
+		Detail: fmt.Sprintf("%s: %d created, %d updated", header.Filename,
+			summary[service.RosterActionCreate], summary[service.RosterActionUpdate]),
+	})
+
+	if sendInvites {
+		go sendAccountInvites(valid, inviteTokens)
+	}
+
+	resp["message"] = "Roster imported successfully"
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func sendAccountInvites(entries []repository.RosterEntry, tokens map[int]string) {
+	for i, token := range tokens {
+		user := entries[i].User
+		err := mailer.Send(mailer.Message{
+			To:      user.Email,
+			Subject: "Your NeuroIQ student account",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nAn account has been created for you on NeuroIQ. Choose your password here:\n\n%s\n\nThis link expires in 7 days.\n",
+				user.Name, service.AppLink("/accept-invite", token),
+			),
+		})
+		if err != nil {
+			log.Printf("failed to send account invite to %s: %v", user.Email, err)
+		}
+	}
+}
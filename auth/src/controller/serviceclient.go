@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// CreateServiceClient registers a calling service. Platform admins only; the
+// secret is returned once and only its hash is stored.
+func CreateServiceClient(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !isPlatformAdmin(authData) {
+		http.Error(w, "Forbidden: only platform admins can manage service clients", http.StatusForbidden)
+		return
+	}
+
+	var req dto.ServiceClientCreateDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, secret, err := service.CreateServiceClient(req.Name, req.Scopes, authData.UserID)
+	if errors.Is(err, service.ErrUnknownScope) {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to save service client", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventServiceClientChange, TargetID: client.ClientID, Detail: "created " + client.Scopes})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Service client created successfully",
+		"client":        client,
+		"client_secret": secret,
+	})
+}
+
+// ListServiceClients lists every service client without secrets
+func ListServiceClients(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !isPlatformAdmin(authData) {
+		http.Error(w, "Forbidden: only platform admins can manage service clients", http.StatusForbidden)
+		return
+	}
+
+	clients, err := repository.ListServiceClients()
+	if err != nil {
+		http.Error(w, "Failed to fetch service clients", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"clients": clients,
+	})
+}
+
+// RevokeServiceClient stops a client from obtaining tokens. Tokens already
+// issued stay valid until they expire (ServiceTokenTTL).
+func RevokeServiceClient(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !isPlatformAdmin(authData) {
+		http.Error(w, "Forbidden: only platform admins can manage service clients", http.StatusForbidden)
+		return
+	}
+
+	revoked, err := repository.RevokeServiceClient(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Failed to revoke service client", http.StatusInternalServerError)
+		return
+	}
+	if !revoked {
+		http.Error(w, "Service client not found", http.StatusNotFound)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventServiceClientChange, TargetID: chi.URLParam(r, "id"), Detail: "revoked"})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Service client revoked successfully",
+	})
+}
+
+// maxRejectedClientID bounds how much of an unknown client_id is logged
+const maxRejectedClientID = 64
+
+// rejectedClientID quotes a client_id that failed authentication, cut to
+// maxRejectedClientID characters
+func rejectedClientID(clientID string) string {
+	if runes := []rune(clientID); len(runes) > maxRejectedClientID {
+		clientID = string(runes[:maxRejectedClientID]) + "…"
+	}
+	return strconv.Quote(clientID)
+}
+
+// IssueServiceToken implements the OAuth 2.0 client credentials grant. The
+// form carries grant_type=client_credentials, the client credentials (or
+// HTTP Basic auth), an optional scope narrowing the client's scopes and an
+// optional institution_id the token acts within.
+func IssueServiceToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		http.Error(w, "unsupported_grant_type", http.StatusBadRequest)
+		return
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+
+	client, err := repository.GetActiveServiceClient(clientID)
+	if err != nil || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(jwtutil.HashToken(secret))) != 1 {
+		// The caller is not authenticated, so the client_id they sent is no actor
+		recordEvent(r, models.AuthEvent{EventType: models.EventServiceToken, Outcome: models.OutcomeFailure, Detail: "invalid_client " + rejectedClientID(clientID)})
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+
+	// Every granted scope unless the client asks for fewer
+	scopes := strings.Fields(r.PostForm.Get("scope"))
+	if len(scopes) == 0 {
+		scopes = strings.Fields(client.Scopes)
+	}
+	for _, s := range scopes {
+		if !client.HasScope(s) {
+			http.Error(w, "invalid_scope", http.StatusBadRequest)
+			return
+		}
+	}
+	scope := strings.Join(scopes, " ")
+
+	institutionID := r.PostForm.Get("institution_id")
+	if institutionID != "" {
+		institution, err := repository.GetInstitutionByID(institutionID)
+		if err != nil || !institution.Active {
+			http.Error(w, "Institution not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	token, err := jwtutil.GenerateServiceToken(client.ClientID, institutionID, scope)
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := repository.TouchServiceClient(client.ID); err != nil {
+		log.Printf("⚠️ Failed to record use of service client %s: %v", client.Name, err)
+	}
+	event := models.AuthEvent{EventType: models.EventServiceToken, ActorID: client.ClientID, Detail: scope}
+	if institutionID != "" {
+		event.InstitutionID = &institutionID
+	}
+	recordEvent(r, event)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(dto.ServiceTokenResponseDTO{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(jwtutil.ServiceTokenTTL.Seconds()),
+		Scope:       scope,
+	})
+}
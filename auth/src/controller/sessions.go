@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// writeSessions lists the live sessions of userID, flagging currentID
+func writeSessions(w http.ResponseWriter, userID string, currentID string) {
+	sessions, err := repository.ListUserSessions(userID)
+	if err != nil {
+		http.Error(w, "Failed to load sessions", http.StatusInternalServerError)
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions":          sessions,
+		"exam_max_sessions": service.StudentExamSessionLimit(),
+	})
+}
+
+// revokeSession ends sessionID of userID: its refresh tokens stop working and
+// auth refuses its access tokens at once
+func revokeSession(w http.ResponseWriter, r *http.Request, userID string, sessionID string) {
+	session, err := repository.GetUserSession(userID, sessionID)
+	if err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	if session.RevokedAt == nil {
+		if err := repository.RevokeRefreshTokenFamily(session.ID); err != nil {
+			http.Error(w, "Failed to end session", http.StatusInternalServerError)
+			return
+		}
+		recordEvent(r, models.AuthEvent{EventType: models.EventSessionRevoke, TargetID: userID, Detail: session.ID})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Session ended successfully",
+	})
+}
+
+// ListSessions lists where the caller is signed in
+func ListSessions(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	writeSessions(w, authData.UserID, authData.Claims.SessionID)
+}
+
+// RevokeSession signs the caller out of one of their sessions, e.g. on a
+// shared exam laptop
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revokeSession(w, r, authData.UserID, chi.URLParam(r, "id"))
+}
+
+// ListUserSessions lists where a user of the admin's institution is signed in
+func ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := tenantUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	writeSessions(w, user.ID, "")
+}
+
+// RevokeUserSession signs a user of the admin's institution out of one session
+func RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := tenantUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	revokeSession(w, r, user.ID, chi.URLParam(r, "sessionID"))
+}
@@ -0,0 +1,511 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/oidc"
+	"auth/src/repository"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	ssoLoginRequestTTL = 10 * time.Minute
+	ssoHandoffTTL      = 2 * time.Minute
+
+	// ssoStateCookie ties a sign-in to the browser that started it. Without
+	// it, a callback URL for someone else's login (an attacker's) would sign
+	// in whoever opens it.
+	ssoStateCookie = "neuroiq_sso_state"
+	ssoCookiePath  = "/api/auth/oidc"
+)
+
+// setSSOStateCookie remembers the hash of state in the browser. Lax lets it
+// ride along the top-level redirect back from the provider.
+func setSSOStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    jwtutil.HashToken(state),
+		Path:     ssoCookiePath,
+		MaxAge:   int(ssoLoginRequestTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ssoStateMatches reports whether the browser started the sign-in state
+// belongs to, and clears the cookie either way
+func ssoStateMatches(w http.ResponseWriter, r *http.Request, state string) bool {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    "",
+		Path:     ssoCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	cookie, err := r.Cookie(ssoStateCookie)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(jwtutil.HashToken(state))) == 1
+}
+
+// ssoRedirectURL is the callback registered at every provider
+func ssoRedirectURL() string {
+	if v := os.Getenv("OIDC_REDIRECT_URL"); v != "" {
+		return v
+	}
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost"
+	}
+	return base + "/api/auth/oidc/callback"
+}
+
+// ssoClient resolves the provider's endpoints and builds the relying party
+func ssoClient(ctx context.Context, p *models.OIDCProvider) (*oidc.Client, error) {
+	provider, err := oidc.Discover(ctx, p.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	return &oidc.Client{
+		Provider:     provider,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  ssoRedirectURL(),
+		Scopes:       strings.Fields(p.Scopes),
+	}, nil
+}
+
+// ssoRedirect sends the browser back to the frontend. Values travel in the
+// fragment so the handoff code stays out of server logs and Referer headers.
+func ssoRedirect(w http.ResponseWriter, r *http.Request, values url.Values) {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost"
+	}
+	http.Redirect(w, r, base+"/sso/callback#"+values.Encode(), http.StatusFound)
+}
+
+func ssoFail(w http.ResponseWriter, r *http.Request, reason string) {
+	ssoRedirect(w, r, url.Values{"error": {reason}})
+}
+
+// safeReturnTo keeps only same-origin paths, so the flow cannot be used as an open redirect
+func safeReturnTo(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, `\`) {
+		return ""
+	}
+	return path
+}
+
+// StartSSOLogin redirects to the sign-in page of the institution's identity
+// provider. {institution} is the institution's code or name.
+func StartSSOLogin(w http.ResponseWriter, r *http.Request) {
+	institution, err := repository.FindActiveInstitution(chi.URLParam(r, "institution"))
+	if err != nil {
+		http.Error(w, "Unknown institution", http.StatusNotFound)
+		return
+	}
+
+	provider, err := repository.GetOIDCProviderByInstitution(institution.ID)
+	if err != nil {
+		http.Error(w, "Failed to load SSO settings", http.StatusInternalServerError)
+		return
+	}
+	if provider == nil || !provider.Enabled {
+		http.Error(w, "Single sign-on is not enabled for this institution", http.StatusNotFound)
+		return
+	}
+
+	client, err := ssoClient(r.Context(), provider)
+	if err != nil {
+		log.Printf("⚠️ OIDC discovery failed for %s: %v", provider.Issuer, err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	var state, nonce, verifier string
+	for _, v := range []*string{&state, &nonce, &verifier} {
+		if *v, err = oidc.RandomToken(); err != nil {
+			http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = repository.CreateOIDCLoginRequest(&models.OIDCLoginRequest{
+		StateHash:    jwtutil.HashToken(state),
+		ProviderID:   provider.ID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ReturnTo:     safeReturnTo(r.URL.Query().Get("return_to")),
+		ExpiresAt:    time.Now().Add(ssoLoginRequestTTL),
+	})
+	if err != nil {
+		http.Error(w, "Failed to start sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	setSSOStateCookie(w, state)
+	http.Redirect(w, r, client.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+// SSOCallback is where the provider sends the browser back. It validates the
+// ID token, links or provisions the user and hands the frontend a short-lived
+// code to exchange at POST /oidc/token.
+func SSOCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	state := q.Get("state")
+	if state == "" {
+		ssoFail(w, r, "invalid_request")
+		return
+	}
+	// Checked before the state is consumed, so a forged callback cannot
+	// spend the sign-in of the browser that did start it
+	if !ssoStateMatches(w, r, state) {
+		ssoFail(w, r, "state_mismatch")
+		return
+	}
+	loginRequest, err := repository.ConsumeOIDCLoginRequest(jwtutil.HashToken(state))
+	if err != nil {
+		ssoFail(w, r, "expired")
+		return
+	}
+
+	provider, err := repository.GetOIDCProviderByID(loginRequest.ProviderID)
+	if err != nil || !provider.Enabled {
+		ssoFail(w, r, "sso_disabled")
+		return
+	}
+	event := models.AuthEvent{
+		InstitutionID: &provider.InstitutionID,
+		EventType:     models.EventSSOLogin,
+		Outcome:       models.OutcomeFailure,
+	}
+
+	if providerErr := q.Get("error"); providerErr != "" {
+		event.Detail = "provider_error: " + providerErr
+		recordEvent(r, event)
+		ssoFail(w, r, "access_denied")
+		return
+	}
+
+	client, err := ssoClient(r.Context(), provider)
+	if err != nil {
+		log.Printf("⚠️ OIDC discovery failed for %s: %v", provider.Issuer, err)
+		ssoFail(w, r, "provider_unavailable")
+		return
+	}
+
+	rawIDToken, err := client.Exchange(r.Context(), q.Get("code"), loginRequest.CodeVerifier)
+	if err != nil {
+		log.Printf("⚠️ OIDC code exchange failed for %s: %v", provider.Issuer, err)
+		event.Detail = "code_exchange_failed"
+		recordEvent(r, event)
+		ssoFail(w, r, "provider_unavailable")
+		return
+	}
+
+	idToken, err := client.VerifyIDToken(r.Context(), rawIDToken, loginRequest.Nonce)
+	if err != nil {
+		log.Printf("⚠️ OIDC ID token rejected for %s: %v", provider.Issuer, err)
+		event.Detail = "invalid_id_token"
+		recordEvent(r, event)
+		ssoFail(w, r, "invalid_token")
+		return
+	}
+	event.ActorEmail = idToken.Email
+
+	user, reason := ssoUser(provider, idToken)
+	if user == nil {
+		event.Detail = reason
+		recordEvent(r, event)
+		ssoFail(w, r, reason)
+		return
+	}
+
+	if user.Status != models.StatusActive {
+		recordEvent(r, userEvent(user, models.EventSSOLogin, models.OutcomeFailure, "account_"+user.Status))
+		ssoFail(w, r, "account_"+user.Status)
+		return
+	}
+
+	code, err := issueMailedToken(user.ID, models.TokenPurposeSSOLogin, ssoHandoffTTL)
+	if err != nil {
+		ssoFail(w, r, "server_error")
+		return
+	}
+	recordEvent(r, userEvent(user, models.EventSSOLogin, models.OutcomeSuccess, idToken.Subject))
+
+	values := url.Values{"code": {code}}
+	if loginRequest.ReturnTo != "" {
+		values.Set("return_to", loginRequest.ReturnTo)
+	}
+	ssoRedirect(w, r, values)
+}
+
+// ssoUser finds the user behind a verified ID token: by a linked identity,
+// else by linking the same verified email in the institution, else by
+// provisioning a student when the provider allows it. A nil user comes with
+// the reason it was refused.
+func ssoUser(provider *models.OIDCProvider, idToken *oidc.IDToken) (*models.User, string) {
+	identity, err := repository.GetUserIdentity(provider.ID, idToken.Subject)
+	if err != nil {
+		return nil, "server_error"
+	}
+	if identity != nil {
+		user, err := repository.GetUserByID(identity.UserID)
+		if err != nil {
+			return nil, "server_error"
+		}
+		if err := repository.TouchUserIdentity(identity.ID); err != nil {
+			log.Printf("⚠️ Failed to record SSO login for identity %s: %v", identity.ID, err)
+		}
+		return user, ""
+	}
+
+	// Linking by email is only safe when the provider vouches for the address
+	if idToken.Email == "" || !idToken.EmailVerified {
+		return nil, "email_unverified"
+	}
+	if !provider.AllowsEmail(idToken.Email) {
+		return nil, "domain_not_allowed"
+	}
+
+	identity = &models.UserIdentity{
+		ProviderID: provider.ID,
+		Subject:    idToken.Subject,
+		Email:      idToken.Email,
+	}
+
+	user, err := repository.GetUserByEmail(idToken.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, "server_error"
+	}
+	if user != nil {
+		if user.InstitutionID != provider.InstitutionID {
+			return nil, "account_conflict"
+		}
+		// A user already linked to another account at this provider is not relinked
+		linked, err := repository.UserHasIdentity(provider.ID, user.ID)
+		if err != nil {
+			return nil, "server_error"
+		}
+		if linked {
+			return nil, "account_conflict"
+		}
+
+		identity.UserID = user.ID
+		if err := repository.LinkUserIdentity(identity); err != nil {
+			return nil, "server_error"
+		}
+		return user, ""
+	}
+
+	if !provider.JITProvisioning {
+		return nil, "no_account"
+	}
+
+	institution, err := repository.GetInstitutionByID(provider.InstitutionID)
+	if err != nil {
+		return nil, "server_error"
+	}
+	name := idToken.Name
+	if name == "" {
+		name, _, _ = strings.Cut(idToken.Email, "@")
+	}
+
+	// No password: the account signs in through the provider only, until a reset sets one
+	user = &models.User{
+		Name:          name,
+		Email:         idToken.Email,
+		Role:          models.RoleStudent,
+		Institution:   institution.Name,
+		InstitutionID: institution.ID,
+	}
+	if err := repository.CreateUserWithIdentity(user, identity); err != nil {
+		return nil, "server_error"
+	}
+	return user, ""
+}
+
+// ExchangeSSOCode trades the code from the SSO callback for the normal login
+// response, including the MFA step when the user has it on
+func ExchangeSSOCode(w http.ResponseWriter, r *http.Request) {
+	var req dto.SSOTokenDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := repository.ConsumeUserToken(jwtutil.HashToken(req.Code), models.TokenPurposeSSOLogin)
+	if err != nil {
+		http.Error(w, "Invalid or expired code", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil || user.Status != models.StatusActive {
+		http.Error(w, "Invalid or expired code", http.StatusUnauthorized)
+		return
+	}
+
+	continueLogin(w, r, user)
+}
+
+// GetSSOConfig returns the identity provider of the admin's institution
+func GetSSOConfig(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	provider, err := repository.GetOIDCProviderByInstitution(authData.InstitutionID)
+	if err != nil {
+		http.Error(w, "Failed to load SSO settings", http.StatusInternalServerError)
+		return
+	}
+	if provider == nil {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"provider":     provider,
+		"redirect_uri": ssoRedirectURL(),
+	})
+}
+
+// SaveSSOConfig creates or replaces the identity provider of the admin's
+// institution. The issuer must answer discovery before it is saved.
+func SaveSSOConfig(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.SSOConfigDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := repository.GetOIDCProviderByInstitution(authData.InstitutionID)
+	if err != nil {
+		http.Error(w, "Failed to load SSO settings", http.StatusInternalServerError)
+		return
+	}
+
+	provider := models.OIDCProvider{InstitutionID: authData.InstitutionID}
+	if existing != nil {
+		provider = *existing
+	}
+	provider.Issuer = strings.TrimSpace(req.Issuer)
+	provider.ClientID = strings.TrimSpace(req.ClientID)
+	provider.JITProvisioning = req.JITProvisioning
+	provider.Enabled = req.Enabled == nil || *req.Enabled
+
+	// The secret is write-only; leaving it out keeps the stored one
+	if req.ClientSecret != "" {
+		provider.ClientSecret = req.ClientSecret
+	}
+	if provider.ClientSecret == "" {
+		http.Error(w, "client_secret is required", http.StatusBadRequest)
+		return
+	}
+
+	scopes := []string{"openid"}
+	for _, s := range req.Scopes {
+		if s != "openid" {
+			scopes = append(scopes, s)
+		}
+	}
+	if len(req.Scopes) == 0 {
+		scopes = append(scopes, "email", "profile")
+	}
+	provider.Scopes = strings.Join(scopes, " ")
+
+	domains := make([]string, len(req.AllowedDomains))
+	for i, d := range req.AllowedDomains {
+		domains[i] = strings.ToLower(strings.TrimSpace(d))
+	}
+	provider.AllowedDomains = strings.Join(domains, ",")
+
+	if _, err := oidc.Discover(r.Context(), provider.Issuer); err != nil {
+		http.Error(w, "Issuer discovery failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := repository.SaveOIDCProvider(&provider); err != nil {
+		http.Error(w, "Failed to save SSO settings", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventSSOConfigChange, TargetID: provider.ID, Detail: "saved " + provider.Issuer})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      "SSO settings saved successfully",
+		"provider":     provider,
+		"redirect_uri": ssoRedirectURL(),
+	})
+}
+
+// DeleteSSOConfig removes the institution's identity provider and unlinks
+// every identity; users keep their accounts
+func DeleteSSOConfig(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deleted, err := repository.DeleteOIDCProvider(authData.InstitutionID)
+	if err != nil {
+		http.Error(w, "Failed to delete SSO settings", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventSSOConfigChange, Detail: "deleted"})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "SSO settings deleted successfully",
+	})
+}
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	defaultStudentSearchLimit = 100
+	maxStudentSearchLimit     = 500
+)
+
+// studentFields are the attributes a caller may pick with ?fields=
+var studentFields = map[string]bool{
+	"id": true, "first_name": true, "last_name": true, "roll_number": true,
+	"enrollment_no": true, "branch": true, "semester": true, "section": true,
+	"email": true, "phone": true, "user_id": true, "active": true,
+	"academic_status": true, "status_effective_date": true,
+	"created_at": true, "updated_at": true,
+}
+
+// listParam reads a query parameter given repeatedly and/or comma separated
+func listParam(q url.Values, key string) []string {
+	var values []string
+	for _, v := range q[key] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// parseFields validates a field selection; nil means every field
+func parseFields(fields []string) ([]string, bool) {
+	for _, f := range fields {
+		if !studentFields[f] {
+			return nil, false
+		}
+	}
+	return fields, true
+}
+
+// projectStudents keeps only the selected fields of each student
+func projectStudents(students []models.Student, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return students, nil
+	}
+
+	projected := make([]map[string]interface{}, len(students))
+	for i, s := range students {
+		raw, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]interface{}
+		if err := json.Unmarshal(raw, &all); err != nil {
+			return nil, err
+		}
+
+		projected[i] = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			projected[i][f] = all[f]
+		}
+	}
+	return projected, nil
+}
+
+// The cursor is the roll number and ID of the last student of a page
+func encodeStudentCursor(s models.Student) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s.RollNumber + "\n" + s.ID))
+}
+
+func decodeStudentCursor(cursor string) (roll string, id string, ok bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", false
+	}
+	roll, id, ok = strings.Cut(string(raw), "\n")
+	return roll, id, ok && roll != "" && id != ""
+}
+
+// SearchStudents lists students of the caller's institution. Query:
+// branch, section, academic_status (repeatable or comma separated),
+// semester or semester_from/semester_to, roll_prefix, roll_from/roll_to,
+// active, q (name), fields, limit and cursor.
+func SearchStudents(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	query := repository.StudentQuery{
+		InstitutionID:  authData.InstitutionID,
+		Branches:       listParam(q, "branch"),
+		Sections:       listParam(q, "section"),
+		RollPrefix:     q.Get("roll_prefix"),
+		RollFrom:       q.Get("roll_from"),
+		RollTo:         q.Get("roll_to"),
+		AcademicStatus: listParam(q, "academic_status"),
+		Name:           strings.TrimSpace(q.Get("q")),
+		Limit:          defaultStudentSearchLimit,
+	}
+
+	// semester is shorthand for semester_from = semester_to
+	for _, key := range []string{"semester", "semester_from", "semester_to"} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+		semester, err := strconv.Atoi(v)
+		if err != nil || semester < 1 || semester > 8 {
+			http.Error(w, key+" must be between 1 and 8", http.StatusBadRequest)
+			return
+		}
+		switch key {
+		case "semester":
+			query.SemesterFrom, query.SemesterTo = semester, semester
+		case "semester_from":
+			query.SemesterFrom = semester
+		case "semester_to":
+			query.SemesterTo = semester
+		}
+	}
+	if query.SemesterFrom != 0 && query.SemesterTo != 0 && query.SemesterFrom > query.SemesterTo {
+		http.Error(w, "semester_from must not be after semester_to", http.StatusBadRequest)
+		return
+	}
+
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "active must be true or false", http.StatusBadRequest)
+			return
+		}
+		query.Active = &active
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxStudentSearchLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxStudentSearchLimit), http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		roll, id, ok := decodeStudentCursor(cursor)
+		if !ok {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		query.AfterRoll, query.AfterID = roll, id
+	}
+
+	fields, ok := parseFields(listParam(q, "fields"))
+	if !ok {
+		http.Error(w, "Unknown field in fields", http.StatusBadRequest)
+		return
+	}
+
+	// One extra row tells whether there is another page
+	pageSize := query.Limit
+	query.Limit++
+	students, err := repository.SearchStudents(query)
+	if err != nil {
+		http.Error(w, "Failed to fetch students", http.StatusInternalServerError)
+		return
+	}
+
+	nextCursor := ""
+	if len(students) > pageSize {
+		students = students[:pageSize]
+		nextCursor = encodeStudentCursor(students[pageSize-1])
+	}
+
+	result, err := projectStudents(students, fields)
+	if err != nil {
+		http.Error(w, "Failed to encode students", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"students":    result,
+		"next_cursor": nextCursor,
+	})
+}
+
+// LookupStudents fetches students of the caller's institution by ID; IDs that
+// are unknown, deleted or of another institution are reported as missing
+func LookupStudents(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.StudentLookupDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fields, ok := parseFields(req.Fields)
+	if !ok {
+		http.Error(w, "Unknown field in fields", http.StatusBadRequest)
+		return
+	}
+
+	students, err := repository.GetStudentsByIDs(authData.InstitutionID, req.IDs)
+	if err != nil {
+		http.Error(w, "Failed to fetch students", http.StatusInternalServerError)
+		return
+	}
+
+	found := make(map[string]bool, len(students))
+	for _, s := range students {
+		found[s.ID] = true
+	}
+	missing := []string{}
+	for _, id := range req.IDs {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	result, err := projectStudents(students, fields)
+	if err != nil {
+		http.Error(w, "Failed to encode students", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"students": result,
+		"missing":  missing,
+	})
+}
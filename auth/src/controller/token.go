@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"auth/src/dto"
+	"auth/src/jwtutil"
+	"auth/src/middleware"
+	"auth/src/models"
+	"auth/src/repository"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// clientIP prefers the address forwarded by the gateway over the socket peer
+func clientIP(r *http.Request) string {
+	return middleware.ClientIP(r)
+}
+
+// newRefreshTokenRecord builds the row persisted for a freshly signed refresh token
+func newRefreshTokenRecord(r *http.Request, userID string, refreshToken string) *models.RefreshToken {
+	return &models.RefreshToken{
+		UserID:    userID,
+		TokenHash: jwtutil.HashToken(refreshToken),
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+		ExpiresAt: time.Now().Add(jwtutil.RefreshTokenTTL),
+	}
+}
+
+// newSession builds the session row of a login from its first refresh token
+func newSession(r *http.Request, sessionID string, userID string) *models.UserSession {
+	label := strings.TrimSpace(r.Header.Get("X-Device-Label"))
+	if label == "" {
+		label = deviceLabel(r.UserAgent())
+	}
+	if len(label) > 120 {
+		label = label[:120]
+	}
+
+	return &models.UserSession{
+		ID:          sessionID,
+		UserID:      userID,
+		DeviceLabel: label,
+		UserAgent:   r.UserAgent(),
+		IPAddress:   clientIP(r),
+	}
+}
+
+// deviceLabel names the browser and OS of a user agent, e.g. "Chrome on Windows"
+func deviceLabel(userAgent string) string {
+	browser := ""
+	for _, b := range []struct{ marker, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, b.marker) {
+			browser = b.name
+			break
+		}
+	}
+
+	system := ""
+	for _, o := range []struct{ marker, name string }{
+		{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"CrOS", "ChromeOS"},
+		{"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.marker) {
+			system = o.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return "Unknown device"
+}
+
+func Logout(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshTokenRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Unknown tokens are treated as already logged out
+	stored, _ := repository.GetRefreshTokenByHash(jwtutil.HashToken(req.RefreshToken))
+	if stored != nil {
+		if err := repository.RevokeRefreshTokenFamily(stored.FamilyID); err != nil {
+			http.Error(w, "Failed to revoke refresh token", http.StatusInternalServerError)
+			return
+		}
+		recordUserEvent(r, stored.UserID, models.EventLogout, models.OutcomeSuccess, "")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User logged out successfully",
+	})
+}
+
+func LogoutAll(w http.ResponseWriter, r *http.Request) {
+	authData, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := repository.RevokeUserRefreshTokens(authData.UserID); err != nil {
+		http.Error(w, "Failed to revoke refresh tokens", http.StatusInternalServerError)
+		return
+	}
+	recordEvent(r, models.AuthEvent{EventType: models.EventLogoutAll, TargetID: authData.UserID})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User logged out from all devices successfully",
+	})
+}
+
+func GetJWKS(w http.ResponseWriter, r *http.Request) {
+	// Verifiers cache this; keep it short so rotations propagate quickly
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(jwtutil.JWKS())
+}
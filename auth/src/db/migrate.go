@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"time"
+
+	"neuroiq/shared/migrate"
+)
+
+// Migrations live in migrations/ and are run by the shared migrate package
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrMigrationLocked means another process holds the migration lock
+var ErrMigrationLocked = migrate.ErrLocked
+
+var migrator = &migrate.Migrator{Files: migrationFiles, Dir: "migrations", Store: sqlxStore{}, Name: "auth"}
+
+// MigrationStatus lists every known migration and whether it has been applied
+func MigrationStatus() ([]migrate.State, error) {
+	return migrator.Status()
+}
+
+// PendingMigrations returns the migrations not applied yet, failing if an
+// applied one was modified afterwards
+func PendingMigrations() ([]migrate.Migration, error) {
+	return migrator.Pending()
+}
+
+// MigrateUp applies every pending migration and returns how many were applied
+func MigrateUp() (int, error) {
+	return migrator.Up()
+}
+
+// RunMigrateCommand implements `auth migrate up|down [steps]|status`
+func RunMigrateCommand(args []string) error {
+	return migrator.RunCommand(args)
+}
+
+// sqlxStore runs the migrations on DB
+type sqlxStore struct{}
+
+func (sqlxStore) Exec(query string, args ...interface{}) (int64, error) {
+	res, err := DB.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (sqlxStore) ExecInTx(script string, record string, args ...interface{}) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (sqlxStore) Applied() ([]migrate.Applied, error) {
+	var applied []struct {
+		Version   int       `db:"version"`
+		Checksum  string    `db:"checksum"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := DB.Select(&applied, migrate.AppliedSQL); err != nil {
+		return nil, err
+	}
+
+	rows := make([]migrate.Applied, len(applied))
+	for i, a := range applied {
+		rows[i] = migrate.Applied(a)
+	}
+	return rows, nil
+}
+
+func (sqlxStore) LockHolder() (string, error) {
+	var holder sql.NullString
+	if err := DB.Get(&holder, migrate.LockHolderSQL); err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	return holder.String, nil
+}
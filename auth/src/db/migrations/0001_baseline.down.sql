@@ -0,0 +1,13 @@
+-- Drops the whole auth schema
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
+DROP TABLE IF EXISTS role_audit;
+DROP TABLE IF EXISTS role_change_requests;
+DROP TABLE IF EXISTS invite_codes;
+DROP TABLE IF EXISTS login_failures;
+DROP TABLE IF EXISTS user_tokens;
+DROP TABLE IF EXISTS signing_keys;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS students;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS institutions;
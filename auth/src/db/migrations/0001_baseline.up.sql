@@ -0,0 +1,212 @@
+-- Schema as it stood when versioned migrations were introduced. Every
+-- statement is idempotent so databases bootstrapped by the old startup DDL
+-- adopt it without changes.
+
+-- Users
+CREATE TABLE IF NOT EXISTS users (
+	id UUID PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	email VARCHAR(120) UNIQUE NOT NULL,
+	password_hash TEXT NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	institution VARCHAR(120) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Accounts that existed before verification was introduced count as verified
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP DEFAULT NOW();
+ALTER TABLE users ALTER COLUMN email_verified_at DROP DEFAULT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
+CREATE INDEX IF NOT EXISTS idx_users_institution ON users(institution);
+
+-- Institutions are the tenants. The first run turns every distinct free-text
+-- users.institution into an institution row and links users to it.
+CREATE TABLE IF NOT EXISTS institutions (
+	id UUID PRIMARY KEY,
+	name VARCHAR(120) UNIQUE NOT NULL,
+	code VARCHAR(30) UNIQUE NOT NULL,       -- short code typed at signup
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO institutions (id, name, code)
+SELECT gen_random_uuid(), u.institution, UPPER(LEFT(MD5(u.institution), 8))
+FROM (SELECT DISTINCT institution FROM users) u
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS institution_id UUID REFERENCES institutions(id);
+
+UPDATE users u
+SET institution_id = i.id
+FROM institutions i
+WHERE u.institution_id IS NULL AND i.name = u.institution;
+
+ALTER TABLE users ALTER COLUMN institution_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_users_institution_id ON users(institution_id);
+
+-- Students
+CREATE TABLE IF NOT EXISTS students (
+	id UUID PRIMARY KEY,
+	first_name VARCHAR(100) NOT NULL,
+	last_name VARCHAR(100) NOT NULL,
+
+	roll_number VARCHAR(50) UNIQUE NOT NULL,
+	enrollment_no VARCHAR(50) UNIQUE,
+
+	branch VARCHAR(20) NOT NULL,        -- CSE/IT/ECE/MECH/etc
+	semester INT NOT NULL CHECK (semester >= 1 AND semester <= 8),
+	section VARCHAR(10),
+
+	email VARCHAR(120) UNIQUE NOT NULL,
+	phone VARCHAR(20),
+
+	user_id UUID,                        -- FK to users table
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_students_branch ON students(branch);
+CREATE INDEX IF NOT EXISTS idx_students_semester ON students(semester);
+CREATE INDEX IF NOT EXISTS idx_students_user_id ON students(user_id);
+
+-- Soft-deleted students keep their row so exam history still resolves
+ALTER TABLE students ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Refresh tokens are stored hashed; family_id groups every token produced by
+-- rotating the one issued at login so a replayed token can revoke the chain.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL,
+	family_id UUID NOT NULL,
+	token_hash CHAR(64) UNIQUE NOT NULL,
+
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address VARCHAR(64) NOT NULL DEFAULT '',
+
+	issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	replaced_by UUID,
+
+	CONSTRAINT fk_refresh_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+-- Signing keys for access tokens; the newest unretired row signs, retired rows
+-- stay published in the JWKS until tokens signed with them have expired.
+CREATE TABLE IF NOT EXISTS signing_keys (
+	kid VARCHAR(64) PRIMARY KEY,
+	algorithm VARCHAR(10) NOT NULL,
+	private_key TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	retired_at TIMESTAMP
+);
+
+-- Single-use tokens mailed to users (password reset, email verification)
+CREATE TABLE IF NOT EXISTS user_tokens (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL,
+	purpose VARCHAR(30) NOT NULL,       -- password_reset | email_verification | account_invite | mfa_challenge
+	token_hash CHAR(64) UNIQUE NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+
+	CONSTRAINT fk_user_tokens_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens(user_id);
+
+-- Failed login counters, keyed by scope (account | ip) and the email or address
+CREATE TABLE IF NOT EXISTS login_failures (
+	scope VARCHAR(10) NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	failures INT NOT NULL DEFAULT 0,
+	last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	locked_until TIMESTAMP,
+
+	PRIMARY KEY (scope, key)
+);
+
+-- Invite codes, elevation requests and the audit trail of every role change
+CREATE TABLE IF NOT EXISTS invite_codes (
+	id UUID PRIMARY KEY,
+	code_hash CHAR(64) UNIQUE NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	max_uses INT NOT NULL DEFAULT 1,
+	uses INT NOT NULL DEFAULT 0,
+	created_by UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL
+);
+
+-- Invites made before tenancy belong to their creator's institution
+ALTER TABLE invite_codes ADD COLUMN IF NOT EXISTS institution_id UUID REFERENCES institutions(id);
+UPDATE invite_codes c
+SET institution_id = u.institution_id
+FROM users u
+WHERE c.institution_id IS NULL AND c.created_by = u.id;
+
+CREATE TABLE IF NOT EXISTS role_change_requests (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL,
+	from_role VARCHAR(20) NOT NULL,
+	requested_role VARCHAR(20) NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',   -- pending | approved | rejected
+	decided_by UUID,
+	decision_note TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	decided_at TIMESTAMP,
+
+	CONSTRAINT fk_role_request_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_change_requests_status ON role_change_requests(status);
+
+CREATE TABLE IF NOT EXISTS role_audit (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL,
+	old_role VARCHAR(20) NOT NULL,
+	new_role VARCHAR(20) NOT NULL,
+	actor_id UUID,                       -- NULL for invite-based signup
+	request_id UUID,                     -- set when the change came from an approved request
+	reason TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_audit_user_id ON role_audit(user_id);
+
+-- TOTP secrets stay pending (enabled_at NULL) until the first code is verified.
+-- last_used_step stops a code from being replayed within its validity window.
+CREATE TABLE IF NOT EXISTS user_mfa (
+	user_id UUID PRIMARY KEY,
+	secret TEXT NOT NULL,
+	enabled_at TIMESTAMP,
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT fk_mfa_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL,
+	code_hash CHAR(64) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	used_at TIMESTAMP,
+
+	CONSTRAINT fk_recovery_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
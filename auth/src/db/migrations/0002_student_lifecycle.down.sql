@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS student_academic_history;
+DROP INDEX IF EXISTS idx_students_cohort;
+ALTER TABLE students DROP COLUMN IF EXISTS status_effective_date;
+ALTER TABLE students DROP COLUMN IF EXISTS academic_status;
@@ -0,0 +1,32 @@
+-- Academic lifecycle of a student, separate from the login's active flag.
+-- Detained students repeat their semester and are skipped by promotions;
+-- graduated and withdrawn students are no longer seated for exams.
+ALTER TABLE students ADD COLUMN academic_status VARCHAR(20) NOT NULL DEFAULT 'enrolled'
+	CHECK (academic_status IN ('enrolled', 'detained', 'graduated', 'withdrawn'));
+ALTER TABLE students ADD COLUMN status_effective_date DATE;
+
+CREATE INDEX idx_students_cohort ON students(branch, semester, section);
+
+-- Every change of semester, branch, section or academic status
+CREATE TABLE student_academic_history (
+	id UUID PRIMARY KEY,
+	student_id UUID NOT NULL,
+	change_type VARCHAR(20) NOT NULL,        -- promotion | status | academic | import
+	from_semester INT NOT NULL,
+	to_semester INT NOT NULL,
+	from_branch VARCHAR(20) NOT NULL,
+	to_branch VARCHAR(20) NOT NULL,
+	from_section VARCHAR(10) NOT NULL DEFAULT '',
+	to_section VARCHAR(10) NOT NULL DEFAULT '',
+	from_status VARCHAR(20) NOT NULL,
+	to_status VARCHAR(20) NOT NULL,
+	effective_date DATE NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	actor_id UUID,
+	batch_id UUID,                           -- shared by every row of one cohort promotion
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT fk_history_student FOREIGN KEY (student_id) REFERENCES students(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_student_academic_history_student_id ON student_academic_history(student_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS teaching_assignments;
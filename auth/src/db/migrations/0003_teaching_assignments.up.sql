@@ -0,0 +1,22 @@
+-- Which teacher teaches which subject to which cohort in an academic year.
+-- An empty section means every section of the branch and semester.
+CREATE TABLE teaching_assignments (
+	id UUID PRIMARY KEY,
+	institution_id UUID NOT NULL REFERENCES institutions(id),
+	teacher_id UUID NOT NULL,
+	subject VARCHAR(120) NOT NULL,
+	branch VARCHAR(20) NOT NULL,
+	semester INT NOT NULL CHECK (semester BETWEEN 1 AND 8),
+	section VARCHAR(10) NOT NULL DEFAULT '',
+	academic_year VARCHAR(7) NOT NULL,       -- e.g. 2025-26
+	created_by UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT fk_assignment_teacher FOREIGN KEY (teacher_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Subjects are compared case-insensitively, as the question bank lowercases them
+CREATE UNIQUE INDEX idx_teaching_assignments_unique
+	ON teaching_assignments(teacher_id, LOWER(subject), branch, semester, section, academic_year);
+CREATE INDEX idx_teaching_assignments_institution ON teaching_assignments(institution_id, academic_year);
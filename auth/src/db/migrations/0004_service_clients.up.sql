@@ -0,0 +1,12 @@
+-- Credentials other services use to obtain service tokens (client credentials grant)
+CREATE TABLE service_clients (
+	id UUID PRIMARY KEY,
+	client_id VARCHAR(64) UNIQUE NOT NULL,
+	secret_hash CHAR(64) NOT NULL,           -- sha256 of the secret, shown once at creation
+	name VARCHAR(120) NOT NULL,
+	scopes TEXT NOT NULL,                    -- space separated, e.g. "students:read llm:evaluate"
+	created_by UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
@@ -0,0 +1,31 @@
+-- Append-only log of logins, token use, profile changes and admin actions.
+-- actor_id and target_id are free text: user UUIDs, student UUIDs or service
+-- client IDs. Failed logins for unknown emails have neither actor nor institution.
+CREATE TABLE auth_events (
+	id UUID PRIMARY KEY,
+	institution_id UUID,
+	actor_id VARCHAR(64) NOT NULL DEFAULT '',
+	actor_email VARCHAR(255) NOT NULL DEFAULT '',
+	target_id VARCHAR(64) NOT NULL DEFAULT '',
+	event_type VARCHAR(50) NOT NULL,
+	outcome VARCHAR(10) NOT NULL CHECK (outcome IN ('success', 'failure')),
+	detail TEXT NOT NULL DEFAULT '',          -- failure reason or what changed
+	ip_address VARCHAR(64) NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_auth_events_institution ON auth_events(institution_id, created_at DESC);
+CREATE INDEX idx_auth_events_actor ON auth_events(actor_id, created_at DESC);
+CREATE INDEX idx_auth_events_target ON auth_events(target_id, created_at DESC);
+
+-- Rows are evidence in exam disputes: refuse any change once written
+CREATE FUNCTION auth_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_auth_events_append_only
+	BEFORE UPDATE OR DELETE ON auth_events
+	FOR EACH ROW EXECUTE FUNCTION auth_events_append_only();
@@ -0,0 +1,39 @@
+-- OpenID Connect sign-in: at most one identity provider per institution
+CREATE TABLE oidc_providers (
+	id UUID PRIMARY KEY,
+	institution_id UUID UNIQUE NOT NULL REFERENCES institutions(id),
+	issuer TEXT NOT NULL,
+	client_id TEXT NOT NULL,
+	client_secret TEXT NOT NULL,
+	scopes TEXT NOT NULL DEFAULT 'openid email profile',
+	allowed_domains TEXT NOT NULL DEFAULT '',   -- comma separated email domains, empty = any
+	jit_provisioning BOOLEAN NOT NULL DEFAULT FALSE, -- create student accounts for unknown users
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- A sign-in in flight between the redirect to the provider and its callback
+CREATE TABLE oidc_login_requests (
+	state_hash CHAR(64) PRIMARY KEY,
+	provider_id UUID NOT NULL REFERENCES oidc_providers(id) ON DELETE CASCADE,
+	nonce TEXT NOT NULL,
+	code_verifier TEXT NOT NULL,
+	return_to TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL
+);
+
+-- Provider accounts linked to NeuroIQ users, matched by the stable subject
+CREATE TABLE user_identities (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	provider_id UUID NOT NULL REFERENCES oidc_providers(id) ON DELETE CASCADE,
+	subject VARCHAR(255) NOT NULL,
+	email VARCHAR(120) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	last_login_at TIMESTAMP,
+
+	UNIQUE (provider_id, subject),
+	UNIQUE (provider_id, user_id)
+);
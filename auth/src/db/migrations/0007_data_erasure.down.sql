@@ -0,0 +1,7 @@
+CREATE OR REPLACE FUNCTION auth_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS data_erasures;
@@ -0,0 +1,39 @@
+-- Erasure requests. The pseudonym replaces the user's ID in every service, so
+-- exam statistics still add up; pending_services lists the services that have
+-- not confirmed yet and are retried when the erasure is requested again.
+CREATE TABLE data_erasures (
+	id UUID PRIMARY KEY,
+	user_id UUID UNIQUE NOT NULL REFERENCES users(id),
+	institution_id UUID NOT NULL REFERENCES institutions(id),
+	pseudonym VARCHAR(50) UNIQUE NOT NULL,
+	requested_by UUID NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	status VARCHAR(20) NOT NULL CHECK (status IN ('partial', 'completed')),
+	pending_services TEXT NOT NULL DEFAULT '',   -- comma separated
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_data_erasures_institution ON data_erasures(institution_id, created_at DESC);
+
+-- auth_events stays append-only, except that an erasure (which sets
+-- neuroiq.erasure for its transaction) may blank the email, address and user
+-- agent of the erased user's events. Nothing else about a row can change.
+CREATE OR REPLACE FUNCTION auth_events_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE'
+		AND current_setting('neuroiq.erasure', true) = 'on'
+		AND NEW.id = OLD.id
+		AND NEW.institution_id IS NOT DISTINCT FROM OLD.institution_id
+		AND NEW.actor_id = OLD.actor_id
+		AND NEW.target_id = OLD.target_id
+		AND NEW.event_type = OLD.event_type
+		AND NEW.outcome = OLD.outcome
+		AND NEW.detail = OLD.detail
+		AND NEW.created_at = OLD.created_at
+	THEN
+		RETURN NEW;
+	END IF;
+	RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,23 @@
+-- One row per login. Every refresh token of the login shares family_id with
+-- the session id, and access tokens carry it as their sid claim.
+CREATE TABLE user_sessions (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	device_label VARCHAR(120) NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address VARCHAR(64) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,   -- of the newest refresh token
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_user_sessions_user ON user_sessions(user_id, last_seen_at DESC);
+
+-- Logins made before sessions were tracked, from their newest live token
+INSERT INTO user_sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+SELECT DISTINCT ON (family_id)
+	family_id, user_id, user_agent, ip_address, issued_at, issued_at, expires_at
+FROM refresh_tokens
+WHERE revoked_at IS NULL AND expires_at > NOW()
+ORDER BY family_id, issued_at DESC;
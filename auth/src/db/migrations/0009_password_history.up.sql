@@ -0,0 +1,17 @@
+-- Previous password hashes, newest first per user, so a password change can
+-- refuse the last PASSWORD_HISTORY passwords. The current password is the
+-- newest row.
+CREATE TABLE password_history (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_history_user ON password_history(user_id, created_at DESC);
+
+-- Current passwords; pending invites ('!') and SSO-only accounts have none
+INSERT INTO password_history (id, user_id, password_hash, created_at)
+SELECT gen_random_uuid(), id, password_hash, updated_at
+FROM users
+WHERE password_hash NOT IN ('', '!');
@@ -0,0 +1 @@
+ALTER TABLE data_erasures DROP COLUMN IF EXISTS roll_number;
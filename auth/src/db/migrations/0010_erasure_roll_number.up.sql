@@ -0,0 +1,3 @@
+-- Seating arrangements name students by roll number, which auth replaces
+-- with the pseudonym at once; it is kept here until every service is done
+ALTER TABLE data_erasures ADD COLUMN IF NOT EXISTS roll_number TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_students_user_id;
+CREATE INDEX idx_students_user_id ON students(user_id);
@@ -0,0 +1,12 @@
+-- A login owns at most one live student record. Extra live records left by
+-- repeated self-registration are soft-deleted, keeping the oldest one.
+UPDATE students s SET deleted_at = NOW()
+WHERE s.user_id IS NOT NULL AND s.deleted_at IS NULL
+	AND EXISTS (
+		SELECT 1 FROM students o
+		WHERE o.user_id = s.user_id AND o.deleted_at IS NULL
+			AND (o.created_at, o.id) < (s.created_at, s.id)
+	);
+
+DROP INDEX IF EXISTS idx_students_user_id;
+CREATE UNIQUE INDEX idx_students_user_id ON students(user_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS trg_auth_events_no_truncate ON auth_events;
+DROP FUNCTION IF EXISTS auth_events_no_truncate();
@@ -0,0 +1,17 @@
+-- Row triggers do not fire on TRUNCATE, which would empty auth_events in one
+-- statement. Refuse it as well; 0005 is applied and checksummed, so the
+-- trigger is added here.
+CREATE FUNCTION auth_events_no_truncate() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_auth_events_no_truncate
+	BEFORE TRUNCATE ON auth_events
+	FOR EACH STATEMENT EXECUTE FUNCTION auth_events_no_truncate();
+
+-- 0007 lets an erasure UPDATE through when the transaction sets
+-- neuroiq.erasure. Any session may set that setting, so the exception is for
+-- a trusted role only: auth's own database role must be the only one granted
+-- UPDATE on auth_events, never a reporting or support account.
@@ -10,14 +10,63 @@ type AccessClaim struct {
 	ID            string
 	Email         string 
 	Role          string 
+	InstitutionID string // tenant every downstream query is scoped to
+	Scope         string `json:"scope,omitempty"` // service tokens only, space separated
+	SessionID     string `json:"sid,omitempty"`   // user tokens only, the login they belong to
+	Actor         *ActorClaim `json:"act,omitempty"` // set when an admin impersonates the user
 	jwt.RegisteredClaims
 }
 
+// ActorClaim names who really holds an impersonation token (RFC 8693 act)
+type ActorClaim struct {
+	ID    string `json:"sub"`
+	Email string `json:"email,omitempty"`
+}
+
+// ImpersonationDTO asks for a read-only token acting as another user
+type ImpersonationDTO struct {
+	Reason string `json:"reason" validate:"required,min=5"`
+}
+
+// ImpersonatedRequestDTO is a request another service served to an impersonation token
+type ImpersonatedRequestDTO struct {
+	Service string `json:"service" validate:"required,max=40"`
+	Method  string `json:"method" validate:"required,max=10"`
+	Path    string `json:"path" validate:"required,max=500"`
+}
+
 type RefreshClaim struct {
 	ID            string
 	jwt.RegisteredClaims
 }
 
+// JWK is the public part of an access token signing key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type ServiceClientCreateDTO struct {
+	Name   string   `json:"name" validate:"required,min=3,max=120"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,required"`
+}
+
+// ServiceTokenResponseDTO follows the OAuth 2.0 token response (RFC 6749 section 5.1)
+type ServiceTokenResponseDTO struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
 type RefreshTokenRequestDTO struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
@@ -25,9 +74,10 @@ type RefreshTokenRequestDTO struct {
 type UserRegisterDTO struct {
 	Name          string  `json:"name" validate:"required"`
 	Email         string  `json:"email" validate:"required,email"`
-	Password      string  `json:"password" validate:"required,min=6"`
-	Role          string  `json:"role" validate:"required,oneof=student teacher admin"`
-	Institution   string  `json:"institution" validate:"required"`
+	Password      string  `json:"password" validate:"required"` // checked against the password policy
+	Role          string  `json:"role" validate:"omitempty,oneof=student teacher admin"` // defaults to student
+	Institution   string  `json:"institution" validate:"required_without=InviteCode"` // code or name of an existing institution; invites carry their own
+	InviteCode    string  `json:"invite_code"` // needed for teacher/admin
 }
 
 type UserLoginDTO struct {
@@ -35,6 +85,51 @@ type UserLoginDTO struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type EmailRequestDTO struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordDTO struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type VerifyEmailDTO struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// MFALoginDTO completes a login that returned an MFA challenge; send either code or recovery_code
+type MFALoginDTO struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// MFAEnrollmentDTO starts the enrollment a login asked for
+type MFAEnrollmentDTO struct {
+	EnrollmentToken string `json:"enrollment_token" validate:"required"`
+}
+
+// MFAEnrollmentVerifyDTO finishes that enrollment, and the login, with the first code
+type MFAEnrollmentVerifyDTO struct {
+	EnrollmentToken string `json:"enrollment_token" validate:"required"`
+	Code            string `json:"code" validate:"required"`
+}
+
+type MFACodeDTO struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFADisableDTO struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
 type LoginResponseDTO struct{
 	Name	string 	`json:"name"`
 	Role 	string 	`json:"role"`
@@ -47,23 +142,140 @@ type UserResponseDTO struct {
 	Email         string     `json:"email"`
 	Role          string     `json:"role"`
 	Institution   string     `json:"institution"`
+	InstitutionID string     `json:"institution_id"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 
+// AdminUserResponseDTO is the admin view of a user, without the password hash
+type AdminUserResponseDTO struct {
+	ID                    string     `json:"id"`
+	Name                  string     `json:"name"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	Institution           string     `json:"institution"`
+	InstitutionID         string     `json:"institution_id"`
+	Status                string     `json:"status"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+type InstitutionCreateDTO struct {
+	Name string `json:"name" validate:"required,min=3,max=120"`
+	Code string `json:"code" validate:"required,alphanum,min=2,max=30"`
+}
+
+type BulkUserStatusDTO struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,max=500,dive,uuid"`
+	Status  string   `json:"status" validate:"required,oneof=active inactive"`
+}
+
 type UserUpdateDTO struct {
 	Name        *string `json:"name" validate:"omitempty,min=3"` // <--- explanation below
-	Role        *string `json:"role" validate:"omitempty,oneof=student teacher admin"`
-	Institution *string `json:"institution" validate:"required"`
 }
 
-type StudentFilterDTO struct {
-	Prefix   string `json:"prefix" validate:"required"`
-	Branch   string `json:"branch" validate:"required"`
-	Semester int    `json:"semester" validate:"required,min=1,max=8"`
+type InviteCodeCreateDTO struct {
+	Role           string `json:"role" validate:"required,oneof=teacher admin"`
+	MaxUses        int    `json:"max_uses" validate:"omitempty,min=1"`          // default 1
+	ExpiresInHours int    `json:"expires_in_hours" validate:"omitempty,min=1"` // default 72
+}
+
+type RoleChangeRequestDTO struct {
+	Role   string `json:"role" validate:"required,oneof=student teacher admin"`
+	Reason string `json:"reason" validate:"required,min=5"`
 }
 
+type RoleDecisionDTO struct {
+	Note string `json:"note"`
+}
+
+type RoleChangeDTO struct {
+	Role   string `json:"role" validate:"required,oneof=student teacher admin"`
+	Reason string `json:"reason" validate:"required,min=5"`
+}
+
+// AssignmentDTO creates or replaces a teaching assignment; academic_year defaults to the current one
+type AssignmentDTO struct {
+	TeacherID    string `json:"teacher_id" validate:"required,uuid"`
+	Subject      string `json:"subject" validate:"required,max=120"`
+	Branch       string `json:"branch" validate:"required,oneof=CSE IT ECE MECH CIVIL EE EC"`
+	Semester     int    `json:"semester" validate:"required,min=1,max=8"`
+	Section      string `json:"section" validate:"omitempty,max=10"` // empty means every section
+	AcademicYear string `json:"academic_year" validate:"omitempty,len=7"`
+}
+
+// StudentImportRowDTO reports the outcome of one roster row
+type StudentImportRowDTO struct {
+	Line       int      `json:"line"`
+	RollNumber string   `json:"roll_number"`
+	Action     string   `json:"action"` // create | update | error
+	Errors     []string `json:"errors,omitempty"`
+	InviteLink string   `json:"invite_link,omitempty"` // only for new logins, and not in dry-run
+}
+
+// StudentLookupDTO fetches students by ID; fields optionally limits the returned attributes
+type StudentLookupDTO struct {
+	IDs    []string `json:"ids" validate:"required,min=1,max=1000,dive,uuid"`
+	Fields []string `json:"fields"`
+}
+
+// DataErasureDTO asks for a user's personal data to be erased everywhere
+type DataErasureDTO struct {
+	Reason string `json:"reason" validate:"required,min=5"`
+}
+
+// SSOConfigDTO configures an institution's OpenID provider; client_secret may
+// be left out when updating, scopes default to openid email profile
+type SSOConfigDTO struct {
+	Issuer          string   `json:"issuer" validate:"required,url"`
+	ClientID        string   `json:"client_id" validate:"required"`
+	ClientSecret    string   `json:"client_secret"`
+	Scopes          []string `json:"scopes" validate:"omitempty,max=20,dive,printascii,excludes= "`
+	AllowedDomains  []string `json:"allowed_domains" validate:"omitempty,max=50,dive,fqdn"`
+	JITProvisioning bool     `json:"jit_provisioning"`
+	Enabled         *bool    `json:"enabled"` // default true
+}
+
+// SSOTokenDTO carries the one-time code the SSO callback hands to the frontend
+type SSOTokenDTO struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// StudentProfileUpdateDTO holds the profile fields a student may change themselves
+type StudentProfileUpdateDTO struct {
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+	Phone     string `json:"phone" validate:"omitempty"`
+}
+
+// StudentPromotionDTO moves a cohort to the next semester; effective_date defaults to today
+type StudentPromotionDTO struct {
+	Branch            string   `json:"branch" validate:"required,oneof=CSE IT ECE MECH CIVIL EE EC"`
+	Semester          int      `json:"semester" validate:"required,min=1,max=8"`
+	Section           string   `json:"section" validate:"omitempty"` // empty promotes every section
+	ExcludeStudentIDs []string `json:"exclude_student_ids" validate:"omitempty,max=500,dive,uuid"`
+	EffectiveDate     string   `json:"effective_date" validate:"omitempty,datetime=2006-01-02"`
+	Reason            string   `json:"reason"`
+}
+
+type StudentStatusDTO struct {
+	Status        string `json:"status" validate:"required,oneof=enrolled detained graduated withdrawn"`
+	EffectiveDate string `json:"effective_date" validate:"omitempty,datetime=2006-01-02"`
+	Reason        string `json:"reason" validate:"required,min=5"`
+}
+
+// StudentAcademicUpdateDTO corrects branch, semester or section; omitted fields are kept
+type StudentAcademicUpdateDTO struct {
+	Branch        *string `json:"branch" validate:"omitempty,oneof=CSE IT ECE MECH CIVIL EE EC"`
+	Semester      *int    `json:"semester" validate:"omitempty,min=1,max=8"`
+	Section       *string `json:"section"`
+	EffectiveDate string  `json:"effective_date" validate:"omitempty,datetime=2006-01-02"`
+	Reason        string  `json:"reason" validate:"required,min=5"`
+}
 
 type StudentRegisterDTO struct {
 	FirstName    string `json:"first_name" validate:"required"`
@@ -2,26 +2,53 @@ package jwtutil
 
 import (
 	"auth/src/dto"
+	"auth/src/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateToken(userId string , email string , role string ) (string , string , error) {
-	accessSecret := os.Getenv("JWT_ACCESS_SECRET")
+// AccessTokenTTL is how long an access token is valid; retired signing keys
+// stay published for at least this long. Only auth sees a session being
+// revoked, so this bounds how long the other services keep accepting the
+// tokens of a signed-out device; clients refresh when it runs out.
+const AccessTokenTTL = 15 * time.Minute
+
+// RefreshTokenTTL is how long a refresh token stays usable if never rotated.
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// ServiceTokenTTL is how long a client credentials token is valid; services
+// fetch a new one instead of refreshing.
+const ServiceTokenTTL = 15 * time.Minute
+
+// GenerateToken signs an access and a refresh token for one session of a user
+func GenerateToken(userId string , email string , role string , institutionID string , sessionID string ) (string , string , error) {
+	signingKey, err := activeSigningKey()
+	if err != nil {
+		return "", "", err
+	}
 	accessClaim := dto.AccessClaim{
 		ID: userId,
 		Email: email,
 		Role: role,
+		InstitutionID: institutionID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
     		IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256 , accessClaim)
-	signedAccessToken , err := accessToken.SignedString([]byte(accessSecret))
+	accessToken := jwt.NewWithClaims(jwt.SigningMethodRS256 , accessClaim)
+	// kid lets verifiers pick the right key from the JWKS across rotations
+	accessToken.Header["kid"] = signingKey.kid
+	signedAccessToken , err := accessToken.SignedString(signingKey.private)
 	if err != nil {
 		return "", "" , err
 	}
@@ -30,7 +57,9 @@ func GenerateToken(userId string , email string , role string ) (string , string
 	refreshClaim := dto.RefreshClaim{
 		ID: userId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			// jti keeps two tokens minted in the same second distinct
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
     		IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -44,12 +73,59 @@ func GenerateToken(userId string , email string , role string ) (string , string
 	return signedAccessToken , signedRefreshToken , nil
 }
 
-func ValidateToken(tokenString string) (*dto.AccessClaim, error) {
-	secret := os.Getenv("JWT_ACCESS_SECRET")
-	if secret == "" {
-		return nil, fmt.Errorf("missing JWT_ACCESS_SECRET")
+// GenerateServiceToken signs an access token for a service client. The role
+// claim is "service" so no user endpoint accepts it; institutionID is empty
+// for platform-wide tokens.
+func GenerateServiceToken(clientID string, institutionID string, scope string) (string, error) {
+	signingKey, err := activeSigningKey()
+	if err != nil {
+		return "", err
 	}
+	claim := dto.AccessClaim{
+		ID:            clientID,
+		Role:          models.RoleService,
+		InstitutionID: institutionID,
+		Scope:         scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ServiceTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
+	token.Header["kid"] = signingKey.kid
+	return token.SignedString(signingKey.private)
+}
+
+// ImpersonationTokenTTL is how long an admin may act as another user per
+// token; there is no refresh token, the admin asks again.
+const ImpersonationTokenTTL = 15 * time.Minute
 
+// GenerateImpersonationToken signs a read-only access token for user with
+// actor in its act claim. impersonationID becomes the jti, tying every
+// request made with the token back to the admin's request for it.
+func GenerateImpersonationToken(user *models.User, actor dto.ActorClaim, impersonationID string) (string, error) {
+	signingKey, err := activeSigningKey()
+	if err != nil {
+		return "", err
+	}
+	claim := dto.AccessClaim{
+		ID:            user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		InstitutionID: user.InstitutionID,
+		Actor:         &actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        impersonationID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ImpersonationTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
+	token.Header["kid"] = signingKey.kid
+	return token.SignedString(signingKey.private)
+}
+
+func ValidateToken(tokenString string) (*dto.AccessClaim, error) {
 	var claim dto.AccessClaim
 
 	// Parse the token into our custom claim struct
@@ -57,12 +133,14 @@ func ValidateToken(tokenString string) (*dto.AccessClaim, error) {
 		tokenString,
 		&claim,
 		func(t *jwt.Token) (interface{}, error) {
-			// Check if signing method is HS256
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			// Check if signing method is RS256
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
 				return nil, fmt.Errorf("invalid signing method")
 			}
-			return []byte(secret), nil
+			kid, _ := t.Header["kid"].(string)
+			return publicKeyByKid(kid)
 		},
+		jwt.WithValidMethods([]string{"RS256"}),
 	)
 
 	if err != nil {
@@ -75,3 +153,19 @@ func ValidateToken(tokenString string) (*dto.AccessClaim, error) {
 
 	return &claim, nil
 }
+
+// HashToken returns the hex sha256 of a token so it can be stored and looked
+// up without keeping the usable value in the database.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOpaqueToken returns a random URL-safe token for emailed links
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,85 @@
+package jwtutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Private signing keys are stored encrypted with AES-256-GCM under the key
+// encryption key (KEK) in JWT_KEY_ENCRYPTION_KEY, 32 bytes in base64
+// (`openssl rand -base64 32`). The kid is authenticated with the ciphertext,
+// so a stored key cannot be moved to another row. Rows written before
+// encryption hold plain PEM and are encrypted when first loaded.
+const encryptedKeyPrefix = "enc:v1:"
+
+var errNoKEK = errors.New("JWT_KEY_ENCRYPTION_KEY is not set")
+
+func keyEncryptionKey() ([]byte, error) {
+	raw := os.Getenv("JWT_KEY_ENCRYPTION_KEY")
+	if raw == "" {
+		return nil, errNoKEK
+	}
+	kek, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(kek) != 32 {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY must be 32 bytes in base64")
+	}
+	return kek, nil
+}
+
+func keyCipher() (cipher.AEAD, error) {
+	kek, err := keyEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptPrivateKey seals a PEM private key for storage under kid
+func encryptPrivateKey(kid string, privatePEM []byte) (string, error) {
+	aead, err := keyCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, privatePEM, []byte(kid))
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptPrivateKey opens a stored private key. legacy reports a plain PEM
+// row, which the caller should store encrypted.
+func decryptPrivateKey(kid string, stored string) (privatePEM []byte, legacy bool, err error) {
+	if !strings.HasPrefix(stored, encryptedKeyPrefix) {
+		return []byte(stored), true, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedKeyPrefix))
+	if err != nil {
+		return nil, false, fmt.Errorf("signing key %s: %w", kid, err)
+	}
+	aead, err := keyCipher()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, false, fmt.Errorf("signing key %s: ciphertext too short", kid)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	privatePEM, err = aead.Open(nil, nonce, ciphertext, []byte(kid))
+	if err != nil {
+		return nil, false, fmt.Errorf("signing key %s: cannot decrypt, wrong JWT_KEY_ENCRYPTION_KEY?", kid)
+	}
+	return privatePEM, false, nil
+}
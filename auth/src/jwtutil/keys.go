@@ -0,0 +1,260 @@
+package jwtutil
+
+import (
+	"auth/src/dto"
+	"auth/src/models"
+	"auth/src/repository"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	signingKeyBits = 2048
+
+	// Another replica may rotate at any time. A token with an unknown kid
+	// reloads the keyring (at most this often), and the JWKS is served from
+	// a keyring at most keyringMaxAge old.
+	keyringMinInterval = 10 * time.Second
+	keyringMaxAge      = time.Minute
+)
+
+// keyring holds the keys loaded from signing_keys; keys[0] is the one that signs
+var (
+	keyringMu       sync.RWMutex
+	keyring         []loadedKey
+	keyringLoadedAt time.Time
+	keyringReloadMu sync.Mutex
+)
+
+// The key store; tests replace it
+var (
+	getPublishedSigningKeys = repository.GetPublishedSigningKeys
+	createSigningKey        = repository.CreateSigningKey
+	updateSigningKeyPrivate = repository.UpdateSigningKeyPrivateKey
+)
+
+type loadedKey struct {
+	kid       string
+	private   *rsa.PrivateKey
+	createdAt time.Time
+}
+
+// keyRotationInterval is how long a key signs before a new one is generated
+func keyRotationInterval() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("JWT_KEY_ROTATION_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 30 * 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// InitSigningKeys loads the signing keys, generating the first one if none
+// exist. JWT_KEY_ENCRYPTION_KEY is required.
+func InitSigningKeys() {
+	if _, err := keyEncryptionKey(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := refreshKeyring(); err != nil {
+		log.Fatalf("❌ Failed to initialise signing keys: %v", err)
+	}
+	fmt.Println("✅ Signing keys ready")
+}
+
+// StartKeyRotation reloads the keyring periodically so every replica picks up
+// keys rotated by another one, and rotates when the active key is too old.
+func StartKeyRotation() {
+	ticker := time.NewTicker(time.Hour)
+	go func() {
+		for range ticker.C {
+			if err := refreshKeyring(); err != nil {
+				log.Printf("❌ signing key refresh failed: %v", err)
+			}
+		}
+	}()
+}
+
+// refreshKeyring rotates if the active key is too old and reloads the keyring
+func refreshKeyring() error {
+	keys, err := getPublishedSigningKeys(time.Now().Add(-AccessTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 || time.Since(keys[0].CreatedAt) > keyRotationInterval() {
+		if err := rotateSigningKey(); err != nil {
+			return err
+		}
+	}
+	return loadKeyring()
+}
+
+// loadKeyring reads the published keys without rotating
+func loadKeyring() error {
+	keys, err := getPublishedSigningKeys(time.Now().Add(-AccessTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	loaded := make([]loadedKey, 0, len(keys))
+	for _, k := range keys {
+		privatePEM, legacy, err := decryptPrivateKey(k.Kid, k.PrivateKey)
+		if err != nil {
+			return err
+		}
+		block, _ := pem.Decode(privatePEM)
+		if block == nil {
+			return fmt.Errorf("signing key %s is not valid PEM", k.Kid)
+		}
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("signing key %s: %w", k.Kid, err)
+		}
+		if legacy {
+			encryptLegacyKey(k.Kid, privatePEM)
+		}
+		loaded = append(loaded, loadedKey{kid: k.Kid, private: private, createdAt: k.CreatedAt})
+	}
+
+	keyringMu.Lock()
+	keyring = loaded
+	keyringLoadedAt = time.Now()
+	keyringMu.Unlock()
+	return nil
+}
+
+// encryptLegacyKey replaces a plain PEM row with its encrypted form. A
+// failure is only logged; the row is tried again on the next load.
+func encryptLegacyKey(kid string, privatePEM []byte) {
+	sealed, err := encryptPrivateKey(kid, privatePEM)
+	if err == nil {
+		err = updateSigningKeyPrivate(kid, sealed)
+	}
+	if err != nil {
+		log.Printf("❌ failed to encrypt signing key %s: %v", kid, err)
+		return
+	}
+	log.Printf("🔑 Encrypted stored signing key %s", kid)
+}
+
+// reloadKeyringIfOlder reloads the keyring when it was loaded (or a reload
+// was tried) more than age ago. Reloads run one at a time and a failed one
+// keeps the current keyring, so tokens with made-up kids cannot hammer the
+// database.
+func reloadKeyringIfOlder(age time.Duration) {
+	keyringReloadMu.Lock()
+	defer keyringReloadMu.Unlock()
+
+	keyringMu.Lock()
+	if time.Since(keyringLoadedAt) < age {
+		keyringMu.Unlock()
+		return
+	}
+	keyringLoadedAt = time.Now()
+	keyringMu.Unlock()
+
+	if err := loadKeyring(); err != nil {
+		log.Printf("❌ signing key reload failed: %v", err)
+	}
+}
+
+func rotateSigningKey() error {
+	private, err := rsa.GenerateKey(rand.Reader, signingKeyBits)
+	if err != nil {
+		return err
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(private),
+	})
+
+	kid := uuid.New().String()
+	sealed, err := encryptPrivateKey(kid, privatePEM)
+	if err != nil {
+		return err
+	}
+
+	key := models.SigningKey{
+		Kid:        kid,
+		Algorithm:  "RS256",
+		PrivateKey: sealed,
+	}
+	if err := createSigningKey(&key); err != nil {
+		return err
+	}
+
+	log.Printf("🔑 Rotated access token signing key, new kid %s", key.Kid)
+	return nil
+}
+
+// activeSigningKey returns the key new access tokens are signed with
+func activeSigningKey() (*loadedKey, error) {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("no signing key loaded")
+	}
+	k := keyring[0]
+	return &k, nil
+}
+
+// publicKeyByKid looks up a published key for verification. An unknown kid
+// may have been rotated in by another replica, so the keyring is reloaded.
+func publicKeyByKid(kid string) (*rsa.PublicKey, error) {
+	if key := keyringPublicKey(kid); key != nil {
+		return key, nil
+	}
+	reloadKeyringIfOlder(keyringMinInterval)
+	if key := keyringPublicKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func keyringPublicKey(kid string) *rsa.PublicKey {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	for _, k := range keyring {
+		if k.kid == kid {
+			return &k.private.PublicKey
+		}
+	}
+	return nil
+}
+
+// JWKS returns the public half of every published key, including keys
+// another replica rotated in within the last keyringMaxAge
+func JWKS() dto.JWKSet {
+	reloadKeyringIfOlder(keyringMaxAge)
+
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	set := dto.JWKSet{Keys: make([]dto.JWK, 0, len(keyring))}
+	for _, k := range keyring {
+		pub := k.private.PublicKey
+		set.Keys = append(set.Keys, dto.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: k.kid,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+	return set
+}
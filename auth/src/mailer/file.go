@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileSender writes each message as an .eml file, handy for local runs and
+// for inspecting the exact links that were sent.
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s *FileSender) Send(msg Message) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New().String())
+	return os.WriteFile(filepath.Join(s.Dir, name), render(s.From, msg), 0o644)
+}
+
+// LogSender only logs the message, for development with MAIL_DRIVER=log.
+// Token parameters are redacted: the links are as good as a password to
+// anyone who can read the logs.
+type LogSender struct {
+	From string
+}
+
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+func (s *LogSender) Send(msg Message) error {
+	log.Printf("📧 mail to=%s subject=%q\n%s", msg.To, msg.Subject, redactTokens(msg.Body))
+	return nil
+}
+
+func redactTokens(body string) string {
+	return tokenParam.ReplaceAllString(body, "${1}[redacted]")
+}
+
+// render builds an RFC 5322 message
+func render(from string, msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	buf.WriteString(msg.Body)
+	return buf.Bytes()
+}
+
+// envelopeAddress strips a display name, "NeuroIQ <a@b>" → "a@b"
+func envelopeAddress(from string) string {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return from
+	}
+	return addr.Address
+}
@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string // plain text
+}
+
+// Sender delivers a single message. Implementations are chosen by MAIL_DRIVER.
+type Sender interface {
+	Send(msg Message) error
+}
+
+var sender Sender
+
+func GetSender() Sender {
+	return sender
+}
+
+// Send delivers msg through the configured sender
+func Send(msg Message) error {
+	if sender == nil {
+		return fmt.Errorf("mailer not initialised")
+	}
+	return sender.Send(msg)
+}
+
+// InitMailer picks the sender from MAIL_DRIVER: smtp, file or log. There is
+// no default: mails carry sign-in links, and a deployment that forgot to
+// configure delivery must not quietly drop them into a log.
+func InitMailer() {
+	s, err := newSender()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	sender = s
+
+	log.Printf("✅ Mailer initialised (%T)", sender)
+}
+
+func newSender() (Sender, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "NeuroIQ <no-reply@neuroiq.local>"
+	}
+
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is not set")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "25"
+		}
+		return &SMTPSender{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		return &FileSender{Dir: dir, From: from}, nil
+	case "log":
+		return &LogSender{From: from}, nil
+	case "":
+		return nil, fmt.Errorf("MAIL_DRIVER is not set (smtp, file, or log for development)")
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+}
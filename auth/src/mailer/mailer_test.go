@@ -0,0 +1,231 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTP is an SMTP relay that records what it was sent. It advertises
+// AUTH PLAIN so the test can see whether the client authenticates.
+type fakeSMTP struct {
+	net.Listener
+
+	mu       sync.Mutex
+	commands []string
+	data     string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{Listener: l}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		switch verb {
+		case "EHLO":
+			reply("250-fake")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "DATA":
+			reply("354 go ahead")
+			var body strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				body.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = body.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTP) sent() ([]string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...), s.data
+}
+
+func (s *fakeSMTP) sender(username string) *SMTPSender {
+	host, port, _ := net.SplitHostPort(s.Addr().String())
+	return &SMTPSender{Host: host, Port: port, Username: username, Password: "secret", From: "NeuroIQ <no-reply@neuroiq.test>"}
+}
+
+var testMessage = Message{To: "ada@example.edu", Subject: "Verify your NeuroIQ email", Body: "Hi Ada,\n\nhttps://neuroiq.test/verify-email?token=abc\n"}
+
+func TestSMTPSenderDelivers(t *testing.T) {
+	relay := newFakeSMTP(t)
+	if err := relay.sender("").Send(testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	commands, data := relay.sent()
+	joined := strings.Join(commands, "\n")
+	for _, want := range []string{"MAIL FROM:<no-reply@neuroiq.test>", "RCPT TO:<ada@example.edu>"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("commands lack %q:\n%s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "AUTH") {
+		t.Error("authenticated without a username")
+	}
+	for _, want := range []string{"From: NeuroIQ <no-reply@neuroiq.test>\r\n", "To: ada@example.edu\r\n", "Subject: Verify your NeuroIQ email\r\n", "token=abc"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("message lacks %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestSMTPSenderAuthenticates(t *testing.T) {
+	relay := newFakeSMTP(t)
+	s := relay.sender("mailer")
+	s.Host = "localhost" // PLAIN auth is only sent in the clear to localhost
+	if err := s.Send(testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	commands, _ := relay.sent()
+	if !strings.Contains(strings.Join(commands, "\n"), "AUTH PLAIN") {
+		t.Errorf("no AUTH PLAIN in %v", commands)
+	}
+}
+
+func TestSMTPSenderRelayDown(t *testing.T) {
+	relay := newFakeSMTP(t)
+	s := relay.sender("")
+	relay.Close()
+	if err := s.Send(testMessage); err == nil {
+		t.Fatal("Send succeeded with the relay down")
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	s := &FileSender{Dir: dir, From: "NeuroIQ <no-reply@neuroiq.test>"}
+	if err := s.Send(testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("files = %v, %v; want one .eml", files, err)
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "Subject: Verify your NeuroIQ email\r\n") || !strings.HasSuffix(string(raw), testMessage.Body) {
+		t.Errorf("unexpected message:\n%s", raw)
+	}
+}
+
+func TestInitMailerDrivers(t *testing.T) {
+	prev := sender
+	t.Cleanup(func() { sender = prev })
+
+	t.Setenv("MAIL_DRIVER", "file")
+	t.Setenv("MAIL_DIR", t.TempDir())
+	InitMailer()
+	if _, ok := GetSender().(*FileSender); !ok {
+		t.Errorf("file driver gave %T", GetSender())
+	}
+
+	t.Setenv("MAIL_DRIVER", "log")
+	InitMailer()
+	if _, ok := GetSender().(*LogSender); !ok {
+		t.Errorf("log driver gave %T", GetSender())
+	}
+
+	for _, driver := range []string{"", "sendmail"} {
+		t.Setenv("MAIL_DRIVER", driver)
+		if s, err := newSender(); err == nil {
+			t.Errorf("MAIL_DRIVER=%q gave %T", driver, s)
+		}
+	}
+	t.Setenv("MAIL_DRIVER", "smtp")
+	t.Setenv("SMTP_HOST", "")
+	if _, err := newSender(); err == nil {
+		t.Error("smtp driver without SMTP_HOST succeeded")
+	}
+
+	sender = nil
+	if err := Send(testMessage); err == nil {
+		t.Error("Send without a sender succeeded")
+	}
+}
+
+func TestEnvelopeAddress(t *testing.T) {
+	for from, want := range map[string]string{
+		"NeuroIQ <no-reply@neuroiq.test>": "no-reply@neuroiq.test",
+		"no-reply@neuroiq.test":           "no-reply@neuroiq.test",
+		"not an address":                  "not an address",
+	} {
+		if got := envelopeAddress(from); got != want {
+			t.Errorf("envelopeAddress(%q) = %q, want %q", from, got, want)
+		}
+	}
+}
+
+func TestRedactTokens(t *testing.T) {
+	for body, want := range map[string]string{
+		"Reset: https://app.test/reset-password?token=abc-DEF_123\nThanks": "Reset: https://app.test/reset-password?token=[redacted]\nThanks",
+		"https://app.test/invite?next=%2F&token=abc%2Bdef&lang=en":         "https://app.test/invite?next=%2F&token=[redacted]&lang=en",
+		"No links here":                       "No links here",
+		"https://app.test/verify?tokenless=1": "https://app.test/verify?tokenless=1",
+	} {
+		if got := redactTokens(body); got != want {
+			t.Errorf("redactTokens(%q) = %q, want %q", body, got, want)
+		}
+	}
+}
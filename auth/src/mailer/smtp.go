@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+)
+
+// SMTPSender sends through a plain SMTP relay (MailHog, Postfix, a provider).
+// Auth is only attempted when a username is configured.
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	return smtp.SendMail(
+		net.JoinHostPort(s.Host, s.Port),
+		auth,
+		envelopeAddress(s.From),
+		[]string{msg.To},
+		render(s.From, msg),
+	)
+}
@@ -3,9 +3,17 @@ package middleware
 import (
 	"auth/src/dto"
 	"auth/src/jwtutil"
+	"auth/src/models"
+	"auth/src/repository"
 	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
 
@@ -13,42 +21,227 @@ type AuthContext struct {
 	UserID string
 	Email  string
 	Role   string
+	InstitutionID string
+	Service bool     // token was issued to a service client; UserID is its client ID
+	Scopes  []string // granted scopes of a service token
+	ActorID string   // admin impersonating the user; such tokens are read-only
 	Claims *dto.AccessClaim
 }
+
+// HasScope reports whether a service token was granted scope
+func (a AuthContext) HasScope(scope string) bool {
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 type contextKey string
 
 const AuthKey contextKey = "auth_context"
 
 
+// AuthMiddleware admits user tokens only; service tokens are refused
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+		authCtx, ok := authenticate(w, r)
+		if !ok {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+		if authCtx.Service {
+			http.Error(w, "Service tokens are not accepted here", http.StatusForbidden)
+			return
+		}
+		if !allowImpersonation(w, r, authCtx) {
 			return
 		}
 
-		tokenString := parts[1]
+		ctx := context.WithValue(r.Context(), AuthKey, authCtx)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RoleOrServiceMiddleware admits users with one of roles and service tokens
+// granted scope. Both must carry an institution.
+func RoleOrServiceMiddleware(scope string, roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authCtx, ok := authenticate(w, r)
+			if !ok {
+				return
+			}
+
+			allowed := authCtx.Service && authCtx.HasScope(scope)
+			for _, role := range roles {
+				allowed = allowed || (!authCtx.Service && authCtx.Role == role)
+			}
+			if !allowed {
+				http.Error(w, "Forbidden: insufficient role or scope", http.StatusForbidden)
+				return
+			}
+			if !allowImpersonation(w, r, authCtx) {
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), AuthKey, authCtx)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func authenticate(w http.ResponseWriter, r *http.Request) (AuthContext, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+		return AuthContext{}, false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+		return AuthContext{}, false
+	}
+
+	tokenString := parts[1]
+
+	claims, err := jwtutil.ValidateToken(tokenString)
+	if err != nil {
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return AuthContext{}, false
+	}
+
+	// Tokens minted before tenancy carry no institution; make them log in again.
+	// Platform-wide service tokens have none either and cannot use tenant routes.
+	if claims.InstitutionID == "" {
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return AuthContext{}, false
+	}
+
+	// Signing out a device ends its access tokens here at once; the other
+	// services accept them until they expire
+	if claims.SessionID != "" {
+		live, err := repository.SessionLive(claims.SessionID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Failed to check session", http.StatusInternalServerError)
+			return AuthContext{}, false
+		}
+		if !live {
+			http.Error(w, "Session has ended", http.StatusUnauthorized)
+			return AuthContext{}, false
+		}
+		if touchDue(claims.SessionID) {
+			if err := repository.TouchSession(claims.SessionID); err != nil {
+				log.Printf("failed to record session use: %v", err)
+			}
+		}
+	}
 
-		claims, err := jwtutil.ValidateToken(tokenString)
-		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+	// Store multiple values in context
+	return AuthContext{
+		UserID: claims.ID,
+		Email:  claims.Email,
+		Role:   claims.Role,
+		InstitutionID: claims.InstitutionID,
+		Service: claims.Role == models.RoleService,
+		Scopes:  strings.Fields(claims.Scope),
+		ActorID: actorID(claims),
+		Claims: claims,
+	}, true
+}
+
+// sessionTouchInterval is how often one replica records use of a session
+const sessionTouchInterval = time.Minute
+
+var (
+	touchedMu sync.Mutex
+	touchedAt = map[string]time.Time{}
+)
+
+// touchDue reports whether the use of sessionID should be written now, that
+// is when this replica has not written it for sessionTouchInterval
+func touchDue(sessionID string) bool {
+	touchedMu.Lock()
+	defer touchedMu.Unlock()
+
+	now := time.Now()
+	if last, ok := touchedAt[sessionID]; ok && now.Sub(last) < sessionTouchInterval {
+		return false
+	}
+	if len(touchedAt) > 10000 {
+		for id, last := range touchedAt {
+			if now.Sub(last) >= sessionTouchInterval {
+				delete(touchedAt, id)
+			}
+		}
+	}
+	touchedAt[sessionID] = now
+	return true
+}
+
+func actorID(claims *dto.AccessClaim) string {
+	if claims.Actor == nil {
+		return ""
+	}
+	return claims.Actor.ID
+}
+
+// allowImpersonation lets impersonation tokens read and nothing else, and
+// logs every request they make. Other tokens pass untouched.
+func allowImpersonation(w http.ResponseWriter, r *http.Request, authCtx AuthContext) bool {
+	if authCtx.ActorID == "" {
+		return true
+	}
+
+	readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+	RecordImpersonatedRequest(r, authCtx, "auth", r.Method, r.URL.Path, readOnly)
+	if !readOnly {
+		http.Error(w, "Forbidden: impersonation is read-only", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// RecordImpersonatedRequest appends one request made with an impersonation
+// token to the audit log: the admin as actor, the user as target and the
+// token's jti in the detail. A failed write is logged.
+func RecordImpersonatedRequest(r *http.Request, authCtx AuthContext, service string, method string, path string, allowed bool) {
+	e := models.AuthEvent{
+		InstitutionID: &authCtx.InstitutionID,
+		ActorID:       authCtx.ActorID,
+		ActorEmail:    authCtx.Claims.Actor.Email,
+		TargetID:      authCtx.UserID,
+		EventType:     models.EventImpersonatedRequest,
+		Outcome:       models.OutcomeSuccess,
+		Detail:        service + " " + method + " " + path + " (" + authCtx.Claims.RegisteredClaims.ID + ")",
+		IPAddress:     ClientIP(r),
+		UserAgent:     r.UserAgent(),
+	}
+	if !allowed {
+		e.Outcome = models.OutcomeFailure
+	}
+
+	if err := repository.CreateAuthEvent(&e); err != nil {
+		log.Printf("⚠️ Failed to record impersonated request %s: %v", e.Detail, err)
+	}
+}
+
+// ImpersonationMiddleware admits impersonation tokens only, for the endpoint
+// other services report their impersonated requests to
+func ImpersonationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authCtx, ok := authenticate(w, r)
+		if !ok {
 			return
 		}
 
-		// Store multiple values in context
-		authCtx := AuthContext{
-			UserID: claims.ID,
-			Email:  claims.Email,
-			Role:   claims.Role,
-			Claims: claims,
+		if authCtx.ActorID == "" {
+			http.Error(w, "Forbidden: not an impersonation token", http.StatusForbidden)
+			return
 		}
 
 		ctx := context.WithValue(r.Context(), AuthKey, authCtx)
@@ -56,3 +249,41 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// ClientIP prefers the address forwarded by the gateway over the socket peer
+func ClientIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		return strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RequireRole rejects requests whose authenticated role is not in roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authData, ok := r.Context().Value(AuthKey).(AuthContext)
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			for _, role := range roles {
+				if authData.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			http.Error(w, "Forbidden: insufficient role", http.StatusForbidden)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// TeachingAssignment grants a teacher a subject of one cohort for an academic
+// year. Downstream services only let teachers manage exams, material and
+// grades of the subjects they are assigned.
+type TeachingAssignment struct {
+	ID            string    `json:"id" db:"id"` // UUID
+	InstitutionID string    `json:"institution_id" db:"institution_id"`
+	TeacherID     string    `json:"teacher_id" db:"teacher_id"`
+	Subject       string    `json:"subject" db:"subject"`
+	Branch        string    `json:"branch" db:"branch"`
+	Semester      int       `json:"semester" db:"semester"`
+	Section       string    `json:"section" db:"section"` // empty means every section
+	AcademicYear  string    `json:"academic_year" db:"academic_year"`
+	CreatedBy     string    `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
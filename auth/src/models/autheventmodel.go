@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event types of the audit log
+const (
+	EventLogin                = "login"
+	EventMFAVerify            = "mfa_verify"
+	EventSSOLogin             = "sso_login" // identity provider callback
+	EventTokenRefresh         = "token_refresh"
+	EventServiceToken         = "service_token"
+	EventLogout               = "logout"
+	EventLogoutAll            = "logout_all"
+	EventSessionRevoke        = "session_revoke"
+	EventPasswordSet          = "password_set" // reset link or account invite
+	EventPasswordChange       = "password_change"
+	EventUserUpdate           = "user_update"
+	EventStudentProfileUpdate = "student_profile_update"
+	EventMFAChange            = "mfa_change"
+	EventRoleRequest          = "role_request"
+	EventImpersonatedRequest  = "impersonated_request" // actor is the admin, target the user
+
+	// Admin actions
+	EventUserStatus          = "user_status"
+	EventUserDelete          = "user_delete"
+	EventUserUnlock          = "user_unlock"
+	EventPasswordResetForced = "password_reset_forced"
+	EventMFAReset            = "mfa_reset"
+	EventRoleChange          = "role_change"
+	EventRoleRequestDecision = "role_request_decision"
+	EventInviteCreate        = "invite_create"
+	EventStudentDelete       = "student_delete"
+	EventStudentImport       = "student_import"
+	EventStudentPromotion    = "student_promotion"
+	EventStudentStatus       = "student_status"
+	EventStudentAcademic     = "student_academic"
+	EventAssignmentChange    = "assignment_change"
+	EventInstitutionCreate   = "institution_create"
+	EventServiceClientChange = "service_client_change"
+	EventSSOConfigChange     = "sso_config_change"
+	EventDataExport          = "data_export"
+	EventDataErasure         = "data_erasure"
+	EventImpersonationStart  = "impersonation_start"
+)
+
+// AuthEvent is one row of the append-only audit log
+type AuthEvent struct {
+	ID            string    `json:"id" db:"id"` // UUID
+	InstitutionID *string   `json:"institution_id,omitempty" db:"institution_id"`
+	ActorID       string    `json:"actor_id" db:"actor_id"`
+	ActorEmail    string    `json:"actor_email" db:"actor_email"`
+	TargetID      string    `json:"target_id" db:"target_id"`
+	EventType     string    `json:"event_type" db:"event_type"`
+	Outcome       string    `json:"outcome" db:"outcome"` // success | failure
+	Detail        string    `json:"detail" db:"detail"`
+	IPAddress     string    `json:"ip_address" db:"ip_address"`
+	UserAgent     string    `json:"user_agent" db:"user_agent"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
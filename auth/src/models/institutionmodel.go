@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Institution is a tenant: every user belongs to exactly one
+type Institution struct {
+	ID        string    `json:"id" db:"id"` // UUID
+	Name      string    `json:"name" db:"name"`
+	Code      string    `json:"code" db:"code"` // short code typed at signup
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// UserMFA is a user's TOTP enrollment
+type UserMFA struct {
+	UserID       string     `json:"user_id" db:"user_id"`
+	Secret       string     `json:"-" db:"secret"`                        // base32
+	EnabledAt    *time.Time `json:"enabled_at,omitempty" db:"enabled_at"` // nil while enrollment is pending
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (m *UserMFA) Enabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
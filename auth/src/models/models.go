@@ -10,7 +10,12 @@ type User struct {
 	Email         string    `json:"email" db:"email"`
 	PasswordHash  string    `json:"password_hash" db:"password_hash"`
 	Role          string    `json:"role" db:"role"`                               // student | teacher | admin
-	Institution	  string   	`json:"institution,omitempty" db:"institution"` // nullable
+	Institution	  string   	`json:"institution,omitempty" db:"institution"` // institution name, kept for display
+	InstitutionID string    `json:"institution_id" db:"institution_id"`       // tenant
+	Status        string    `json:"status" db:"status"`                           // active | inactive | blocked | deleted
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"` // nil until the emailed link is used
+	PasswordResetRequired bool `json:"password_reset_required" db:"password_reset_required"` // set by an admin, cleared by a reset
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`          // soft delete
 	CreatedAt     time.Time	`json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time	`json:"updated_at" db:"updated_at"`
 }
@@ -23,4 +28,5 @@ const (
 	StatusActive   = "active"
 	StatusInactive = "inactive"
 	StatusBlocked  = "blocked"
+	StatusDeleted  = "deleted"
 )
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Scopes of the tokens auth signs for itself when it collects or erases a
+// user's data in the other services. No service client can be granted them.
+const (
+	ScopePrivacyExport = "privacy:export"
+	ScopePrivacyErase  = "privacy:erase"
+)
+
+const (
+	ErasureStatusPartial   = "partial" // some services have not confirmed yet
+	ErasureStatusCompleted = "completed"
+)
+
+// DataErasure records the pseudonymisation of one user across services
+type DataErasure struct {
+	ID              string     `json:"id" db:"id"` // UUID
+	UserID          string     `json:"user_id" db:"user_id"`
+	InstitutionID   string     `json:"institution_id" db:"institution_id"`
+	Pseudonym       string     `json:"pseudonym" db:"pseudonym"` // replaces the user and student IDs elsewhere
+	RequestedBy     string     `json:"requested_by" db:"requested_by"`
+	Reason          string     `json:"reason" db:"reason"`
+	Status          string     `json:"status" db:"status"`
+	PendingServices string     `json:"pending_services" db:"pending_services"` // comma separated
+	RollNumber      string     `json:"-" db:"roll_number"`                     // the student's, until no service is pending
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// UserDataExport is everything auth holds about one user
+type UserDataExport struct {
+	User           *User               `json:"user"`
+	Student        *Student            `json:"student,omitempty"`
+	StudentHistory []StudentHistory    `json:"student_history"`
+	RoleAudit      []RoleAudit         `json:"role_audit"`
+	RoleRequests   []RoleChangeRequest `json:"role_requests"`
+	Sessions       []UserSession       `json:"sessions"`
+	RefreshTokens  []RefreshToken      `json:"refresh_tokens"`
+	Identities     []UserIdentity      `json:"identities"`
+	MFAEnabled     bool                `json:"mfa_enabled"`
+	AuthEvents     []AuthEvent         `json:"auth_events"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	RoleRequestPending  = "pending"
+	RoleRequestApproved = "approved"
+	RoleRequestRejected = "rejected"
+)
+
+// InviteCode lets someone sign up directly with a teacher/admin role
+type InviteCode struct {
+	ID            string    `json:"id" db:"id"` // UUID
+	CodeHash      string    `json:"-" db:"code_hash"`
+	Role          string    `json:"role" db:"role"`
+	InstitutionID string    `json:"institution_id" db:"institution_id"` // institution the new account joins
+	MaxUses       int       `json:"max_uses" db:"max_uses"`
+	Uses          int       `json:"uses" db:"uses"`
+	CreatedBy     string    `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// RoleChangeRequest is a user's pending ask for a different role
+type RoleChangeRequest struct {
+	ID            string     `json:"id" db:"id"` // UUID
+	UserID        string     `json:"user_id" db:"user_id"`
+	FromRole      string     `json:"from_role" db:"from_role"`
+	RequestedRole string     `json:"requested_role" db:"requested_role"`
+	Reason        string     `json:"reason" db:"reason"`
+	Status        string     `json:"status" db:"status"` // pending | approved | rejected
+	DecidedBy     *string    `json:"decided_by,omitempty" db:"decided_by"`
+	DecisionNote  string     `json:"decision_note" db:"decision_note"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+}
+
+// RoleAudit records one applied role change
+type RoleAudit struct {
+	ID        string    `json:"id" db:"id"` // UUID
+	UserID    string    `json:"user_id" db:"user_id"`
+	OldRole   string    `json:"old_role" db:"old_role"`
+	NewRole   string    `json:"new_role" db:"new_role"`
+	ActorID   *string   `json:"actor_id,omitempty" db:"actor_id"`
+	RequestID *string   `json:"request_id,omitempty" db:"request_id"`
+	Reason    string    `json:"reason" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
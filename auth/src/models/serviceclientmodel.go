@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// RoleService is the role claim of tokens issued to services rather than users
+const RoleService = "service"
+
+// Scopes a service client may be granted
+const (
+	ScopeStudentsRead = "students:read" // student search and lookup
+	ScopeLLMEvaluate  = "llm:evaluate"  // answer evaluation in the LLM service
+)
+
+var ServiceScopes = []string{ScopeStudentsRead, ScopeLLMEvaluate}
+
+// ScopeExamsRead is signed by auth for itself, to ask management whether a
+// student has an exam running. No service client can be granted it.
+const ScopeExamsRead = "exams:read"
+
+// ScopeTenancyBackfill is signed by auth for itself, to assign records the
+// services hold from before tenancy. No service client can be granted it.
+const ScopeTenancyBackfill = "tenancy:backfill"
+
+// ServiceClient is a calling service's identity for the client credentials grant
+type ServiceClient struct {
+	ID         string     `json:"id" db:"id"` // UUID
+	ClientID   string     `json:"client_id" db:"client_id"`
+	SecretHash string     `json:"-" db:"secret_hash"`
+	Name       string     `json:"name" db:"name"`
+	Scopes     string     `json:"scopes" db:"scopes"` // space separated
+	CreatedBy  string     `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// HasScope reports whether the client was granted scope
+func (c *ServiceClient) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
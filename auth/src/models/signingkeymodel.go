@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type SigningKey struct {
+	Kid        string     `json:"kid" db:"kid"`
+	Algorithm  string     `json:"alg" db:"algorithm"` // RS256
+	PrivateKey string     `json:"-" db:"private_key"` // PKCS#1 PEM, encrypted under JWT_KEY_ENCRYPTION_KEY
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty" db:"retired_at"` // set once a newer key takes over signing
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// OIDCProvider is an institution's identity provider for single sign-on
+type OIDCProvider struct {
+	ID              string    `json:"id" db:"id"` // UUID
+	InstitutionID   string    `json:"institution_id" db:"institution_id"`
+	Issuer          string    `json:"issuer" db:"issuer"`
+	ClientID        string    `json:"client_id" db:"client_id"`
+	ClientSecret    string    `json:"-" db:"client_secret"`
+	Scopes          string    `json:"scopes" db:"scopes"`                   // space separated, includes openid
+	AllowedDomains  string    `json:"allowed_domains" db:"allowed_domains"` // comma separated, empty = any
+	JITProvisioning bool      `json:"jit_provisioning" db:"jit_provisioning"`
+	Enabled         bool      `json:"enabled" db:"enabled"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AllowsEmail reports whether email is in one of the allowed domains
+func (p *OIDCProvider) AllowsEmail(email string) bool {
+	if p.AllowedDomains == "" {
+		return true
+	}
+	_, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	if !ok {
+		return false
+	}
+	for _, d := range strings.Split(p.AllowedDomains, ",") {
+		if strings.TrimSpace(strings.ToLower(d)) == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// OIDCLoginRequest carries the state, nonce and PKCE verifier of one sign-in
+type OIDCLoginRequest struct {
+	StateHash    string    `db:"state_hash"`
+	ProviderID   string    `db:"provider_id"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ReturnTo     string    `db:"return_to"` // frontend path to land on afterwards
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// UserIdentity links a provider account to a user
+type UserIdentity struct {
+	ID          string     `json:"id" db:"id"` // UUID
+	UserID      string     `json:"user_id" db:"user_id"`
+	ProviderID  string     `json:"provider_id" db:"provider_id"`
+	Subject     string     `json:"subject" db:"subject"`
+	Email       string     `json:"email" db:"email"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+}
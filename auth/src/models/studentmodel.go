@@ -21,6 +21,44 @@ type Student struct {
 	UserID string `json:"user_id" db:"user_id"` // FK referencing user table if login exists
 	Active bool   `json:"active" db:"active"`   // Is student active
 
+	// Academic lifecycle, changed only by admins
+	AcademicStatus      string     `json:"academic_status" db:"academic_status"`                       // enrolled | detained | graduated | withdrawn
+	StatusEffectiveDate *time.Time `json:"status_effective_date,omitempty" db:"status_effective_date"` // when the current status took effect
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // soft delete
+
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+const (
+	AcademicStatusEnrolled  = "enrolled"
+	AcademicStatusDetained  = "detained"
+	AcademicStatusGraduated = "graduated"
+	AcademicStatusWithdrawn = "withdrawn"
+
+	HistoryChangePromotion = "promotion"
+	HistoryChangeStatus    = "status"
+	HistoryChangeAcademic  = "academic"
+	HistoryChangeImport    = "import"
+)
+
+// StudentHistory records one change of a student's semester, branch, section or academic status
+type StudentHistory struct {
+	ID            string    `json:"id" db:"id"`
+	StudentID     string    `json:"student_id" db:"student_id"`
+	ChangeType    string    `json:"change_type" db:"change_type"`
+	FromSemester  int       `json:"from_semester" db:"from_semester"`
+	ToSemester    int       `json:"to_semester" db:"to_semester"`
+	FromBranch    string    `json:"from_branch" db:"from_branch"`
+	ToBranch      string    `json:"to_branch" db:"to_branch"`
+	FromSection   string    `json:"from_section" db:"from_section"`
+	ToSection     string    `json:"to_section" db:"to_section"`
+	FromStatus    string    `json:"from_status" db:"from_status"`
+	ToStatus      string    `json:"to_status" db:"to_status"`
+	EffectiveDate time.Time `json:"effective_date" db:"effective_date"`
+	Reason        string    `json:"reason" db:"reason"`
+	ActorID       *string   `json:"actor_id,omitempty" db:"actor_id"`
+	BatchID       *string   `json:"batch_id,omitempty" db:"batch_id"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID        string `json:"id" db:"id"` // UUID
+	UserID    string `json:"user_id" db:"user_id"`
+	FamilyID  string `json:"family_id" db:"family_id"` // shared by every rotation of one login
+	TokenHash string `json:"-" db:"token_hash"`        // sha256 of the signed refresh token
+
+	UserAgent string `json:"user_agent" db:"user_agent"`
+	IPAddress string `json:"ip_address" db:"ip_address"`
+
+	IssuedAt   time.Time  `json:"issued_at" db:"issued_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *string    `json:"replaced_by,omitempty" db:"replaced_by"` // token issued when this one was rotated
+}
+
+// UserSession is one login on one device; its ID is the family ID of the
+// login's refresh tokens and the sid claim of its access tokens
+type UserSession struct {
+	ID          string     `json:"id" db:"id"` // UUID
+	UserID      string     `json:"user_id" db:"user_id"`
+	DeviceLabel string     `json:"device_label" db:"device_label"` // X-Device-Label header, else derived from the user agent
+	UserAgent   string     `json:"user_agent" db:"user_agent"`
+	IPAddress   string     `json:"ip_address" db:"ip_address"` // of the latest login, refresh or request to auth
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	Current     bool       `json:"current" db:"-"` // the session of the caller's access token
+}
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeAccountInvite     = "account_invite" // first password for an admin-created account
+	TokenPurposeMFAChallenge      = "mfa_challenge"  // password accepted, second factor pending
+	TokenPurposeMFAEnrollment     = "mfa_enrollment" // password accepted, MFA required but not set up; enrollment only
+	TokenPurposeSSOLogin          = "sso_login"      // provider sign-in done, handed to the frontend
+)
+
+// UserToken is a single-use secret mailed to a user
+type UserToken struct {
+	ID        string     `json:"id" db:"id"` // UUID
+	UserID    string     `json:"user_id" db:"user_id"`
+	Purpose   string     `json:"purpose" db:"purpose"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+}
+
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+
+	// Requests that send mail (password reset, verification resend) are
+	// counted in the same table
+	MailScopeAccount = "mail"
+	MailScopeIP      = "mail_ip"
+)
+
+// LoginFailure counts recent failed logins for one account or client address
+type LoginFailure struct {
+	Scope         string     `json:"scope" db:"scope"`
+	Key           string     `json:"key" db:"key"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+}
@@ -0,0 +1,315 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "neuroiq-test"
+
+// testProvider is a minimal OpenID provider: discovery, a JWKS whose keys
+// can be rotated, and a token endpoint that enforces PKCE S256
+type testProvider struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	issuer    string // advertised in discovery; the server URL unless overridden
+	keys      map[string]*rsa.PrivateKey
+	challenge string // code_challenge of the pending authorization
+	idToken   string // returned by the token endpoint
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	t.Setenv("OIDC_ALLOW_HTTP", "true")
+
+	p := &testProvider{keys: map[string]*rsa.PrivateKey{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.issuer,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		var keys []map[string]string
+		for kid, key := range p.keys {
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if r.FormValue("code_verifier") == "" || PKCEChallenge(r.FormValue("code_verifier")) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken})
+	})
+
+	p.Server = httptest.NewServer(mux)
+	p.issuer = p.URL
+	t.Cleanup(p.Close)
+	p.rotate(t, "key-1")
+	return p
+}
+
+// rotate replaces the provider's signing keys with a fresh one named kid
+func (p *testProvider) rotate(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.keys = map[string]*rsa.PrivateKey{kid: key}
+	p.mu.Unlock()
+}
+
+// sign issues an ID token with kid; claims override the valid defaults
+func (p *testProvider) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	now := time.Now()
+	all := jwt.MapClaims{
+		"iss":   p.URL,
+		"sub":   "user-1",
+		"aud":   testClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": "nonce-1",
+		"email": "Ada@Example.edu",
+	}
+	for k, v := range claims {
+		if v == nil {
+			delete(all, k)
+			continue
+		}
+		all[k] = v
+	}
+
+	p.mu.Lock()
+	key := p.keys[kid]
+	p.mu.Unlock()
+	if key == nil {
+		t.Fatalf("no key %q", kid)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, all)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func (p *testProvider) client(t *testing.T) *Client {
+	t.Helper()
+	provider, err := Discover(context.Background(), p.URL)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	return &Client{
+		Provider:    provider,
+		ClientID:    testClientID,
+		RedirectURL: "https://neuroiq.test/api/auth/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	p := newTestProvider(t)
+
+	provider, err := Discover(context.Background(), p.URL)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if provider.TokenEndpoint != p.URL+"/token" || provider.JWKSURI != p.URL+"/jwks" {
+		t.Errorf("unexpected endpoints %+v", provider)
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	p := newTestProvider(t)
+	p.issuer = "https://attacker.example"
+
+	if _, err := Discover(context.Background(), p.URL); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected issuer mismatch, got %v", err)
+	}
+}
+
+func TestCheckIssuer(t *testing.T) {
+	t.Setenv("OIDC_ALLOW_HTTP", "")
+	tests := []struct {
+		issuer string
+		ok     bool
+	}{
+		{"https://login.example.edu", true},
+		{"https://login.example.edu/tenant/v2.0", true},
+		{"http://login.example.edu", false},
+		{"https://login.example.edu?x=1", false},
+		{"https://login.example.edu#frag", false},
+		{"login.example.edu", false},
+	}
+	for _, tt := range tests {
+		if err := CheckIssuer(tt.issuer); (err == nil) != tt.ok {
+			t.Errorf("CheckIssuer(%q) = %v, want ok=%v", tt.issuer, err, tt.ok)
+		}
+	}
+}
+
+func TestExchangeRequiresPKCEVerifier(t *testing.T) {
+	p := newTestProvider(t)
+	c := p.client(t)
+
+	verifier, err := RandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := url.Parse(c.AuthCodeURL("state-1", "nonce-1", verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := authURL.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code_challenge_method = %q", q.Get("code_challenge_method"))
+	}
+	if q.Get("code_challenge") == verifier {
+		t.Fatal("verifier sent in the clear")
+	}
+	p.challenge = q.Get("code_challenge")
+	p.idToken = p.sign(t, "key-1", nil)
+
+	if _, err := c.Exchange(context.Background(), "code-1", "wrong-verifier"); err == nil {
+		t.Fatal("exchange with a wrong verifier succeeded")
+	}
+	raw, err := c.Exchange(context.Background(), "code-1", verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if raw != p.idToken {
+		t.Fatal("unexpected id token")
+	}
+}
+
+func TestPKCEChallengeRFC7636(t *testing.T) {
+	// RFC 7636 appendix B
+	got := PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Fatalf("PKCEChallenge = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newTestProvider(t)
+	c := p.client(t)
+	past := time.Now().Add(-10 * time.Minute).Unix()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		nonce  string
+		ok     bool
+	}{
+		{"valid", nil, "nonce-1", true},
+		{"wrong issuer", jwt.MapClaims{"iss": "https://other.example"}, "nonce-1", false},
+		{"wrong audience", jwt.MapClaims{"aud": "someone-else"}, "nonce-1", false},
+		{"multiple audiences with azp", jwt.MapClaims{"aud": []string{testClientID, "api"}, "azp": testClientID}, "nonce-1", true},
+		{"multiple audiences without azp", jwt.MapClaims{"aud": []string{testClientID, "api"}}, "nonce-1", false},
+		{"azp of another client", jwt.MapClaims{"aud": []string{testClientID, "api"}, "azp": "api"}, "nonce-1", false},
+		{"expired", jwt.MapClaims{"exp": past, "iat": past - 60}, "nonce-1", false},
+		{"no exp", jwt.MapClaims{"exp": nil}, "nonce-1", false},
+		{"wrong nonce", nil, "nonce-2", false},
+		{"no nonce", jwt.MapClaims{"nonce": nil}, "", false},
+		{"no sub", jwt.MapClaims{"sub": nil}, "nonce-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := c.VerifyIDToken(context.Background(), p.sign(t, "key-1", tt.claims), tt.nonce)
+			if (err == nil) != tt.ok {
+				t.Fatalf("VerifyIDToken error = %v, want ok=%v", err, tt.ok)
+			}
+			if tt.ok && (token.Subject != "user-1" || token.Email != "ada@example.edu") {
+				t.Errorf("unexpected claims %+v", token)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsForeignKey(t *testing.T) {
+	p := newTestProvider(t)
+	c := p.client(t)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": p.URL, "sub": "user-1", "aud": testClientID, "nonce": "nonce-1",
+		"iat": time.Now().Unix(), "exp": time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "key-1"
+	raw, err := token.SignedString(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.VerifyIDToken(context.Background(), raw, "nonce-1"); err == nil {
+		t.Fatal("token signed by an unpublished key was accepted")
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	p := newTestProvider(t)
+	c := p.client(t)
+
+	old := p.sign(t, "key-1", nil)
+	if _, err := c.VerifyIDToken(context.Background(), old, "nonce-1"); err != nil {
+		t.Fatalf("before rotation: %v", err)
+	}
+
+	p.rotate(t, "key-2")
+	raw := p.sign(t, "key-2", nil)
+
+	// Within jwksMinInterval of the last fetch an unknown kid does not refetch
+	if _, err := c.VerifyIDToken(context.Background(), raw, "nonce-1"); err == nil {
+		t.Fatal("unknown kid accepted without a refetch")
+	}
+
+	jwksMu.Lock()
+	jwks[c.Provider.JWKSURI].fetchedAt = time.Now().Add(-2 * jwksMinInterval)
+	jwksMu.Unlock()
+
+	if _, err := c.VerifyIDToken(context.Background(), raw, "nonce-1"); err != nil {
+		t.Fatalf("after rotation: %v", err)
+	}
+	// The retired key is gone once the new set is fetched
+	if _, err := c.VerifyIDToken(context.Background(), old, "nonce-1"); err == nil {
+		t.Fatal("token of the retired key accepted after rotation")
+	}
+}
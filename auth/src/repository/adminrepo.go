@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"auth/src/db"
+	"auth/src/models"
+)
+
+// UserFilter narrows ListUsers; empty fields other than InstitutionID are ignored
+type UserFilter struct {
+	InstitutionID  string
+	Role           string
+	Status         string
+	Search         string // matched against name and email
+	IncludeDeleted bool
+	Limit          int
+	Offset         int
+}
+
+// StudentFilter narrows ListStudents; empty fields other than InstitutionID are ignored
+type StudentFilter struct {
+	InstitutionID  string
+	Branch         string
+	Semester       int
+	Section        string
+	AcademicStatus string
+	Active         *bool
+	Search         string // matched against name, roll number, enrollment number and email
+	IncludeDeleted bool
+	Limit          int
+	Offset         int
+}
+
+// whereBuilder collects AND-ed conditions with numbered placeholders
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+func (b *whereBuilder) add(cond string, arg interface{}) {
+	b.args = append(b.args, arg)
+	b.conds = append(b.conds, strings.ReplaceAll(cond, "?", fmt.Sprintf("$%d", len(b.args))))
+}
+
+func (b *whereBuilder) sql() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// ListUsers returns one page of users matching f and the total match count
+func ListUsers(f UserFilter) ([]models.User, int, error) {
+	var b whereBuilder
+	b.add("institution_id = ?", f.InstitutionID)
+	if f.Role != "" {
+		b.add("role = ?", f.Role)
+	}
+	if f.Status != "" {
+		b.add("status = ?", f.Status)
+	}
+	if f.Search != "" {
+		b.add("(name ILIKE ? OR email ILIKE ?)", "%"+f.Search+"%")
+	}
+	if !f.IncludeDeleted {
+		b.conds = append(b.conds, "deleted_at IS NULL")
+	}
+	where := b.sql()
+
+	var total int
+	if err := db.DB.Get(&total, "SELECT COUNT(*) FROM users"+where, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	users := []models.User{}
+	query := fmt.Sprintf(
+		"SELECT * FROM users%s ORDER BY created_at DESC LIMIT %d OFFSET %d",
+		where, f.Limit, f.Offset,
+	)
+	if err := db.DB.Select(&users, query, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// ListStudents returns one page of students matching f and the total match count
+func ListStudents(f StudentFilter) ([]models.Student, int, error) {
+	// Students belong to an institution through their login
+	var b whereBuilder
+	b.add("user_id IN (SELECT id FROM users WHERE institution_id = ?)", f.InstitutionID)
+	if f.Branch != "" {
+		b.add("branch = ?", f.Branch)
+	}
+	if f.Semester != 0 {
+		b.add("semester = ?", f.Semester)
+	}
+	if f.Section != "" {
+		b.add("section = ?", f.Section)
+	}
+	if f.AcademicStatus != "" {
+		b.add("academic_status = ?", f.AcademicStatus)
+	}
+	if f.Active != nil {
+		b.add("active = ?", *f.Active)
+	}
+	if f.Search != "" {
+		b.add(`(first_name ILIKE ? OR last_name ILIKE ? OR roll_number ILIKE ?
+			OR enrollment_no ILIKE ? OR email ILIKE ?)`, "%"+f.Search+"%")
+	}
+	if !f.IncludeDeleted {
+		b.conds = append(b.conds, "deleted_at IS NULL")
+	}
+	where := b.sql()
+
+	var total int
+	if err := db.DB.Get(&total, "SELECT COUNT(*) FROM students"+where, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	students := []models.Student{}
+	query := fmt.Sprintf(
+		"SELECT * FROM students%s ORDER BY roll_number ASC LIMIT %d OFFSET %d",
+		where, f.Limit, f.Offset,
+	)
+	if err := db.DB.Select(&students, query, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	return students, total, nil
+}
+
+// SetUsersStatus changes the status of several users of one institution at
+// once and keeps the active flag of their student records in step. Deleted
+// users and users of other institutions are skipped. Returns the IDs that
+// were actually updated.
+func SetUsersStatus(institutionID string, userIDs []string, status string) ([]string, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updated := []string{}
+	err = tx.Select(&updated, `
+		UPDATE users
+		SET status = $2, updated_at = NOW()
+		WHERE id = ANY($1) AND institution_id = $3 AND deleted_at IS NULL
+		RETURNING id
+	`, userIDs, status, institutionID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE students
+		SET active = $2, updated_at = NOW()
+		WHERE user_id = ANY($1) AND deleted_at IS NULL
+	`, updated, status == models.StatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, tx.Commit()
+}
+
+// SoftDeleteUser marks a user and their student record deleted. Rows are kept
+// so exams, answers and seating that reference them still resolve.
+func SoftDeleteUser(userID string) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET status = $2, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, userID, models.StatusDeleted)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE students
+		SET active = FALSE, deleted_at = NOW(), updated_at = NOW()
+		WHERE user_id = $1 AND deleted_at IS NULL
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SoftDeleteStudent marks a student record without a login deleted
+func SoftDeleteStudent(studentID string) error {
+	query := `
+		UPDATE students
+		SET active = FALSE, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	_, err := db.DB.Exec(query, studentID)
+	return err
+}
+
+// SetPasswordResetRequired flags (or clears) a user as having to reset their password before logging in
+func SetPasswordResetRequired(userID string, required bool) error {
+	query := `UPDATE users SET password_reset_required = $2, updated_at = NOW() WHERE id = $1`
+	_, err := db.DB.Exec(query, userID, required)
+	return err
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+)
+
+// AssignmentFilter narrows ListAssignments; empty fields other than InstitutionID are ignored
+type AssignmentFilter struct {
+	InstitutionID string
+	TeacherID     string
+	Subject       string
+	Branch        string
+	Semester      int
+	AcademicYear  string
+	Limit         int
+	Offset        int
+}
+
+// CreateAssignment stores a new teaching assignment
+func CreateAssignment(a *models.TeachingAssignment) error {
+	a.ID = uuid.New().String()
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO teaching_assignments (
+			id, institution_id, teacher_id, subject, branch, semester, section,
+			academic_year, created_by, created_at, updated_at
+		)
+		VALUES (
+			:id, :institution_id, :teacher_id, :subject, :branch, :semester, :section,
+			:academic_year, :created_by, :created_at, :updated_at
+		)
+	`
+
+	_, err := db.DB.NamedExec(query, a)
+	return err
+}
+
+// GetAssignmentByID fetches an assignment of one institution
+func GetAssignmentByID(institutionID string, id string) (*models.TeachingAssignment, error) {
+	var a models.TeachingAssignment
+
+	query := `SELECT * FROM teaching_assignments WHERE id = $1 AND institution_id = $2`
+
+	if err := db.DB.Get(&a, query, id, institutionID); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// AssignmentExists reports whether the same teacher already has a's course,
+// ignoring the assignment with a's own ID
+func AssignmentExists(a *models.TeachingAssignment) (bool, error) {
+	var exists bool
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM teaching_assignments
+			WHERE teacher_id = $1 AND LOWER(subject) = LOWER($2) AND branch = $3
+			AND semester = $4 AND section = $5 AND academic_year = $6 AND id::text <> $7
+		)
+	`
+
+	err := db.DB.Get(&exists, query, a.TeacherID, a.Subject, a.Branch, a.Semester, a.Section, a.AcademicYear, a.ID)
+	return exists, err
+}
+
+// ListAssignments returns one page of assignments matching f and the total match count
+func ListAssignments(f AssignmentFilter) ([]models.TeachingAssignment, int, error) {
+	var b whereBuilder
+	b.add("institution_id = ?", f.InstitutionID)
+	if f.TeacherID != "" {
+		b.add("teacher_id = ?", f.TeacherID)
+	}
+	if f.Subject != "" {
+		b.add("LOWER(subject) = LOWER(?)", f.Subject)
+	}
+	if f.Branch != "" {
+		b.add("branch = ?", f.Branch)
+	}
+	if f.Semester != 0 {
+		b.add("semester = ?", f.Semester)
+	}
+	if f.AcademicYear != "" {
+		b.add("academic_year = ?", f.AcademicYear)
+	}
+	where := b.sql()
+
+	var total int
+	if err := db.DB.Get(&total, "SELECT COUNT(*) FROM teaching_assignments"+where, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	assignments := []models.TeachingAssignment{}
+	query := fmt.Sprintf(
+		"SELECT * FROM teaching_assignments%s ORDER BY academic_year DESC, subject ASC, branch ASC, semester ASC LIMIT %d OFFSET %d",
+		where, f.Limit, f.Offset,
+	)
+	if err := db.DB.Select(&assignments, query, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	return assignments, total, nil
+}
+
+// GetTeacherAssignments returns every assignment of a teacher in one academic year
+func GetTeacherAssignments(teacherID string, academicYear string) ([]models.TeachingAssignment, error) {
+	assignments := []models.TeachingAssignment{}
+
+	query := `
+		SELECT * FROM teaching_assignments
+		WHERE teacher_id = $1 AND academic_year = $2
+		ORDER BY subject ASC, branch ASC, semester ASC, section ASC
+	`
+
+	err := db.DB.Select(&assignments, query, teacherID, academicYear)
+	return assignments, err
+}
+
+// UpdateAssignment saves the course fields of an assignment
+func UpdateAssignment(a *models.TeachingAssignment) error {
+	a.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE teaching_assignments
+		SET
+			teacher_id = :teacher_id,
+			subject = :subject,
+			branch = :branch,
+			semester = :semester,
+			section = :section,
+			academic_year = :academic_year,
+			updated_at = :updated_at
+		WHERE id = :id AND institution_id = :institution_id
+	`
+
+	_, err := db.DB.NamedExec(query, a)
+	return err
+}
+
+// DeleteAssignment removes an assignment of one institution
+func DeleteAssignment(institutionID string, id string) error {
+	query := `DELETE FROM teaching_assignments WHERE id = $1 AND institution_id = $2`
+
+	_, err := db.DB.Exec(query, id, institutionID)
+	return err
+}
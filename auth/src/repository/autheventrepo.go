@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+)
+
+// AuthEventFilter narrows the audit log of one institution; empty fields
+// other than InstitutionID are ignored
+type AuthEventFilter struct {
+	InstitutionID string
+	ActorID       string
+	ActorEmail    string
+	TargetID      string
+	EventTypes    []string
+	Outcome       string
+	IPAddress     string
+	From          *time.Time // inclusive
+	To            *time.Time // exclusive
+	Limit         int
+	Offset        int
+}
+
+// CreateAuthEvent appends one event to the audit log
+func CreateAuthEvent(e *models.AuthEvent) error {
+	e.ID = uuid.New().String()
+	e.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO auth_events (
+			id, institution_id, actor_id, actor_email, target_id,
+			event_type, outcome, detail, ip_address, user_agent, created_at
+		)
+		VALUES (
+			:id, :institution_id, :actor_id, :actor_email, :target_id,
+			:event_type, :outcome, :detail, :ip_address, :user_agent, :created_at
+		)
+	`
+
+	_, err := db.DB.NamedExec(query, e)
+	return err
+}
+
+func (f AuthEventFilter) where() whereBuilder {
+	var b whereBuilder
+	b.add("institution_id = ?", f.InstitutionID)
+	if f.ActorID != "" {
+		b.add("actor_id = ?", f.ActorID)
+	}
+	if f.ActorEmail != "" {
+		b.add("LOWER(actor_email) = LOWER(?)", f.ActorEmail)
+	}
+	if f.TargetID != "" {
+		b.add("target_id = ?", f.TargetID)
+	}
+	if len(f.EventTypes) > 0 {
+		b.add("event_type = ANY(?)", f.EventTypes)
+	}
+	if f.Outcome != "" {
+		b.add("outcome = ?", f.Outcome)
+	}
+	if f.IPAddress != "" {
+		b.add("ip_address = ?", f.IPAddress)
+	}
+	if f.From != nil {
+		b.add("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		b.add("created_at < ?", *f.To)
+	}
+	return b
+}
+
+// ListAuthEvents returns one page of events matching f, newest first, and the total match count
+func ListAuthEvents(f AuthEventFilter) ([]models.AuthEvent, int, error) {
+	b := f.where()
+	where := b.sql()
+
+	var total int
+	if err := db.DB.Get(&total, "SELECT COUNT(*) FROM auth_events"+where, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	events := []models.AuthEvent{}
+	query := fmt.Sprintf(
+		"SELECT * FROM auth_events%s ORDER BY created_at DESC LIMIT %d OFFSET %d",
+		where, f.Limit, f.Offset,
+	)
+	if err := db.DB.Select(&events, query, b.args...); err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// EachAuthEvent streams every event matching f, oldest first, to fn without
+// loading them all; Limit and Offset are ignored. It stops at fn's first error.
+func EachAuthEvent(f AuthEventFilter, fn func(models.AuthEvent) error) error {
+	b := f.where()
+
+	rows, err := db.DB.Queryx("SELECT * FROM auth_events"+b.sql()+" ORDER BY created_at ASC", b.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.AuthEvent
+		if err := rows.StructScan(&e); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateInstitution stores a new tenant
+func CreateInstitution(i *models.Institution) error {
+	i.ID = uuid.New().String()
+	i.Active = true
+	i.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO institutions (id, name, code, active, created_at)
+		VALUES (:id, :name, :code, :active, :created_at)
+	`
+
+	_, err := db.DB.NamedExec(query, i)
+	return err
+}
+
+// CreateInstitutionWithAdmin stores a new tenant together with its first
+// admin, who has no password yet, and the admin's account invite token, all
+// in one transaction
+func CreateInstitutionWithAdmin(i *models.Institution, admin *models.User, inviteHash string, inviteTTL time.Duration) error {
+	i.ID = uuid.New().String()
+	i.Active = true
+	i.CreatedAt = time.Now()
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExec(`
+		INSERT INTO institutions (id, name, code, active, created_at)
+		VALUES (:id, :name, :code, :active, :created_at)
+	`, i)
+	if err != nil {
+		return err
+	}
+
+	prepareNewUser(admin)
+	admin.InstitutionID = i.ID
+	admin.Institution = i.Name
+	if _, err := tx.NamedExec(insertUserQuery, admin); err != nil {
+		return err
+	}
+
+	token := models.UserToken{
+		ID:        uuid.New().String(),
+		UserID:    admin.ID,
+		Purpose:   models.TokenPurposeAccountInvite,
+		TokenHash: inviteHash,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(inviteTTL),
+	}
+	if _, err := tx.NamedExec(insertUserTokenQuery, &token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func GetInstitutionByID(id string) (*models.Institution, error) {
+	var institution models.Institution
+
+	err := db.DB.Get(&institution, `SELECT * FROM institutions WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &institution, nil
+}
+
+// FindActiveInstitution matches an active institution by code or by name, ignoring case
+func FindActiveInstitution(codeOrName string) (*models.Institution, error) {
+	var institution models.Institution
+
+	query := `
+		SELECT *
+		FROM institutions
+		WHERE active = TRUE
+		AND (UPPER(code) = UPPER($1) OR LOWER(name) = LOWER($1))
+		LIMIT 1
+	`
+
+	err := db.DB.Get(&institution, query, codeOrName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &institution, nil
+}
+
+// ListActiveInstitutions is the public list shown at signup
+func ListActiveInstitutions() ([]models.Institution, error) {
+	institutions := []models.Institution{}
+
+	err := db.DB.Select(&institutions, `SELECT * FROM institutions WHERE active = TRUE ORDER BY name`)
+	return institutions, err
+}
+
+// InstitutionExists reports whether the name or code is already taken, active or not
+func InstitutionExists(name string, code string) (bool, error) {
+	var exists bool
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM institutions
+			WHERE LOWER(name) = LOWER($1) OR UPPER(code) = UPPER($2)
+		)
+	`
+
+	err := db.DB.Get(&exists, query, name, code)
+	return exists, err
+}
+
+// ListInstitutions returns every institution, active or not
+func ListInstitutions() ([]models.Institution, error) {
+	institutions := []models.Institution{}
+
+	err := db.DB.Select(&institutions, `SELECT * FROM institutions ORDER BY name`)
+	return institutions, err
+}
+
+// GetInstitutionOwnerIDs returns the IDs of the institution's users and of
+// their student records, deleted ones included, for the tenancy backfill
+func GetInstitutionOwnerIDs(institutionID string) (userIDs []string, studentIDs []string, err error) {
+	userIDs = []string{}
+	studentIDs = []string{}
+
+	if err := db.DB.Select(&userIDs, `SELECT id FROM users WHERE institution_id = $1 ORDER BY id`, institutionID); err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT s.id FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE u.institution_id = $1
+		ORDER BY s.id
+	`
+	if err := db.DB.Select(&studentIDs, query, institutionID); err != nil {
+		return nil, nil, err
+	}
+	return userIDs, studentIDs, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+)
+
+// CreateSigningKey stores a new signing key and retires every key before it
+func CreateSigningKey(k *models.SigningKey) error {
+	k.CreatedAt = time.Now()
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE signing_keys SET retired_at = $1 WHERE retired_at IS NULL`, k.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(`
+		INSERT INTO signing_keys (kid, algorithm, private_key, created_at)
+		VALUES (:kid, :algorithm, :private_key, :created_at)
+	`, k)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPublishedSigningKeys returns the active key plus keys retired after since, newest first
+func GetPublishedSigningKeys(since time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+
+	query := `
+		SELECT *
+		FROM signing_keys
+		WHERE retired_at IS NULL OR retired_at > $1
+		ORDER BY created_at DESC
+	`
+
+	err := db.DB.Select(&keys, query, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// UpdateSigningKeyPrivateKey rewrites the stored private key of kid, to
+// encrypt a key stored before encryption
+func UpdateSigningKeyPrivateKey(kid string, privateKey string) error {
+	_, err := db.DB.Exec(`UPDATE signing_keys SET private_key = $2 WHERE kid = $1`, kid, privateKey)
+	return err
+}
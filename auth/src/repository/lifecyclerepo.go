@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// CohortFilter selects the students of one institution promoted together;
+// an empty Section means every section
+type CohortFilter struct {
+	InstitutionID string
+	Branch        string
+	Semester      int
+	Section       string
+	Exclude       []string // student IDs held back from this promotion
+}
+
+// PromotionResult lists the students moved by a cohort promotion
+type PromotionResult struct {
+	BatchID   string   `json:"batch_id"`
+	Promoted  []string `json:"promoted"`
+	Graduated []string `json:"graduated"` // final semester students
+}
+
+// PromoteStudents moves every enrolled student of the cohort to the next
+// semester in one transaction. Students of the final semester graduate
+// instead; detained, graduated and withdrawn students are left alone.
+func PromoteStudents(f CohortFilter, finalSemester int, effective time.Time, reason string, actorID string) (*PromotionResult, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if f.Exclude == nil {
+		f.Exclude = []string{}
+	}
+
+	cohort := []models.Student{}
+	err = tx.Select(&cohort, `
+		SELECT s.*
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE u.institution_id = $1
+		AND s.branch = $2
+		AND s.semester = $3
+		AND ($4 = '' OR s.section = $4)
+		AND s.academic_status = $5
+		AND s.deleted_at IS NULL
+		AND NOT s.id = ANY($6)
+		ORDER BY s.roll_number ASC
+		FOR UPDATE OF s
+	`, f.InstitutionID, f.Branch, f.Semester, f.Section, models.AcademicStatusEnrolled, f.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PromotionResult{BatchID: uuid.New().String(), Promoted: []string{}, Graduated: []string{}}
+	for _, s := range cohort {
+		next := s
+		if s.Semester >= finalSemester {
+			next.AcademicStatus = models.AcademicStatusGraduated
+			result.Graduated = append(result.Graduated, s.ID)
+		} else {
+			next.Semester = s.Semester + 1
+			result.Promoted = append(result.Promoted, s.ID)
+		}
+
+		h := newStudentHistory(&s, &next, models.HistoryChangePromotion, effective, reason, &actorID)
+		h.BatchID = &result.BatchID
+		if err := saveStudentChange(tx, &next, h); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// ChangeStudentStatus sets the academic status of a student and records it
+func ChangeStudentStatus(studentID string, status string, effective time.Time, reason string, actorID string) (*models.Student, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current models.Student
+	if err := tx.Get(&current, `SELECT * FROM students WHERE id = $1 FOR UPDATE`, studentID); err != nil {
+		return nil, err
+	}
+
+	next := current
+	next.AcademicStatus = status
+	h := newStudentHistory(&current, &next, models.HistoryChangeStatus, effective, reason, &actorID)
+	if err := saveStudentChange(tx, &next, h); err != nil {
+		return nil, err
+	}
+
+	return &next, tx.Commit()
+}
+
+// UpdateStudentAcademic corrects the branch, semester or section of a student
+// outside a promotion (e.g. a branch transfer) and records it
+func UpdateStudentAcademic(studentID string, branch string, semester int, section string, effective time.Time, reason string, actorID string) (*models.Student, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current models.Student
+	if err := tx.Get(&current, `SELECT * FROM students WHERE id = $1 FOR UPDATE`, studentID); err != nil {
+		return nil, err
+	}
+
+	next := current
+	next.Branch = branch
+	next.Semester = semester
+	next.Section = section
+	h := newStudentHistory(&current, &next, models.HistoryChangeAcademic, effective, reason, &actorID)
+	if err := saveStudentChange(tx, &next, h); err != nil {
+		return nil, err
+	}
+
+	return &next, tx.Commit()
+}
+
+// GetStudentHistory returns the academic changes of a student, newest first
+func GetStudentHistory(studentID string) ([]models.StudentHistory, error) {
+	history := []models.StudentHistory{}
+
+	query := `
+		SELECT * FROM student_academic_history
+		WHERE student_id = $1
+		ORDER BY effective_date DESC, created_at DESC
+	`
+
+	err := db.DB.Select(&history, query, studentID)
+	return history, err
+}
+
+func newStudentHistory(from *models.Student, to *models.Student, changeType string, effective time.Time, reason string, actorID *string) *models.StudentHistory {
+	return &models.StudentHistory{
+		ID:            uuid.New().String(),
+		StudentID:     from.ID,
+		ChangeType:    changeType,
+		FromSemester:  from.Semester,
+		ToSemester:    to.Semester,
+		FromBranch:    from.Branch,
+		ToBranch:      to.Branch,
+		FromSection:   from.Section,
+		ToSection:     to.Section,
+		FromStatus:    from.AcademicStatus,
+		ToStatus:      to.AcademicStatus,
+		EffectiveDate: effective,
+		Reason:        reason,
+		ActorID:       actorID,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// saveStudentChange writes the academic fields of s and the history row
+// describing the change. The effective date of a status change is kept on
+// the student too.
+func saveStudentChange(tx *sqlx.Tx, s *models.Student, h *models.StudentHistory) error {
+	if h.FromStatus != h.ToStatus {
+		effective := h.EffectiveDate
+		s.StatusEffectiveDate = &effective
+	}
+	s.UpdatedAt = time.Now()
+
+	_, err := tx.NamedExec(`
+		UPDATE students
+		SET
+			branch = :branch,
+			semester = :semester,
+			section = :section,
+			academic_status = :academic_status,
+			status_effective_date = :status_effective_date,
+			updated_at = :updated_at
+		WHERE id = :id
+	`, s)
+	if err != nil {
+		return err
+	}
+
+	return insertStudentHistory(tx, h)
+}
+
+func insertStudentHistory(tx *sqlx.Tx, h *models.StudentHistory) error {
+	_, err := tx.NamedExec(`
+		INSERT INTO student_academic_history (
+			id, student_id, change_type,
+			from_semester, to_semester, from_branch, to_branch,
+			from_section, to_section, from_status, to_status,
+			effective_date, reason, actor_id, batch_id, created_at
+		)
+		VALUES (
+			:id, :student_id, :change_type,
+			:from_semester, :to_semester, :from_branch, :to_branch,
+			:from_section, :to_section, :from_status, :to_status,
+			:effective_date, :reason, :actor_id, :batch_id, :created_at
+		)
+	`, h)
+	return err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+)
+
+// GetLoginFailure returns the failure counter for scope/key, nil if there is none
+func GetLoginFailure(scope string, key string) (*models.LoginFailure, error) {
+	var failure models.LoginFailure
+
+	query := `SELECT * FROM login_failures WHERE scope = $1 AND key = $2 LIMIT 1`
+
+	err := db.DB.Get(&failure, query, scope, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &failure, nil
+}
+
+// RecordLoginFailure increments the counter, starting over when the last
+// failure is older than window (a lockout still running is kept), and
+// returns the updated row.
+func RecordLoginFailure(scope string, key string, window time.Duration) (*models.LoginFailure, error) {
+	var failure models.LoginFailure
+
+	query := `
+		INSERT INTO login_failures (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_failures.last_failure_at < NOW() - $3 * INTERVAL '1 second' THEN 1
+				ELSE login_failures.failures + 1
+			END,
+			locked_until = CASE
+				WHEN login_failures.locked_until > NOW() THEN login_failures.locked_until
+				WHEN login_failures.last_failure_at < NOW() - $3 * INTERVAL '1 second' THEN NULL
+				ELSE login_failures.locked_until
+			END,
+			last_failure_at = NOW()
+		RETURNING *
+	`
+
+	err := db.DB.Get(&failure, query, scope, key, int(window.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &failure, nil
+}
+
+// LockLogin blocks further attempts for scope/key until the given time
+func LockLogin(scope string, key string, until time.Time) error {
+	query := `UPDATE login_failures SET locked_until = $3 WHERE scope = $1 AND key = $2`
+	_, err := db.DB.Exec(query, scope, key, until)
+	return err
+}
+
+// ClearLoginFailures forgets failures for scope/key after a good login or an admin unlock
+func ClearLoginFailures(scope string, key string) error {
+	query := `DELETE FROM login_failures WHERE scope = $1 AND key = $2`
+	_, err := db.DB.Exec(query, scope, key)
+	return err
+}
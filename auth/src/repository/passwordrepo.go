@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"auth/src/db"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// SetUserPassword replaces a user's password and records it in the history,
+// which keeps the newest keep entries
+func SetUserPassword(userID string, hash string, keep int) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`, userID, hash)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPasswordHistory(tx, userID, hash, keep); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddPasswordHistory records the password a user was created with
+func AddPasswordHistory(userID string, hash string, keep int) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertPasswordHistory(tx, userID, hash, keep); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertPasswordHistory(tx *sqlx.Tx, userID string, hash string, keep int) error {
+	_, err := tx.Exec(`
+		INSERT INTO password_history (id, user_id, password_hash, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, uuid.New().String(), userID, hash)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history
+			WHERE user_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		)
+	`, userID, keep)
+	return err
+}
+
+// RecentPasswordHashes returns the last n password hashes of a user, newest first
+func RecentPasswordHashes(userID string, n int) ([]string, error) {
+	hashes := []string{}
+
+	query := `
+		SELECT password_hash
+		FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	err := db.DB.Select(&hashes, query, userID, n)
+	return hashes, err
+}
+
+// UpdatePasswordHash swaps a hash for a stronger hash of the same password.
+// It does nothing if the password changed since oldHash was read, and the
+// history keeps the old hash, which still matches the same password.
+func UpdatePasswordHash(userID string, oldHash string, newHash string) error {
+	query := `UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2`
+	_, err := db.DB.Exec(query, userID, oldHash, newHash)
+	return err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+)
+
+// GetUserDataExport collects every row auth holds about user. The password
+// hash is left out.
+func GetUserDataExport(user *models.User) (*models.UserDataExport, error) {
+	u := *user
+	u.PasswordHash = ""
+	export := models.UserDataExport{
+		User:           &u,
+		StudentHistory: []models.StudentHistory{},
+		RoleRequests:   []models.RoleChangeRequest{},
+		Sessions:       []models.UserSession{},
+		RefreshTokens:  []models.RefreshToken{},
+		Identities:     []models.UserIdentity{},
+		AuthEvents:     []models.AuthEvent{},
+	}
+
+	targets := []string{user.ID}
+	student, err := GetStudentByUserID(user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if student != nil {
+		export.Student = student
+		targets = append(targets, student.ID)
+		if export.StudentHistory, err = GetStudentHistory(student.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if export.RoleAudit, err = GetRoleAuditByUser(user.ID); err != nil {
+		return nil, err
+	}
+
+	mfa, err := GetUserMFA(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.MFAEnabled = mfa.Enabled()
+
+	queries := []struct {
+		dest  interface{}
+		query string
+		args  []interface{}
+	}{
+		{&export.RoleRequests, `SELECT * FROM role_change_requests WHERE user_id = $1 ORDER BY created_at`, []interface{}{user.ID}},
+		{&export.Sessions, `SELECT * FROM user_sessions WHERE user_id = $1 ORDER BY created_at`, []interface{}{user.ID}},
+		{&export.RefreshTokens, `SELECT * FROM refresh_tokens WHERE user_id = $1 ORDER BY issued_at`, []interface{}{user.ID}},
+		{&export.Identities, `SELECT * FROM user_identities WHERE user_id = $1 ORDER BY created_at`, []interface{}{user.ID}},
+		{&export.AuthEvents, `
+			SELECT * FROM auth_events
+			WHERE institution_id = $1 AND (actor_id = $2 OR target_id = ANY($3))
+			ORDER BY created_at
+		`, []interface{}{user.InstitutionID, user.ID, targets}},
+	}
+	for _, q := range queries {
+		if err := db.DB.Select(q.dest, q.query, q.args...); err != nil {
+			return nil, err
+		}
+	}
+
+	return &export, nil
+}
+
+// GetDataErasure returns the erasure of a user, nil if they were never erased
+func GetDataErasure(userID string) (*models.DataErasure, error) {
+	var e models.DataErasure
+
+	err := db.DB.Get(&e, `SELECT * FROM data_erasures WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// EraseUserData records erasure e and pseudonymises everything auth holds
+// about the user in one transaction. The user and student rows are kept,
+// deleted and stripped of personal fields, so references from exams and
+// the audit log still resolve; sessions, MFA, one-time tokens and linked
+// identities are removed.
+func EraseUserData(user *models.User, e *models.DataErasure) error {
+	e.ID = uuid.New().String()
+	e.CreatedAt = time.Now()
+	email := e.Pseudonym + "@erased.invalid"
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExec(`
+		INSERT INTO data_erasures (
+			id, user_id, institution_id, pseudonym, requested_by,
+			reason, status, pending_services, roll_number, created_at
+		)
+		VALUES (
+			:id, :user_id, :institution_id, :pseudonym, :requested_by,
+			:reason, :status, :pending_services, :roll_number, :created_at
+		)
+	`, e)
+	if err != nil {
+		return err
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`
+			UPDATE users
+			SET name = 'Erased user', email = $2, password_hash = '', status = $3,
+				email_verified_at = NULL, password_reset_required = FALSE,
+				deleted_at = COALESCE(deleted_at, NOW()), updated_at = NOW()
+			WHERE id = $1
+		`, []interface{}{user.ID, email, models.StatusDeleted}},
+		{`
+			UPDATE students
+			SET first_name = 'Erased', last_name = 'Student', email = $2, phone = '',
+				roll_number = $3, enrollment_no = $3, active = FALSE,
+				deleted_at = COALESCE(deleted_at, NOW()), updated_at = NOW()
+			WHERE user_id = $1
+		`, []interface{}{user.ID, email, e.Pseudonym}},
+		{`UPDATE role_change_requests SET reason = '' WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM refresh_tokens WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM user_sessions WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM user_tokens WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM password_history WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM user_mfa WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM user_identities WHERE user_id = $1`, []interface{}{user.ID}},
+		// The audit trigger lets this transaction blank personal fields only
+		{`SET LOCAL neuroiq.erasure = 'on'`, nil},
+		{`
+			UPDATE auth_events
+			SET actor_email = $3, ip_address = '', user_agent = ''
+			WHERE actor_id = $1 OR LOWER(actor_email) = LOWER($2)
+		`, []interface{}{user.ID, user.Email, email}},
+	}
+	for _, s := range statements {
+		if _, err := tx.Exec(s.query, s.args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateDataErasureProgress saves which services are still pending; the
+// erasure completes once none are, and the roll number is forgotten
+func UpdateDataErasureProgress(e *models.DataErasure) error {
+	e.Status = models.ErasureStatusPartial
+	e.CompletedAt = nil
+	if e.PendingServices == "" {
+		now := time.Now()
+		e.Status = models.ErasureStatusCompleted
+		e.CompletedAt = &now
+		e.RollNumber = ""
+	}
+
+	query := `
+		UPDATE data_erasures
+		SET status = $2, pending_services = $3, completed_at = $4, roll_number = $5
+		WHERE id = $1
+	`
+	_, err := db.DB.Exec(query, e.ID, e.Status, e.PendingServices, e.CompletedAt, e.RollNumber)
+	return err
+}
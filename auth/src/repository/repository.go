@@ -9,25 +9,32 @@ import (
 	"github.com/google/uuid"
 )
 
-func CreateUser(u *models.User) error {
+const insertUserQuery = `
+	INSERT INTO users (
+		id, name, email, password_hash,
+		role, institution, institution_id, status,
+		created_at, updated_at
+	)
+	VALUES (
+		:id, :name, :email, :password_hash,
+		:role, :institution, :institution_id, :status,
+		:created_at, :updated_at
+	)
+`
+
+func prepareNewUser(u *models.User) {
 	u.ID = uuid.New().String()
 	u.CreatedAt = time.Now()
 	u.UpdatedAt = time.Now()
+	if u.Status == "" {
+		u.Status = models.StatusActive
+	}
+}
 
-	query := `
-		INSERT INTO users (
-			id, name, email, password_hash,
-			role, institution,
-			created_at, updated_at
-		)
-		VALUES (
-			:id, :name, :email, :password_hash,
-			:role, :institution,
-			:created_at, :updated_at
-		)
-	`
+func CreateUser(u *models.User) error {
+	prepareNewUser(u)
 
-	_, err := db.DB.NamedExec(query, u)
+	_, err := db.DB.NamedExec(insertUserQuery, u)
 	return err
 }
 
@@ -55,6 +62,8 @@ func GetUserByID(id string) (*models.User, error) {
 	return &user, nil
 }
 
+// UpdateUser saves profile fields; role changes go through ChangeUserRole
+// and the institution never changes
 func UpdateUser(u *models.User) error {
 	query := `
 		UPDATE users
@@ -62,8 +71,6 @@ func UpdateUser(u *models.User) error {
 			name = :name,
 			email = :email,
 			password_hash = :password_hash,
-			role = :role,
-			institution = :institution,
 			updated_at = :updated_at
 		WHERE id = :id
 	`
@@ -71,48 +78,66 @@ func UpdateUser(u *models.User) error {
 	return err
 }
 
+// SetUserStatus changes whether a user may log in (active | inactive | blocked)
+func SetUserStatus(userID string, status string) error {
+	query := `UPDATE users SET status = $2, updated_at = NOW() WHERE id = $1`
+	_, err := db.DB.Exec(query, userID, status)
+	return err
+}
+
+// MarkEmailVerified records that the user proved ownership of their email
+func MarkEmailVerified(userID string) error {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := db.DB.Exec(query, userID)
+	return err
+}
+
+const insertStudentQuery = `
+	INSERT INTO students (
+		id,
+		first_name,
+		last_name,
+		roll_number,
+		enrollment_no,
+		branch,
+		semester,
+		section,
+		email,
+		phone,
+		user_id,
+		active,
+		created_at,
+		updated_at
+	)
+	VALUES (
+		:id,
+		:first_name,
+		:last_name,
+		:roll_number,
+		:enrollment_no,
+		:branch,
+		:semester,
+		:section,
+		:email,
+		:phone,
+		:user_id,
+		:active,
+		:created_at,
+		:updated_at
+	)
+`
+
 // CreateStudent inserts a new student into the database
 func CreateStudent(s *models.Student) error {
 	s.ID = uuid.New().String()
 	s.CreatedAt = time.Now()
 	s.UpdatedAt = time.Now()
 
-	query := `
-		INSERT INTO students (
-			id,
-			first_name,
-			last_name,
-			roll_number,
-			enrollment_no,
-			branch,
-			semester,
-			section,
-			email,
-			phone,
-			user_id,
-			active,
-			created_at,
-			updated_at
-		)
-		VALUES (
-			:id,
-			:first_name,
-			:last_name,
-			:roll_number,
-			:enrollment_no,
-			:branch,
-			:semester,
-			:section,
-			:email,
-			:phone,
-			:user_id,
-			:active,
-			:created_at,
-			:updated_at
-		)
-	`
-
-	_, err := db.DB.NamedExec(query, s)
+	_, err := db.DB.NamedExec(insertStudentQuery, s)
 	return err
 }
 
@@ -144,11 +169,12 @@ func GetStudentByID(id string) (*models.Student, error) {
 	return &student, nil
 }
 
-// GetStudentByUserID fetches a student using the user_id (FK to users table)
+// GetStudentByUserID fetches a student using the user_id (FK to users table),
+// preferring the live record over soft-deleted ones
 func GetStudentByUserID(userID string) (*models.Student, error) {
 	var student models.Student
 
-	query := `SELECT * FROM students WHERE user_id = $1 LIMIT 1`
+	query := `SELECT * FROM students WHERE user_id = $1 ORDER BY deleted_at NULLS FIRST LIMIT 1`
 
 	err := db.DB.Get(&student, query, userID)
 	if err != nil {
@@ -158,45 +184,15 @@ func GetStudentByUserID(userID string) (*models.Student, error) {
 	return &student, nil
 }
 
-func GetStudentsByPrefixBranchSemester(prefix string, branch string, semester int) ([]models.Student, error) {
-	var students []models.Student
-
-	query := `
-		SELECT *
-		FROM students
-		WHERE roll_number LIKE $1 || '%'
-		AND branch = $2
-		AND semester = $3
-		ORDER BY roll_number ASC
-	`
-
-	err := db.DB.Select(&students, query, prefix, branch, semester)
-	if err != nil {
-		return nil, err
-	}
-
-	return students, nil
-}
-
-func UpdateStudent(id string, s *models.Student) error {
+// UpdateStudentContact saves the fields a student may edit themselves; roll
+// number, branch, semester and section are managed by admins
+func UpdateStudentContact(userID string, firstName string, lastName string, phone string) error {
 	query := `
 		UPDATE students
-		SET
-			first_name = :first_name,
-			last_name = :last_name,
-			roll_number = :roll_number,
-			enrollment_no = :enrollment_no,
-			branch = :branch,
-			semester = :semester,
-			section = :section,
-			email = :email,
-			phone = :phone,
-			active = :active,
-			updated_at = :updated_at
-		WHERE user_id = :user_id
-	
+		SET first_name = $2, last_name = $3, phone = $4, updated_at = NOW()
+		WHERE user_id = $1
 	`
 
-	_, err := db.DB.NamedExec(query, s)
+	_, err := db.DB.Exec(query, userID, firstName, lastName, phone)
 	return err
 }
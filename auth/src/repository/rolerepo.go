@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrRequestAlreadyDecided is returned when approving/rejecting a request that is no longer pending
+var ErrRequestAlreadyDecided = errors.New("role change request already decided")
+
+// CreateInviteCode stores a hashed invite code
+func CreateInviteCode(c *models.InviteCode) error {
+	c.ID = uuid.New().String()
+	c.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO invite_codes (id, code_hash, role, institution_id, max_uses, uses, created_by, created_at, expires_at)
+		VALUES (:id, :code_hash, :role, :institution_id, :max_uses, 0, :created_by, :created_at, :expires_at)
+	`
+
+	_, err := db.DB.NamedExec(query, c)
+	return err
+}
+
+// CreateUserWithInvite redeems an invite for u.Role and creates the user in one
+// transaction, in the invite's institution. sql.ErrNoRows means the code is
+// unknown, expired, used up or for another role.
+func CreateUserWithInvite(u *models.User, inviteHash string) error {
+	prepareNewUser(u)
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var invite struct {
+		ID              string `db:"id"`
+		InstitutionID   string `db:"institution_id"`
+		InstitutionName string `db:"name"`
+	}
+	err = tx.Get(&invite, `
+		UPDATE invite_codes c
+		SET uses = uses + 1
+		FROM institutions i
+		WHERE c.code_hash = $1 AND c.role = $2 AND c.uses < c.max_uses AND c.expires_at > NOW()
+		AND i.id = c.institution_id AND i.active = TRUE
+		RETURNING c.id, c.institution_id, i.name
+	`, inviteHash, u.Role)
+	if err != nil {
+		return err
+	}
+	inviteID := invite.ID
+	u.InstitutionID = invite.InstitutionID
+	u.Institution = invite.InstitutionName
+
+	if _, err := tx.NamedExec(insertUserQuery, u); err != nil {
+		return err
+	}
+
+	reason := "signup with invite " + inviteID
+	if err := insertRoleAudit(tx, u.ID, models.RoleStudent, u.Role, nil, nil, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateRoleChangeRequest stores a new pending request
+func CreateRoleChangeRequest(req *models.RoleChangeRequest) error {
+	req.ID = uuid.New().String()
+	req.Status = models.RoleRequestPending
+	req.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO role_change_requests (
+			id, user_id, from_role, requested_role, reason, status, created_at
+		)
+		VALUES (
+			:id, :user_id, :from_role, :requested_role, :reason, :status, :created_at
+		)
+	`
+
+	_, err := db.DB.NamedExec(query, req)
+	return err
+}
+
+// GetPendingRoleChangeRequestByUser returns the user's open request, nil if none
+func GetPendingRoleChangeRequestByUser(userID string) (*models.RoleChangeRequest, error) {
+	var req models.RoleChangeRequest
+
+	query := `SELECT * FROM role_change_requests WHERE user_id = $1 AND status = 'pending' LIMIT 1`
+
+	err := db.DB.Get(&req, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+func GetRoleChangeRequestByID(id string) (*models.RoleChangeRequest, error) {
+	var req models.RoleChangeRequest
+
+	err := db.DB.Get(&req, `SELECT * FROM role_change_requests WHERE id = $1 LIMIT 1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// ListRoleChangeRequests lists the requests of one institution's users,
+// optionally filtered by status, oldest first
+func ListRoleChangeRequests(institutionID string, status string) ([]models.RoleChangeRequest, error) {
+	requests := []models.RoleChangeRequest{}
+
+	query := `
+		SELECT r.*
+		FROM role_change_requests r
+		JOIN users u ON u.id = r.user_id
+		WHERE u.institution_id = $1
+		AND ($2 = '' OR r.status = $2)
+		ORDER BY r.created_at ASC
+	`
+
+	err := db.DB.Select(&requests, query, institutionID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// DecideRoleChangeRequest approves or rejects a pending request. Approval
+// applies the role and writes the audit record in the same transaction.
+func DecideRoleChangeRequest(req *models.RoleChangeRequest, approve bool, adminID string, note string) error {
+	status := models.RoleRequestRejected
+	if approve {
+		status = models.RoleRequestApproved
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE role_change_requests
+		SET status = $2, decided_by = $3, decision_note = $4, decided_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, req.ID, status, adminID, note)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrRequestAlreadyDecided
+	}
+
+	if approve {
+		if err := applyRoleChange(tx, req.UserID, req.RequestedRole, &adminID, &req.ID, req.Reason); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ChangeUserRole sets a user's role directly (admin action) and audits it
+func ChangeUserRole(userID string, newRole string, actorID string, reason string) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := applyRoleChange(tx, userID, newRole, &actorID, nil, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRoleAuditByUser returns the role history of a user, newest first
+func GetRoleAuditByUser(userID string) ([]models.RoleAudit, error) {
+	audit := []models.RoleAudit{}
+
+	query := `SELECT * FROM role_audit WHERE user_id = $1 ORDER BY created_at DESC`
+
+	err := db.DB.Select(&audit, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
+
+func applyRoleChange(tx *sqlx.Tx, userID string, newRole string, actorID *string, requestID *string, reason string) error {
+	var oldRole string
+	err := tx.Get(&oldRole, `SELECT role FROM users WHERE id = $1 FOR UPDATE`, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`, userID, newRole)
+	if err != nil {
+		return err
+	}
+
+	return insertRoleAudit(tx, userID, oldRole, newRole, actorID, requestID, reason)
+}
+
+func insertRoleAudit(tx *sqlx.Tx, userID string, oldRole string, newRole string, actorID *string, requestID *string, reason string) error {
+	_, err := tx.Exec(`
+		INSERT INTO role_audit (id, user_id, old_role, new_role, actor_id, request_id, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+	`, uuid.New().String(), userID, oldRole, newRole, actorID, requestID, reason)
+	return err
+}
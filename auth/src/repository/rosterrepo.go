@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// RosterEntry is one validated roster row. Existing is the student with the
+// same roll number, if any. When the student has no login yet, User is the
+// account to create and InviteHash the hashed invite token for it.
+type RosterEntry struct {
+	Student    models.Student
+	Existing   *RosterStudent
+	User       *models.User
+	InviteHash string
+}
+
+// RosterStudent is an existing student together with the institution of
+// their login (empty when they have none)
+type RosterStudent struct {
+	models.Student
+	InstitutionID string `db:"institution_id"`
+}
+
+// GetStudentsByIdentifiers returns every student sharing a roll number, enrollment number or email with the given lists
+func GetStudentsByIdentifiers(rolls []string, enrollments []string, emails []string) ([]RosterStudent, error) {
+	students := []RosterStudent{}
+
+	query := `
+		SELECT s.*, COALESCE(u.institution_id::text, '') AS institution_id
+		FROM students s
+		LEFT JOIN users u ON u.id = s.user_id
+		WHERE s.roll_number = ANY($1)
+		OR s.enrollment_no = ANY($2)
+		OR LOWER(s.email) = ANY($3)
+	`
+
+	err := db.DB.Select(&students, query, rolls, enrollments, emails)
+	return students, err
+}
+
+// GetUsersByEmails returns the users owning any of the given (lowercased) emails
+func GetUsersByEmails(emails []string) ([]models.User, error) {
+	users := []models.User{}
+
+	query := `SELECT * FROM users WHERE LOWER(email) = ANY($1)`
+
+	err := db.DB.Select(&users, query, emails)
+	return users, err
+}
+
+// ImportRoster writes every entry in one transaction: new students (with their
+// login and invite token) are inserted, existing ones are updated by roll number.
+// Semester, branch and section changes are recorded in the academic history.
+func ImportRoster(entries []RosterEntry, inviteTTL time.Duration, actorID string) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range entries {
+		e := &entries[i]
+
+		if e.User != nil {
+			prepareNewUser(e.User)
+			if _, err := tx.NamedExec(insertUserQuery, e.User); err != nil {
+				return err
+			}
+
+			token := models.UserToken{
+				ID:        uuid.New().String(),
+				UserID:    e.User.ID,
+				Purpose:   models.TokenPurposeAccountInvite,
+				TokenHash: e.InviteHash,
+				CreatedAt: time.Now(),
+				ExpiresAt: time.Now().Add(inviteTTL),
+			}
+			if _, err := tx.NamedExec(insertUserTokenQuery, &token); err != nil {
+				return err
+			}
+			e.Student.UserID = e.User.ID
+		}
+
+		if e.Existing == nil {
+			if err := insertRosterStudent(tx, &e.Student); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := updateRosterStudent(tx, e, actorID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertRosterStudent(tx *sqlx.Tx, s *models.Student) error {
+	s.ID = uuid.New().String()
+	s.Active = true
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+
+	_, err := tx.NamedExec(insertStudentQuery, s)
+	return err
+}
+
+// updateRosterStudent refreshes a re-imported student (e.g. new semester) and
+// keeps the name and email of their login in step
+func updateRosterStudent(tx *sqlx.Tx, e *RosterEntry, actorID string) error {
+	s := &e.Student
+	s.ID = e.Existing.ID
+	if s.UserID == "" {
+		s.UserID = e.Existing.UserID
+	}
+	s.Active = e.Existing.Active // a deactivated student stays so until an admin says otherwise
+	s.AcademicStatus = e.Existing.AcademicStatus
+	s.UpdatedAt = time.Now()
+
+	old := &e.Existing.Student
+	if old.Semester != s.Semester || old.Branch != s.Branch || old.Section != s.Section {
+		h := newStudentHistory(old, s, models.HistoryChangeImport, time.Now(), "roster import", &actorID)
+		if err := insertStudentHistory(tx, h); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.NamedExec(`
+		UPDATE students
+		SET
+			first_name = :first_name,
+			last_name = :last_name,
+			enrollment_no = :enrollment_no,
+			branch = :branch,
+			semester = :semester,
+			section = :section,
+			email = :email,
+			phone = :phone,
+			user_id = :user_id,
+			active = :active,
+			updated_at = :updated_at
+		WHERE id = :id
+	`, s)
+	if err != nil {
+		return err
+	}
+
+	if e.User != nil || s.UserID == "" {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET name = $2, email = $3, updated_at = NOW()
+		WHERE id = $1
+	`, s.UserID, s.FirstName+" "+s.LastName, s.Email)
+	return err
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateServiceClient stores a new service client with its hashed secret
+func CreateServiceClient(c *models.ServiceClient) error {
+	c.ID = uuid.New().String()
+	c.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO service_clients (id, client_id, secret_hash, name, scopes, created_by, created_at)
+		VALUES (:id, :client_id, :secret_hash, :name, :scopes, :created_by, :created_at)
+	`
+
+	_, err := db.DB.NamedExec(query, c)
+	return err
+}
+
+// GetActiveServiceClient fetches a client that has not been revoked
+func GetActiveServiceClient(clientID string) (*models.ServiceClient, error) {
+	var c models.ServiceClient
+
+	query := `SELECT * FROM service_clients WHERE client_id = $1 AND revoked_at IS NULL`
+
+	if err := db.DB.Get(&c, query, clientID); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListServiceClients returns every client, newest first
+func ListServiceClients() ([]models.ServiceClient, error) {
+	clients := []models.ServiceClient{}
+
+	query := `SELECT * FROM service_clients ORDER BY created_at DESC`
+
+	err := db.DB.Select(&clients, query)
+	return clients, err
+}
+
+// RevokeServiceClient stops a client from obtaining new tokens; false means
+// it was unknown or already revoked
+func RevokeServiceClient(id string) (bool, error) {
+	query := `UPDATE service_clients SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+
+	res, err := db.DB.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// TouchServiceClient records that a client obtained a token
+func TouchServiceClient(id string) error {
+	_, err := db.DB.Exec(`UPDATE service_clients SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetOIDCProviderByInstitution returns the institution's provider, nil if it has none
+func GetOIDCProviderByInstitution(institutionID string) (*models.OIDCProvider, error) {
+	var p models.OIDCProvider
+
+	err := db.DB.Get(&p, `SELECT * FROM oidc_providers WHERE institution_id = $1`, institutionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetOIDCProviderByID fetches a provider by its ID
+func GetOIDCProviderByID(id string) (*models.OIDCProvider, error) {
+	var p models.OIDCProvider
+
+	if err := db.DB.Get(&p, `SELECT * FROM oidc_providers WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SaveOIDCProvider creates or replaces the provider of p.InstitutionID
+func SaveOIDCProvider(p *models.OIDCProvider) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+		p.CreatedAt = time.Now()
+	}
+	p.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO oidc_providers (
+			id, institution_id, issuer, client_id, client_secret, scopes,
+			allowed_domains, jit_provisioning, enabled, created_at, updated_at
+		)
+		VALUES (
+			:id, :institution_id, :issuer, :client_id, :client_secret, :scopes,
+			:allowed_domains, :jit_provisioning, :enabled, :created_at, :updated_at
+		)
+		ON CONFLICT (institution_id) DO UPDATE SET
+			issuer = EXCLUDED.issuer,
+			client_id = EXCLUDED.client_id,
+			client_secret = EXCLUDED.client_secret,
+			scopes = EXCLUDED.scopes,
+			allowed_domains = EXCLUDED.allowed_domains,
+			jit_provisioning = EXCLUDED.jit_provisioning,
+			enabled = EXCLUDED.enabled,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := db.DB.NamedExec(query, p)
+	return err
+}
+
+// DeleteOIDCProvider removes an institution's provider together with its linked identities
+func DeleteOIDCProvider(institutionID string) (bool, error) {
+	res, err := db.DB.Exec(`DELETE FROM oidc_providers WHERE institution_id = $1`, institutionID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// CreateOIDCLoginRequest stores a sign-in in flight, clearing expired ones
+func CreateOIDCLoginRequest(req *models.OIDCLoginRequest) error {
+	req.CreatedAt = time.Now()
+
+	if _, err := db.DB.Exec(`DELETE FROM oidc_login_requests WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO oidc_login_requests (state_hash, provider_id, nonce, code_verifier, return_to, created_at, expires_at)
+		VALUES (:state_hash, :provider_id, :nonce, :code_verifier, :return_to, :created_at, :expires_at)
+	`
+
+	_, err := db.DB.NamedExec(query, req)
+	return err
+}
+
+// ConsumeOIDCLoginRequest deletes and returns an unexpired sign-in by state.
+// sql.ErrNoRows means the state is unknown, expired or already used.
+func ConsumeOIDCLoginRequest(stateHash string) (*models.OIDCLoginRequest, error) {
+	var req models.OIDCLoginRequest
+
+	query := `
+		DELETE FROM oidc_login_requests
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING *
+	`
+
+	if err := db.DB.Get(&req, query, stateHash); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetUserIdentity returns the identity of subject at a provider, nil if unlinked
+func GetUserIdentity(providerID string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+
+	err := db.DB.Get(&identity, `SELECT * FROM user_identities WHERE provider_id = $1 AND subject = $2`, providerID, subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// UserHasIdentity reports whether a user is already linked to some account of the provider
+func UserHasIdentity(providerID string, userID string) (bool, error) {
+	var exists bool
+	err := db.DB.Get(&exists, `SELECT EXISTS (SELECT 1 FROM user_identities WHERE provider_id = $1 AND user_id = $2)`, providerID, userID)
+	return exists, err
+}
+
+// LinkUserIdentity links a provider account to an existing user and marks
+// their email verified, since the provider vouched for it
+func LinkUserIdentity(identity *models.UserIdentity) error {
+	identity.ID = uuid.New().String()
+	identity.CreatedAt = time.Now()
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertUserIdentity(tx, identity); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1`, identity.UserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateUserWithIdentity provisions a user on first sign-in together with
+// the identity that created it. The email counts as verified.
+func CreateUserWithIdentity(u *models.User, identity *models.UserIdentity) error {
+	prepareNewUser(u)
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+
+	identity.ID = uuid.New().String()
+	identity.UserID = u.ID
+	identity.CreatedAt = now
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExec(insertUserQuery, u); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE users SET email_verified_at = $2 WHERE id = $1`, u.ID, now); err != nil {
+		return err
+	}
+	if err := insertUserIdentity(tx, identity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TouchUserIdentity records a sign-in through an identity
+func TouchUserIdentity(id string) error {
+	_, err := db.DB.Exec(`UPDATE user_identities SET last_login_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func insertUserIdentity(tx *sqlx.Tx, identity *models.UserIdentity) error {
+	_, err := tx.NamedExec(`
+		INSERT INTO user_identities (id, user_id, provider_id, subject, email, created_at)
+		VALUES (:id, :user_id, :provider_id, :subject, :email, :created_at)
+	`, identity)
+	return err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"auth/src/db"
+	"auth/src/models"
+)
+
+// StudentQuery is a student search within one institution. Empty fields are
+// ignored; lists match any of their values. Results are ordered by roll
+// number and paged with a keyset cursor (AfterRoll, AfterID).
+type StudentQuery struct {
+	InstitutionID  string
+	Branches       []string
+	Sections       []string
+	SemesterFrom   int
+	SemesterTo     int
+	RollPrefix     string
+	RollFrom       string // inclusive
+	RollTo         string // inclusive
+	AcademicStatus []string
+	Active         *bool
+	Name           string // matched against first and last name
+	AfterRoll      string
+	AfterID        string
+	Limit          int
+}
+
+// SearchStudents returns up to q.Limit non-deleted students matching q
+func SearchStudents(q StudentQuery) ([]models.Student, error) {
+	var b whereBuilder
+	b.add("user_id IN (SELECT id FROM users WHERE institution_id = ?)", q.InstitutionID)
+	b.conds = append(b.conds, "deleted_at IS NULL")
+	if len(q.Branches) > 0 {
+		b.add("branch = ANY(?)", q.Branches)
+	}
+	if len(q.Sections) > 0 {
+		b.add("section = ANY(?)", q.Sections)
+	}
+	if q.SemesterFrom != 0 {
+		b.add("semester >= ?", q.SemesterFrom)
+	}
+	if q.SemesterTo != 0 {
+		b.add("semester <= ?", q.SemesterTo)
+	}
+	if q.RollPrefix != "" {
+		b.add(`roll_number LIKE (? || '%') ESCAPE '\'`, escapeLike(q.RollPrefix))
+	}
+	if q.RollFrom != "" {
+		b.add("roll_number >= ?", q.RollFrom)
+	}
+	if q.RollTo != "" {
+		b.add("roll_number <= ?", q.RollTo)
+	}
+	if len(q.AcademicStatus) > 0 {
+		b.add("academic_status = ANY(?)", q.AcademicStatus)
+	}
+	if q.Active != nil {
+		b.add("active = ?", *q.Active)
+	}
+	if q.Name != "" {
+		b.add(`(first_name || ' ' || last_name) ILIKE ? ESCAPE '\'`, "%"+escapeLike(q.Name)+"%")
+	}
+	if q.AfterRoll != "" {
+		b.args = append(b.args, q.AfterRoll, q.AfterID)
+		b.conds = append(b.conds, fmt.Sprintf("(roll_number, id::text) > ($%d, $%d)", len(b.args)-1, len(b.args)))
+	}
+
+	students := []models.Student{}
+	query := fmt.Sprintf(
+		"SELECT * FROM students%s ORDER BY roll_number ASC, id::text ASC LIMIT %d",
+		b.sql(), q.Limit,
+	)
+	err := db.DB.Select(&students, query, b.args...)
+	return students, err
+}
+
+// GetStudentsByIDs returns the non-deleted students of one institution among ids
+func GetStudentsByIDs(institutionID string, ids []string) ([]models.Student, error) {
+	students := []models.Student{}
+
+	query := `
+		SELECT s.*
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id::text = ANY($1)
+		AND u.institution_id = $2
+		AND s.deleted_at IS NULL
+		ORDER BY s.roll_number ASC
+	`
+
+	err := db.DB.Select(&students, query, ids, institutionID)
+	return students, err
+}
+
+// likeEscaper makes user input match literally in a LIKE pattern with
+// ESCAPE '\', so a roll prefix such as "CS_1" does not match "CSX1"
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
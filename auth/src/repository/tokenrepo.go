@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"time"
+
+	"auth/src/db"
+	"auth/src/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateSession stores a new login and its first refresh token, whose
+// family is the session. s.ID must already be set: it is the sid claim of
+// the access token issued alongside.
+func CreateSession(s *models.UserSession, t *models.RefreshToken) error {
+	t.ID = uuid.New().String()
+	t.FamilyID = s.ID
+	t.IssuedAt = time.Now()
+	s.CreatedAt = t.IssuedAt
+	s.LastSeenAt = t.IssuedAt
+	s.ExpiresAt = t.ExpiresAt
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExec(`
+		INSERT INTO user_sessions (
+			id, user_id, device_label, user_agent, ip_address,
+			created_at, last_seen_at, expires_at
+		)
+		VALUES (
+			:id, :user_id, :device_label, :user_agent, :ip_address,
+			:created_at, :last_seen_at, :expires_at
+		)
+	`, s)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(insertRefreshTokenQuery, t)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRefreshTokenByHash fetches a refresh token record by its sha256 hash
+func GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	query := `SELECT * FROM refresh_tokens WHERE token_hash = $1 LIMIT 1`
+
+	err := db.DB.Get(&token, query, hash)
+	if err != nil {
+		return nil, err // sql: no rows → caller handles this
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken revokes old and stores next in the same family.
+// It returns false without inserting when old was already revoked, which
+// means the same token was presented twice.
+func RotateRefreshToken(old *models.RefreshToken, next *models.RefreshToken) (bool, error) {
+	next.ID = uuid.New().String()
+	next.FamilyID = old.FamilyID
+	next.IssuedAt = time.Now()
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(), replaced_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`, old.ID, next.ID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.NamedExec(insertRefreshTokenQuery, next)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_sessions
+		SET last_seen_at = $2, ip_address = $3, user_agent = $4, expires_at = $5
+		WHERE id = $1
+	`, next.FamilyID, next.IssuedAt, next.IPAddress, next.UserAgent, next.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// RevokeRefreshTokenFamily ends one login: its session and every live token
+// descended from it
+func RevokeRefreshTokenFamily(familyID string) error {
+	return revokeSessions("family_id", "id", familyID, "")
+}
+
+// RevokeUserRefreshTokens ends every login of a user
+func RevokeUserRefreshTokens(userID string) error {
+	return revokeSessions("user_id", "user_id", userID, "")
+}
+
+// RevokeOtherSessions ends every login of a user except keepSessionID, the
+// one that just changed the password
+func RevokeOtherSessions(userID string, keepSessionID string) error {
+	return revokeSessions("user_id", "user_id", userID, keepSessionID)
+}
+
+// revokeSessions revokes the refresh tokens whose tokenColumn and the
+// sessions whose sessionColumn equal value, sparing the session keepID if set
+func revokeSessions(tokenColumn string, sessionColumn string, value string, keepID string) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE `+tokenColumn+` = $1 AND revoked_at IS NULL
+		AND family_id::text <> $2
+	`, value, keepID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_sessions
+		SET revoked_at = NOW()
+		WHERE `+sessionColumn+` = $1 AND revoked_at IS NULL
+		AND id::text <> $2
+	`, value, keepID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListUserSessions returns the live sessions of a user, most recently used first
+func ListUserSessions(userID string) ([]models.UserSession, error) {
+	sessions := []models.UserSession{}
+
+	query := `
+		SELECT * FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`
+
+	err := db.DB.Select(&sessions, query, userID)
+	return sessions, err
+}
+
+// GetUserSession fetches one session of a user, live or not.
+// sql.ErrNoRows means the user has no such session.
+func GetUserSession(userID string, sessionID string) (*models.UserSession, error) {
+	var session models.UserSession
+
+	query := `SELECT * FROM user_sessions WHERE id = $1 AND user_id = $2`
+
+	err := db.DB.Get(&session, query, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// CountLiveSessions counts the sessions of a user that can still be refreshed
+func CountLiveSessions(userID string) (int, error) {
+	var count int
+
+	query := `
+		SELECT COUNT(*) FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	`
+
+	err := db.DB.Get(&count, query, userID)
+	return count, err
+}
+
+// SessionLive reports whether a session exists and has not been revoked
+func SessionLive(sessionID string) (bool, error) {
+	var revokedAt *time.Time
+
+	err := db.DB.Get(&revokedAt, `SELECT revoked_at FROM user_sessions WHERE id = $1`, sessionID)
+	if err != nil {
+		return false, err // sql: no rows → unknown session
+	}
+	return revokedAt == nil, nil
+}
+
+// TouchSession records use of a session. The row is only written when
+// last_seen_at is over a minute old, so replicas touching it together
+// cost one write.
+func TouchSession(sessionID string) error {
+	_, err := db.DB.Exec(`
+		UPDATE user_sessions
+		SET last_seen_at = NOW()
+		WHERE id = $1 AND last_seen_at < NOW() - INTERVAL '1 minute'
+	`, sessionID)
+	return err
+}
+
+const insertRefreshTokenQuery = `
+	INSERT INTO refresh_tokens (
+		id, user_id, family_id, token_hash,
+		user_agent, ip_address,
+		issued_at, expires_at
+	)
+	VALUES (
+		:id, :user_id, :family_id, :token_hash,
+		:user_agent, :ip_address,
+		:issued_at, :expires_at
+	)
+`
+
+const insertUserTokenQuery = `
+	INSERT INTO user_tokens (id, user_id, purpose, token_hash, created_at, expires_at)
+	VALUES (:id, :user_id, :purpose, :token_hash, :created_at, :expires_at)
+`
+
+// CreateUserToken stores a mailed token, invalidating earlier unused ones of the same purpose
+func CreateUserToken(t *models.UserToken) error {
+	t.ID = uuid.New().String()
+	t.CreatedAt = time.Now()
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`, t.UserID, t.Purpose)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(insertUserTokenQuery, t)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeUserToken marks an unexpired, unused token as used and returns its owner.
+// sql.ErrNoRows means the token is unknown, expired or already used.
+func ConsumeUserToken(hash string, purpose string) (string, error) {
+	var userID string
+
+	query := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1
+		AND purpose = $2
+		AND used_at IS NULL
+		AND expires_at > NOW()
+		RETURNING user_id
+	`
+
+	err := db.DB.Get(&userID, query, hash, purpose)
+	return userID, err
+}
+
+// GetUserTokenOwner returns the owner of an unexpired, unused token without
+// consuming it. sql.ErrNoRows means the token is unknown, expired or used.
+func GetUserTokenOwner(hash string, purpose string) (string, error) {
+	var userID string
+
+	query := `
+		SELECT user_id
+		FROM user_tokens
+		WHERE token_hash = $1
+		AND purpose = $2
+		AND used_at IS NULL
+		AND expires_at > NOW()
+	`
+
+	err := db.DB.Get(&userID, query, hash, purpose)
+	return userID, err
+}
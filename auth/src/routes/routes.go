@@ -3,6 +3,7 @@ package routes
 import (
 	"auth/src/controller"
 	"auth/src/middleware"
+	"auth/src/models"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -12,8 +13,23 @@ func SetuAuthRoutes() chi.Router {
 
 	router.Post("/signup" , controller.Signup)
 	router.Post("/login" , controller.Login)
-	router.Get("/get/studentlist" , controller.GetStudentsByPrefixBranchSemester)
+	router.Post("/login/mfa" , controller.CompleteMFALogin)
+	router.Post("/login/mfa/enroll" , controller.StartLoginMFAEnrollment)
+	router.Post("/login/mfa/enroll/verify" , controller.CompleteLoginMFAEnrollment)
 	router.Post("/token/refresh" , controller.RefreshToken)
+	router.Post("/logout" , controller.Logout)
+	router.Get("/.well-known/jwks.json" , controller.GetJWKS)
+	router.Post("/forgot-password" , controller.ForgotPassword)
+	router.Post("/reset-password" , controller.ResetPassword)
+	router.Get("/password-policy" , controller.GetPasswordPolicy)
+	router.Post("/accept-invite" , controller.AcceptInvite)
+	router.Post("/verify-email" , controller.VerifyEmail)
+	router.Post("/verify-email/resend" , controller.ResendVerificationEmail)
+	router.Get("/institutions" , controller.ListInstitutions)
+	router.Post("/oauth/token" , controller.IssueServiceToken)
+	router.Get("/oidc/{institution}/login" , controller.StartSSOLogin)
+	router.Get("/oidc/callback" , controller.SSOCallback)
+	router.Post("/oidc/token" , controller.ExchangeSSOCode)
 	router.Group(func (protected chi.Router){
 		protected.Use(middleware.AuthMiddleware)
 		protected.Post("/register/student" , controller.RegisterStudent)
@@ -21,6 +37,74 @@ func SetuAuthRoutes() chi.Router {
 		protected.Put("/update/student" , controller.UpdateStudentProfile)
 		protected.Get("/get/user" , controller.GetUser)
 		protected.Put("/update" , controller.UpdateUser)
+		protected.Put("/password" , controller.ChangePassword)
+		protected.Post("/logout-all" , controller.LogoutAll)
+		protected.Get("/sessions" , controller.ListSessions)
+		protected.Delete("/sessions/{id}" , controller.RevokeSession)
+		protected.Post("/role-requests" , controller.RequestRoleChange)
+		protected.Get("/mfa" , controller.GetMFAStatus)
+		protected.Post("/mfa/enroll" , controller.EnrollMFA)
+		protected.Post("/mfa/enroll/verify" , controller.ConfirmMFAEnrollment)
+		protected.Post("/mfa/recovery-codes" , controller.RegenerateRecoveryCodes)
+		protected.Post("/mfa/disable" , controller.DisableMFA)
+		protected.Get("/assignments/me" , controller.GetMyAssignments)
+	})
+
+	// Other services report requests made with impersonation tokens
+	router.With(middleware.ImpersonationMiddleware).Post("/impersonation/events" , controller.RecordImpersonatedRequest)
+
+	router.Group(func (staff chi.Router){
+		staff.Use(middleware.AuthMiddleware)
+		staff.Use(middleware.RequireRole(models.RoleAdmin, models.RoleTeacher))
+		staff.Post("/students/import" , controller.ImportStudents)
+	})
+
+	// Staff users, or other services holding a students:read token
+	router.Group(func (directory chi.Router){
+		directory.Use(middleware.RoleOrServiceMiddleware(models.ScopeStudentsRead, models.RoleAdmin, models.RoleTeacher))
+		directory.Get("/students" , controller.SearchStudents)
+		directory.Post("/students/lookup" , controller.LookupStudents)
+	})
+
+	router.Group(func (admin chi.Router){
+		admin.Use(middleware.AuthMiddleware)
+		admin.Use(middleware.RequireRole(models.RoleAdmin))
+		admin.Get("/admin/users" , controller.ListUsers)
+		admin.Post("/admin/users/status" , controller.BulkSetUserStatus)
+		admin.Delete("/admin/users/{id}" , controller.DeleteUser)
+		admin.Post("/admin/users/{id}/force-password-reset" , controller.ForcePasswordReset)
+		admin.Post("/admin/users/{id}/unlock" , controller.UnlockUser)
+		admin.Post("/admin/users/{id}/mfa/reset" , controller.ResetUserMFA)
+		admin.Post("/admin/users/{id}/impersonate" , controller.ImpersonateUser)
+		admin.Get("/admin/users/{id}/sessions" , controller.ListUserSessions)
+		admin.Delete("/admin/users/{id}/sessions/{sessionID}" , controller.RevokeUserSession)
+		admin.Get("/admin/users/{id}/export" , controller.ExportUserData)
+		admin.Post("/admin/users/{id}/erase" , controller.EraseUserData)
+		admin.Get("/admin/students" , controller.ListStudents)
+		admin.Delete("/admin/students/{id}" , controller.DeleteStudent)
+		admin.Post("/admin/students/promote" , controller.PromoteStudents)
+		admin.Put("/admin/students/{id}/status" , controller.ChangeStudentStatus)
+		admin.Put("/admin/students/{id}/academic" , controller.UpdateStudentAcademic)
+		admin.Get("/admin/students/{id}/history" , controller.GetStudentHistory)
+		admin.Put("/admin/users/{id}/role" , controller.ChangeUserRole)
+		admin.Get("/admin/users/{id}/role-audit" , controller.GetRoleAudit)
+		admin.Get("/admin/auth-events" , controller.ListAuthEvents)
+		admin.Get("/admin/auth-events/export" , controller.ExportAuthEvents)
+		admin.Post("/admin/invites" , controller.CreateInviteCode)
+		admin.Get("/admin/assignments" , controller.ListAssignments)
+		admin.Post("/admin/assignments" , controller.CreateAssignment)
+		admin.Put("/admin/assignments/{id}" , controller.UpdateAssignment)
+		admin.Delete("/admin/assignments/{id}" , controller.DeleteAssignment)
+		admin.Post("/admin/institutions" , controller.CreateInstitution)
+		admin.Get("/admin/sso" , controller.GetSSOConfig)
+		admin.Put("/admin/sso" , controller.SaveSSOConfig)
+		admin.Delete("/admin/sso" , controller.DeleteSSOConfig)
+		admin.Get("/admin/service-clients" , controller.ListServiceClients)
+		admin.Post("/admin/service-clients" , controller.CreateServiceClient)
+		admin.Delete("/admin/service-clients/{id}" , controller.RevokeServiceClient)
+		admin.Get("/admin/role-requests" , controller.ListRoleChangeRequests)
+		admin.Post("/admin/role-requests/{id}/approve" , controller.ApproveRoleChangeRequest)
+		admin.Post("/admin/role-requests/{id}/reject" , controller.RejectRoleChangeRequest)
 	})
 
 	return router
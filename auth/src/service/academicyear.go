@@ -0,0 +1,32 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CurrentAcademicYear returns the academic year containing t, e.g. "2025-26".
+// The year starts in ACADEMIC_YEAR_START_MONTH (1-12, default 7 for July).
+func CurrentAcademicYear(t time.Time) string {
+	startMonth, err := strconv.Atoi(os.Getenv("ACADEMIC_YEAR_START_MONTH"))
+	if err != nil || startMonth < 1 || startMonth > 12 {
+		startMonth = 7
+	}
+
+	year := t.Year()
+	if int(t.Month()) < startMonth {
+		year--
+	}
+	return fmt.Sprintf("%d-%02d", year, (year+1)%100)
+}
+
+// ValidAcademicYear reports whether year is written like "2025-26"
+func ValidAcademicYear(year string) bool {
+	var start, end int
+	if _, err := fmt.Sscanf(year, "%4d-%2d", &start, &end); err != nil || len(year) != 7 {
+		return false
+	}
+	return (start+1)%100 == end
+}
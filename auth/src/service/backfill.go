@@ -0,0 +1,103 @@
+package service
+
+import (
+	"auth/src/jwtutil"
+	"auth/src/models"
+	"auth/src/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"neuroiq/shared/tenancy"
+)
+
+// backfillServices are the services holding records from before tenancy
+var backfillServices = map[string]bool{"answer": true, "question": true, "ingestion": true, "management": true}
+
+// backfillTimeout bounds one request to one service
+const backfillTimeout = 5 * time.Minute
+
+// backfillInstitutions assigns the records the services wrote before tenancy
+// to the institution of the user who owns them. The 0001 baseline made one
+// institution per distinct users.institution, so legacy data may belong to
+// several; every institution's user and student IDs are sent to each
+// service, which tags its untagged records owned by them. Running it again
+// is harmless.
+func backfillInstitutions() error {
+	jwtutil.InitSigningKeys()
+
+	institutions, err := repository.ListInstitutions()
+	if err != nil {
+		return err
+	}
+
+	remaining := map[string]int64{}
+	failed := false
+	for _, institution := range institutions {
+		userIDs, studentIDs, err := repository.GetInstitutionOwnerIDs(institution.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, svc := range PrivacyServices() {
+			if !backfillServices[svc.Name] {
+				continue
+			}
+
+			var assigned int64
+			for _, owners := range ownerBatches(userIDs, studentIDs) {
+				result, err := backfillService(svc, institution.ID, owners)
+				if err != nil {
+					fmt.Printf("⚠️ %s: %s failed: %v\n", institution.Code, svc.Name, err)
+					failed = true
+					break
+				}
+				assigned += result.Assigned
+				remaining[svc.Name] = result.Remaining
+			}
+			fmt.Printf("✅ %s: %d %s records assigned\n", institution.Code, assigned, svc.Name)
+		}
+	}
+
+	for _, svc := range PrivacyServices() {
+		if remaining[svc.Name] > 0 {
+			fmt.Printf("⚠️ %s still has %d records without an institution\n", svc.Name, remaining[svc.Name])
+		}
+	}
+	if failed {
+		return fmt.Errorf("backfill incomplete; run it again once every service is reachable")
+	}
+	return nil
+}
+
+// backfillService sends one batch of owners to svc
+func backfillService(svc PrivacyService, institutionID string, owners tenancy.Owners) (tenancy.Result, error) {
+	var result tenancy.Result
+
+	ctx, cancel := context.WithTimeout(context.Background(), backfillTimeout)
+	defer cancel()
+
+	data, err := callService(ctx, svc, "/internal/tenancy/backfill", models.ScopeTenancyBackfill, institutionID, owners)
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// ownerBatches splits the IDs into requests of at most tenancy.MaxOwners.
+// There is always one batch, so services also assign the records that only
+// follow already assigned ones.
+func ownerBatches(userIDs []string, studentIDs []string) []tenancy.Owners {
+	batches := []tenancy.Owners{}
+	for len(userIDs) > 0 || len(studentIDs) > 0 || len(batches) == 0 {
+		batch := tenancy.Owners{UserIDs: []string{}, StudentIDs: []string{}}
+		n := min(len(userIDs), tenancy.MaxOwners)
+		batch.UserIDs, userIDs = userIDs[:n], userIDs[n:]
+		n = min(len(studentIDs), tenancy.MaxOwners-len(batch.UserIDs))
+		batch.StudentIDs, studentIDs = studentIDs[:n], studentIDs[n:]
+		batches = append(batches, batch)
+	}
+	return batches
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"auth/src/jwtutil"
+	"auth/src/models"
+	"auth/src/repository"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// bootstrapInviteTTL is how long the first admin's invite link stays valid
+const bootstrapInviteTTL = 7 * 24 * time.Hour
+
+const institutionUsage = "usage: institution create <code> <name> --admin-email <email> | institution backfill"
+
+// RunInstitutionCommand handles `auth institution create <code> <name>
+// --admin-email <email>` and `auth institution backfill`
+func RunInstitutionCommand(args []string) error {
+	if len(args) == 1 && args[0] == "backfill" {
+		return backfillInstitutions()
+	}
+	return createInstitution(args)
+}
+
+// createInstitution bootstraps a database that has no admin yet: the
+// institution is created with its first admin, whose invite link to choose a
+// password is printed. The platform institution is created this way (its
+// code in PLATFORM_INSTITUTION_CODE); later ones may also be made over the
+// API by its admins.
+func createInstitution(args []string) error {
+	code, name, adminEmail, err := parseInstitutionArgs(args)
+	if err != nil {
+		return err
+	}
+
+	exists, err := repository.InstitutionExists(name, code)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("institution name or code already registered")
+	}
+	if user, _ := repository.GetUserByEmail(adminEmail); user != nil {
+		return fmt.Errorf("%s already has an account", adminEmail)
+	}
+
+	token, err := jwtutil.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	institution := models.Institution{Name: name, Code: code}
+	admin := models.User{
+		Name:  strings.SplitN(adminEmail, "@", 2)[0],
+		Email: adminEmail,
+		Role:  models.RoleAdmin,
+	}
+	if err := repository.CreateInstitutionWithAdmin(&institution, &admin, jwtutil.HashToken(token), bootstrapInviteTTL); err != nil {
+		return err
+	}
+
+	event := models.AuthEvent{
+		InstitutionID: &institution.ID,
+		TargetID:      institution.ID,
+		EventType:     models.EventInstitutionCreate,
+		Outcome:       models.OutcomeSuccess,
+		Detail:        institution.Code + " (command line, admin " + admin.Email + ")",
+	}
+	if err := repository.CreateAuthEvent(&event); err != nil {
+		fmt.Printf("⚠️ Failed to record %s event: %v\n", event.EventType, err)
+	}
+
+	fmt.Printf("✅ Institution %s (%s) created with id %s\n", institution.Name, institution.Code, institution.ID)
+	fmt.Printf("Send %s this link to choose a password (valid 7 days):\n%s\n", admin.Email, AppLink("/accept-invite", token))
+	return nil
+}
+
+// parseInstitutionArgs reads `create <code> <name> --admin-email <email>`;
+// the flag may come anywhere after create
+func parseInstitutionArgs(args []string) (code string, name string, adminEmail string, err error) {
+	usage := errors.New(institutionUsage)
+	if len(args) == 0 || args[0] != "create" {
+		return "", "", "", usage
+	}
+
+	var positional []string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--admin-email" && i+1 < len(args):
+			adminEmail = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--admin-email="):
+			adminEmail = strings.TrimPrefix(args[i], "--admin-email=")
+		case strings.HasPrefix(args[i], "-"):
+			return "", "", "", usage
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 2 || adminEmail == "" {
+		return "", "", "", usage
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(positional[0]))
+	name = strings.TrimSpace(positional[1])
+	if code == "" || name == "" {
+		return "", "", "", usage
+	}
+	addr, err := mail.ParseAddress(adminEmail)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid admin email %q", adminEmail)
+	}
+	return code, name, addr.Address, nil
+}
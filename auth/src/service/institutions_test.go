@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"neuroiq/shared/tenancy"
+)
+
+func TestParseInstitutionArgs(t *testing.T) {
+	code, name, email, err := parseInstitutionArgs([]string{"create", "nit", " NIT Trichy ", "--admin-email", "Dean <dean@nit.test>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "NIT" || name != "NIT Trichy" || email != "dean@nit.test" {
+		t.Errorf("got %q %q %q", code, name, email)
+	}
+
+	if _, _, email, err := parseInstitutionArgs([]string{"create", "--admin-email=a@b.test", "X", "Y"}); err != nil || email != "a@b.test" {
+		t.Errorf("flag before positionals: %q, %v", email, err)
+	}
+
+	for _, args := range [][]string{
+		nil,
+		{"delete", "X", "Y", "--admin-email", "a@b.test"},
+		{"create", "X", "Y"},
+		{"create", "X", "--admin-email", "a@b.test"},
+		{"create", "X", "Y", "Z", "--admin-email", "a@b.test"},
+		{"create", "X", "Y", "--admin-email", "not an email"},
+		{"create", "X", "Y", "--admin-email"},
+		{"create", "X", "Y", "--admin", "a@b.test"},
+		{"create", " ", "Y", "--admin-email", "a@b.test"},
+	} {
+		if _, _, _, err := parseInstitutionArgs(args); err == nil {
+			t.Errorf("accepted %q", args)
+		}
+	}
+}
+
+func TestOwnerBatches(t *testing.T) {
+	if batches := ownerBatches([]string{}, []string{}); len(batches) != 1 {
+		t.Errorf("no owners: %d batches, want 1", len(batches))
+	}
+
+	users := make([]string, 700)
+	students := make([]string, 400)
+	batches := ownerBatches(users, students)
+	if len(batches) != 3 {
+		t.Fatalf("%d batches, want 3", len(batches))
+	}
+	var gotUsers, gotStudents int
+	for _, b := range batches {
+		if len(b.UserIDs)+len(b.StudentIDs) > tenancy.MaxOwners {
+			t.Errorf("batch of %d IDs", len(b.UserIDs)+len(b.StudentIDs))
+		}
+		gotUsers += len(b.UserIDs)
+		gotStudents += len(b.StudentIDs)
+	}
+	if gotUsers != 700 || gotStudents != 400 {
+		t.Errorf("sent %d users and %d students", gotUsers, gotStudents)
+	}
+}
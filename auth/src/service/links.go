@@ -0,0 +1,15 @@
+package service
+
+import (
+	"net/url"
+	"os"
+)
+
+// AppLink builds a frontend URL carrying a mailed token
+func AppLink(path string, token string) string {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost"
+	}
+	return base + path + "?token=" + url.QueryEscape(token)
+}
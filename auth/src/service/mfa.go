@@ -0,0 +1,105 @@
+package service
+
+import (
+	"auth/src/jwtutil"
+	"auth/src/models"
+	"auth/src/repository"
+	"auth/src/totp"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+// ErrInvalidMFACode covers wrong, expired and replayed codes alike
+var ErrInvalidMFACode = errors.New("invalid MFA code")
+
+// useMFAStep burns a TOTP step; tests replace it
+var useMFAStep = repository.UseMFAStep
+
+// MFAIssuer is the account issuer shown in authenticator apps (MFA_ISSUER)
+func MFAIssuer() string {
+	if issuer := os.Getenv("MFA_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "NeuroIQ"
+}
+
+// MFARequired reports whether role must use MFA, per the comma separated
+// MFA_REQUIRED_ROLES (e.g. "admin,teacher"). MFA is optional by default.
+func MFARequired(role string) bool {
+	for _, r := range strings.Split(os.Getenv("MFA_REQUIRED_ROLES"), ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// StartMFAEnrollment stores a fresh pending secret for user and returns it with its provisioning URI
+func StartMFAEnrollment(user *models.User) (secret string, uri string, err error) {
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := repository.SavePendingMFA(user.ID, secret); err != nil {
+		return "", "", err
+	}
+	return secret, totp.ProvisioningURI(secret, MFAIssuer(), user.Email), nil
+}
+
+// VerifyMFACode checks a TOTP code at time now and burns its step so it cannot be reused
+func VerifyMFACode(mfa *models.UserMFA, code string, now time.Time) error {
+	step, ok := totp.Validate(mfa.Secret, code, now)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	fresh, err := useMFAStep(mfa.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// UseRecoveryCode spends one of the user's recovery codes
+func UseRecoveryCode(userID string, code string) error {
+	ok, err := repository.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// GenerateRecoveryCodes returns new codes for the user to keep and their hashes to store
+func GenerateRecoveryCodes() (codes []string, hashes []string, err error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, dashes and spaces so codes can be typed loosely
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return jwtutil.HashToken(normalized)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"auth/src/models"
+	"auth/src/totp"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStepStore stands in for user_mfa.last_used_step
+func fakeStepStore(t *testing.T) {
+	t.Helper()
+	last := map[string]int64{}
+	prev := useMFAStep
+	useMFAStep = func(userID string, step int64) (bool, error) {
+		if step <= last[userID] {
+			return false, nil
+		}
+		last[userID] = step
+		return true, nil
+	}
+	t.Cleanup(func() { useMFAStep = prev })
+}
+
+func TestVerifyMFACodeRejectsReplay(t *testing.T) {
+	fakeStepStore(t)
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfa := &models.UserMFA{UserID: "user-1", Secret: secret}
+
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.CodeAt(secret, totp.Step(now))
+	if err := VerifyMFACode(mfa, code, now); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	// The same code is still inside the skew window a step later
+	if err := VerifyMFACode(mfa, code, now.Add(totp.Period)); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("replay: err = %v, want ErrInvalidMFACode", err)
+	}
+	// An older code of the window is refused once a newer one was used
+	older, _ := totp.CodeAt(secret, totp.Step(now)-1)
+	if err := VerifyMFACode(mfa, older, now); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("older step: err = %v, want ErrInvalidMFACode", err)
+	}
+
+	next, _ := totp.CodeAt(secret, totp.Step(now)+1)
+	if err := VerifyMFACode(mfa, next, now.Add(totp.Period)); err != nil {
+		t.Fatalf("next step: %v", err)
+	}
+}
+
+func TestVerifyMFACodeWrongCode(t *testing.T) {
+	fakeStepStore(t)
+	secret, _ := totp.GenerateSecret()
+	mfa := &models.UserMFA{UserID: "user-1", Secret: secret}
+
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.CodeAt(secret, totp.Step(now)+2)
+	if err := VerifyMFACode(mfa, code, now); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("err = %v, want ErrInvalidMFACode", err)
+	}
+}
+
+func TestMFARequired(t *testing.T) {
+	t.Setenv("MFA_REQUIRED_ROLES", "admin, teacher")
+	for role, want := range map[string]bool{"admin": true, "teacher": true, "student": false, "": false} {
+		if got := MFARequired(role); got != want {
+			t.Errorf("MFARequired(%q) = %v, want %v", role, got, want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"auth/src/breach"
+	"auth/src/models"
+	"auth/src/repository"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt ignores everything past 72 bytes, so longer passwords are refused
+// rather than silently truncated
+const maxPasswordBytes = 72
+
+// recentPasswordHashes reads the history; tests replace it
+var recentPasswordHashes = repository.RecentPasswordHashes
+
+// PasswordPolicy is read from the environment on every use, so it can be
+// tightened without a migration; existing passwords keep working until the
+// next change.
+type PasswordPolicy struct {
+	MinLength  int `json:"min_length"`  // PASSWORD_MIN_LENGTH
+	MinClasses int `json:"min_classes"` // PASSWORD_MIN_CLASSES, of lower, upper, digit and symbol
+	History    int `json:"history"`     // PASSWORD_HISTORY, previous passwords that cannot be reused
+	MaxBytes   int `json:"max_bytes"`
+}
+
+// PolicyError is a password refused by the policy; the message is safe to
+// show to the user
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Reason
+}
+
+func CurrentPasswordPolicy() PasswordPolicy {
+	classes := envInt("PASSWORD_MIN_CLASSES", 2)
+	if classes > 4 {
+		classes = 4
+	}
+	return PasswordPolicy{
+		MinLength:  envInt("PASSWORD_MIN_LENGTH", 8),
+		MinClasses: classes,
+		History:    passwordHistory(),
+		MaxBytes:   maxPasswordBytes,
+	}
+}
+
+// passwordHistory is PASSWORD_HISTORY clamped to at least 0, which turns the
+// history check off; it ends up in a SQL LIMIT, where negatives are an error
+func passwordHistory() int {
+	n, err := strconv.Atoi(os.Getenv("PASSWORD_HISTORY"))
+	if err != nil {
+		return 5
+	}
+	return max(n, 0)
+}
+
+// BcryptCost is the cost new hashes are made with (BCRYPT_COST). Raising it
+// upgrades existing hashes as their owners log in.
+func BcryptCost() int {
+	cost := envInt("BCRYPT_COST", 12)
+	if cost < bcrypt.MinCost {
+		return bcrypt.MinCost
+	}
+	if cost > bcrypt.MaxCost {
+		return bcrypt.MaxCost
+	}
+	return cost
+}
+
+// HashPassword hashes password at the configured cost
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost())
+	return string(hash), err
+}
+
+// CheckPassword applies the policy to a new password for user. user.ID may
+// be empty at signup, where there is no history yet. A *PolicyError means
+// the password was refused; any other error is internal.
+func CheckPassword(user *models.User, password string) error {
+	policy := CurrentPasswordPolicy()
+
+	if len(password) > policy.MaxBytes {
+		return &PolicyError{fmt.Sprintf("Password must be at most %d bytes", policy.MaxBytes)}
+	}
+	if len([]rune(password)) < policy.MinLength {
+		return &PolicyError{fmt.Sprintf("Password must be at least %d characters", policy.MinLength)}
+	}
+	if characterClasses(password) < policy.MinClasses {
+		return &PolicyError{fmt.Sprintf("Password must mix at least %d of lowercase letters, uppercase letters, digits and symbols", policy.MinClasses)}
+	}
+
+	lower := strings.ToLower(password)
+	for _, part := range personalParts(user) {
+		if strings.Contains(lower, part) {
+			return &PolicyError{"Password must not contain your name or email"}
+		}
+	}
+
+	breached, err := breach.Breached(password)
+	if err != nil {
+		// A broken breach file should not lock everyone out of changing passwords
+		log.Printf("password breach check failed: %v", err)
+	}
+	if breached {
+		return &PolicyError{"Password appears in a known data breach, choose another"}
+	}
+
+	if user.ID == "" || policy.History == 0 {
+		return nil
+	}
+	hashes, err := recentPasswordHashes(user.ID, policy.History)
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return &PolicyError{fmt.Sprintf("Password must differ from your last %d passwords", policy.History)}
+		}
+	}
+	return nil
+}
+
+// SavePassword stores a password that passed CheckPassword as user's
+// password and remembers it in the history
+func SavePassword(user *models.User, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := repository.SetUserPassword(user.ID, hash, CurrentPasswordPolicy().History); err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	return nil
+}
+
+// UpgradePasswordHash rehashes a password that just matched if its hash is
+// cheaper than the configured cost. Failures are only logged; the login
+// itself already succeeded.
+func UpgradePasswordHash(user *models.User, password string) {
+	cost, err := bcrypt.Cost([]byte(user.PasswordHash))
+	if err != nil || cost >= BcryptCost() {
+		return
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		log.Printf("failed to rehash password: %v", err)
+		return
+	}
+	if err := repository.UpdatePasswordHash(user.ID, user.PasswordHash, hash); err != nil {
+		log.Printf("failed to store rehashed password: %v", err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// personalParts are the lowercase pieces of the user's name and email local
+// part long enough to matter
+func personalParts(user *models.User) []string {
+	local := user.Email
+	if at := strings.LastIndex(local, "@"); at >= 0 {
+		local = local[:at]
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(user.Name+" "+local), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	if local = strings.ToLower(local); local != "" {
+		fields = append(fields, local)
+	}
+
+	var parts []string
+	for _, f := range fields {
+		if len([]rune(f)) >= 3 {
+			parts = append(parts, f)
+		}
+	}
+	return parts
+}
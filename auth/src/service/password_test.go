@@ -0,0 +1,124 @@
+package service
+
+import (
+	"auth/src/models"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "")
+	t.Setenv("PASSWORD_MIN_CLASSES", "")
+	t.Setenv("PASSWORD_BREACH_FILE", "")
+	user := &models.User{Name: "Ada Lovelace", Email: "ada.l@example.edu"}
+
+	tests := []struct {
+		name     string
+		password string
+		reason   string // substring of the refusal; empty = accepted
+	}{
+		{"accepted", "Tulip-Harbor-42", ""},
+		{"too short", "Ab1-x", "at least 8 characters"},
+		{"too long", strings.Repeat("Ab1-", 19), "at most 72 bytes"},
+		{"one class", "tuliparbor", "mix at least 2"},
+		{"name", "Lovelace#2024", "name or email"},
+		{"email local part", "xx-ada.l-99", "name or email"},
+		{"breached", "Password1", "known data breach"},
+		{"multibyte length counts runes", "ÄÖÜäöü1!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPassword(user, tt.password)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("refused: %v", err)
+				}
+				return
+			}
+			var policy *PolicyError
+			if !errors.As(err, &policy) || !strings.Contains(policy.Reason, tt.reason) {
+				t.Fatalf("err = %v, want a PolicyError with %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordMinClasses(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_CLASSES", "9") // capped at 4
+	user := &models.User{}
+
+	if err := CheckPassword(user, "tulip-harbor-42"); err == nil {
+		t.Fatal("three classes accepted with all four required")
+	}
+	if err := CheckPassword(user, "Tulip-Harbor-42"); err != nil {
+		t.Fatalf("four classes refused: %v", err)
+	}
+}
+
+func TestCheckPasswordHistory(t *testing.T) {
+	t.Setenv("PASSWORD_HISTORY", "2")
+	old, err := bcrypt.GenerateFromPassword([]byte("Tulip-Harbor-42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := recentPasswordHashes
+	var asked int
+	recentPasswordHashes = func(userID string, n int) ([]string, error) {
+		asked = n
+		return []string{string(old)}, nil
+	}
+	t.Cleanup(func() { recentPasswordHashes = prev })
+
+	user := &models.User{ID: "user-1"}
+	var policy *PolicyError
+	if err := CheckPassword(user, "Tulip-Harbor-42"); !errors.As(err, &policy) || !strings.Contains(policy.Reason, "last 2 passwords") {
+		t.Fatalf("reused password: err = %v", err)
+	}
+	if asked != 2 {
+		t.Errorf("history read with n = %d, want 2", asked)
+	}
+	if err := CheckPassword(user, "Maple-Canyon-77"); err != nil {
+		t.Fatalf("new password refused: %v", err)
+	}
+
+	// Signup has no user ID and no history to read
+	asked = -1
+	if err := CheckPassword(&models.User{}, "Tulip-Harbor-42"); err != nil || asked != -1 {
+		t.Fatalf("signup: err = %v, history read = %v", err, asked != -1)
+	}
+}
+
+func TestPasswordHistoryClamp(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", 5},
+		{"abc", 5},
+		{"3", 3},
+		{"0", 0},
+		{"-1", 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("PASSWORD_HISTORY", tt.env)
+		if got := CurrentPasswordPolicy().History; got != tt.want {
+			t.Errorf("PASSWORD_HISTORY=%q: History = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+
+	// Off means the history is not read at all
+	t.Setenv("PASSWORD_HISTORY", "-1")
+	prev := recentPasswordHashes
+	recentPasswordHashes = func(string, int) ([]string, error) {
+		t.Fatal("history read with PASSWORD_HISTORY off")
+		return nil, nil
+	}
+	t.Cleanup(func() { recentPasswordHashes = prev })
+	if err := CheckPassword(&models.User{ID: "user-1"}, "Tulip-Harbor-42"); err != nil {
+		t.Fatal(err)
+	}
+}
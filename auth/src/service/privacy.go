@@ -0,0 +1,101 @@
+package service
+
+import (
+	"auth/src/jwtutil"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// privacyClientID is the token subject when auth calls the services itself
+const privacyClientID = "auth"
+
+var privacyClient = &http.Client{Timeout: 30 * time.Second}
+
+// PrivacyService is a service holding user data, reached at its API base
+// (e.g. http://answer:8006/api/answer) under /internal/privacy
+type PrivacyService struct {
+	Name    string
+	BaseURL string
+}
+
+// PrivacyServices lists the services named by ANSWER_URI, QUESTION_URI,
+// INGESTION_URI, MANAGEMENT_URI and PROCTORING_URI; unset ones are skipped
+// and reported as such by the callers.
+func PrivacyServices() []PrivacyService {
+	services := []PrivacyService{
+		{Name: "answer", BaseURL: os.Getenv("ANSWER_URI")},
+		{Name: "question", BaseURL: os.Getenv("QUESTION_URI")},
+		{Name: "ingestion", BaseURL: os.Getenv("INGESTION_URI")},
+		{Name: "management", BaseURL: os.Getenv("MANAGEMENT_URI")},
+		{Name: "proctoring", BaseURL: os.Getenv("PROCTORING_URI")},
+	}
+	for i := range services {
+		services[i].BaseURL = strings.TrimSuffix(services[i].BaseURL, "/")
+	}
+	return services
+}
+
+// PrivacySubject identifies a user to the services: answers and uploads are
+// keyed by the user ID, attendance by the student record ID and seats by
+// the roll number. Pseudonym is set for erasure only.
+type PrivacySubject struct {
+	UserID     string `json:"user_id"`
+	StudentID  string `json:"student_id,omitempty"`
+	RollNumber string `json:"roll_number,omitempty"`
+	Pseudonym  string `json:"pseudonym,omitempty"`
+}
+
+// CallPrivacyService posts subject to the service's export or erase endpoint
+// with a token scoped to institutionID and returns the JSON response
+func CallPrivacyService(ctx context.Context, svc PrivacyService, action string, scope string, institutionID string, subject PrivacySubject) (json.RawMessage, error) {
+	return callService(ctx, svc, "/internal/privacy/"+action, scope, institutionID, subject)
+}
+
+// callService posts payload to path under the service's base URL with a token
+// auth signs for itself, scoped to institutionID and scope
+func callService(ctx context.Context, svc PrivacyService, path string, scope string, institutionID string, payload interface{}) (json.RawMessage, error) {
+	if svc.BaseURL == "" {
+		return nil, fmt.Errorf("%s is not configured", svc.Name)
+	}
+
+	token, err := jwtutil.GenerateServiceToken(privacyClientID, institutionID, scope)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", svc.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := privacyClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s: %s", svc.Name, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%s returned invalid JSON", svc.Name)
+	}
+	return data, nil
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"auth/src/dto"
+	"auth/src/models"
+	"auth/src/repository"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	RosterActionCreate = "create"
+	RosterActionUpdate = "update"
+	RosterActionError  = "error"
+
+	MaxRosterRows = 5000
+)
+
+// rosterColumns are the accepted header names; every one except section and phone is required
+var rosterColumns = []string{
+	"first_name", "last_name", "roll_number", "enrollment_no",
+	"branch", "semester", "section", "email", "phone",
+}
+
+// RosterRow is one data row of an uploaded roster
+type RosterRow struct {
+	Line    int // 1-based line in the file, header included
+	Student dto.StudentRegisterDTO
+	Errors  []string
+}
+
+// ParseRoster reads a .csv or .xlsx roster (first sheet) with a header row
+func ParseRoster(filename string, r io.Reader) ([]RosterRow, error) {
+	var records [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err = reader.ReadAll()
+	case ".xlsx":
+		records, err = readXLSX(r)
+	default:
+		return nil, errors.New("roster must be a .csv or .xlsx file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roster: %w", err)
+	}
+
+	if len(records) < 2 {
+		return nil, errors.New("roster has no data rows")
+	}
+	if len(records)-1 > MaxRosterRows {
+		return nil, fmt.Errorf("roster has more than %d rows", MaxRosterRows)
+	}
+
+	index := map[string]int{}
+	for i, h := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF")))] = i
+	}
+	for _, col := range rosterColumns {
+		if _, ok := index[col]; !ok && col != "section" && col != "phone" {
+			return nil, fmt.Errorf("roster is missing column %q", col)
+		}
+	}
+
+	rows := make([]RosterRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		cell := func(col string) string {
+			j, ok := index[col]
+			if !ok || j >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[j])
+		}
+
+		if strings.Join(record, "") == "" {
+			continue // blank line
+		}
+
+		row := RosterRow{Line: i + 2}
+		row.Student = dto.StudentRegisterDTO{
+			FirstName:    cell("first_name"),
+			LastName:     cell("last_name"),
+			RollNumber:   cell("roll_number"),
+			EnrollmentNo: cell("enrollment_no"),
+			Branch:       strings.ToUpper(cell("branch")),
+			Section:      cell("section"),
+			Email:        strings.ToLower(cell("email")),
+			Phone:        cell("phone"),
+		}
+		if semester, err := strconv.Atoi(cell("semester")); err == nil {
+			row.Student.Semester = semester
+		} else {
+			row.Errors = append(row.Errors, "semester: must be a number")
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("workbook has no sheets")
+	}
+	return f.GetRows(sheets[0])
+}
+
+// ValidateRoster checks each row against the StudentRegisterDTO rules, against
+// the other rows and against existing students and users. It returns one
+// entry per row (nil for rows with errors); rows whose roll number already
+// exists become updates, and students without a login get a new user in the
+// importer's institution. Identifiers held by another institution are
+// rejected without naming the student they belong to.
+func ValidateRoster(rows []RosterRow, institutionID string, institution string) ([]*repository.RosterEntry, error) {
+	validate := validator.New()
+
+	var rolls, enrollments, emails []string
+	for i := range rows {
+		row := &rows[i]
+		if err := validate.Struct(&row.Student); err != nil {
+			var fieldErrs validator.ValidationErrors
+			if errors.As(err, &fieldErrs) {
+				for _, fe := range fieldErrs {
+					row.Errors = append(row.Errors, fmt.Sprintf("%s: failed %s", fe.Field(), fe.Tag()))
+				}
+			} else {
+				row.Errors = append(row.Errors, err.Error())
+			}
+		}
+		rolls = append(rolls, row.Student.RollNumber)
+		enrollments = append(enrollments, row.Student.EnrollmentNo)
+		emails = append(emails, row.Student.Email)
+	}
+
+	existing, err := repository.GetStudentsByIdentifiers(rolls, enrollments, emails)
+	if err != nil {
+		return nil, err
+	}
+	users, err := repository.GetUsersByEmails(emails)
+	if err != nil {
+		return nil, err
+	}
+
+	byRoll := map[string]*repository.RosterStudent{}
+	byEnrollment := map[string]*repository.RosterStudent{}
+	byEmail := map[string]*repository.RosterStudent{}
+	for i := range existing {
+		s := &existing[i]
+		// A student without a login has no institution of their own; they
+		// predate tenancy, and the importer's institution takes them over
+		// and gives them a login there
+		if s.InstitutionID == "" {
+			s.InstitutionID = institutionID
+		}
+		byRoll[s.RollNumber] = s
+		byEnrollment[s.EnrollmentNo] = s
+		byEmail[strings.ToLower(s.Email)] = s
+	}
+	usersByEmail := map[string]*models.User{}
+	for i := range users {
+		usersByEmail[strings.ToLower(users[i].Email)] = &users[i]
+	}
+
+	// Duplicates inside the file are reported on the later row
+	seenRoll := map[string]int{}
+	seenEnrollment := map[string]int{}
+	seenEmail := map[string]int{}
+
+	entries := make([]*repository.RosterEntry, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		s := row.Student
+
+		if line, ok := seenRoll[s.RollNumber]; ok && s.RollNumber != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("roll_number: duplicate of line %d", line))
+		}
+		if line, ok := seenEnrollment[s.EnrollmentNo]; ok && s.EnrollmentNo != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("enrollment_no: duplicate of line %d", line))
+		}
+		if line, ok := seenEmail[s.Email]; ok && s.Email != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("email: duplicate of line %d", line))
+		}
+		seenRoll[s.RollNumber] = row.Line
+		seenEnrollment[s.EnrollmentNo] = row.Line
+		seenEmail[s.Email] = row.Line
+
+		current := byRoll[s.RollNumber]
+		if current != nil && current.InstitutionID != institutionID {
+			row.Errors = append(row.Errors, "roll_number: already registered")
+			current = nil
+		} else if current != nil && current.DeletedAt != nil {
+			row.Errors = append(row.Errors, "roll_number: belongs to a deleted student")
+		}
+		if other := byEnrollment[s.EnrollmentNo]; other != nil && other != current {
+			row.Errors = append(row.Errors, "enrollment_no: "+conflictMessage(other, institutionID))
+		}
+		if other := byEmail[s.Email]; other != nil && other != current {
+			row.Errors = append(row.Errors, "email: "+conflictMessage(other, institutionID))
+		}
+		user := usersByEmail[s.Email]
+		if user != nil && (current == nil || current.UserID != user.ID) {
+			row.Errors = append(row.Errors, "email: already registered to another account")
+		}
+
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		entry := &repository.RosterEntry{
+			Existing: current,
+			Student: models.Student{
+				FirstName:    s.FirstName,
+				LastName:     s.LastName,
+				RollNumber:   s.RollNumber,
+				EnrollmentNo: s.EnrollmentNo,
+				Branch:       s.Branch,
+				Semester:     s.Semester,
+				Section:      s.Section,
+				Email:        s.Email,
+				Phone:        s.Phone,
+			},
+		}
+		if current == nil || current.UserID == "" {
+			entry.User = &models.User{
+				Name:          s.FirstName + " " + s.LastName,
+				Email:         s.Email,
+				Role:          models.RoleStudent,
+				Institution:   institution,
+				InstitutionID: institutionID,
+				// Not a bcrypt hash, so no password matches until the invite is accepted
+				PasswordHash: "!",
+			}
+		}
+		entries[i] = entry
+	}
+
+	return entries, nil
+}
+
+// conflictMessage only names the clashing student when they are in the importer's institution
+func conflictMessage(other *repository.RosterStudent, institutionID string) string {
+	if other.InstitutionID != institutionID {
+		return "already registered"
+	}
+	return "already used by roll number " + other.RollNumber
+}
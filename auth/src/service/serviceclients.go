@@ -0,0 +1,68 @@
+package service
+
+import (
+	"auth/src/jwtutil"
+	"auth/src/models"
+	"auth/src/repository"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownScope is returned for a scope no service client can be granted
+var ErrUnknownScope = errors.New("unknown scope")
+
+// CreateServiceClient registers a calling service granted scopes and
+// returns it with its secret, which is not stored and cannot be shown again
+func CreateServiceClient(name string, scopes []string, createdBy string) (*models.ServiceClient, string, error) {
+	known := map[string]bool{}
+	for _, s := range models.ServiceScopes {
+		known[s] = true
+	}
+	for _, s := range scopes {
+		if !known[s] {
+			return nil, "", fmt.Errorf("%w: %s", ErrUnknownScope, s)
+		}
+	}
+
+	clientID, err := jwtutil.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := jwtutil.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := models.ServiceClient{
+		ClientID:   clientID,
+		SecretHash: jwtutil.HashToken(secret),
+		Name:       strings.TrimSpace(name),
+		Scopes:     strings.Join(scopes, " "),
+		CreatedBy:  createdBy,
+	}
+	if err := repository.CreateServiceClient(&client); err != nil {
+		return nil, "", err
+	}
+	return &client, secret, nil
+}
+
+// cliCreator is the created_by of clients made on the command line
+const cliCreator = "00000000-0000-0000-0000-000000000000"
+
+// RunServiceClientCommand handles `auth service-client create <name> <scope>...`,
+// which provisions a client before any platform admin exists (e.g. answer's
+// llm:evaluate client at deployment) and prints its env lines
+func RunServiceClientCommand(args []string) error {
+	if len(args) < 3 || args[0] != "create" {
+		return errors.New("usage: service-client create <name> <scope>... (scopes: " + strings.Join(models.ServiceScopes, ", ") + ")")
+	}
+
+	client, secret, err := CreateServiceClient(args[1], args[2:], cliCreator)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Service client %q created with scopes %q\n", client.Name, client.Scopes)
+	fmt.Printf("SERVICE_CLIENT_ID=%s\nSERVICE_CLIENT_SECRET=%s\n", client.ClientID, secret)
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"auth/src/jwtutil"
+	"auth/src/models"
+	"auth/src/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionsClientID is the token subject when auth asks management about exams
+const sessionsClientID = "auth"
+
+var examClient = &http.Client{Timeout: 5 * time.Second}
+
+// StudentExamSessionLimit is STUDENT_EXAM_MAX_SESSIONS, the most sessions a
+// student may hold while one of their exams runs; 0 (unset) means no cap
+func StudentExamSessionLimit() int {
+	return envInt("STUDENT_EXAM_MAX_SESSIONS", 0)
+}
+
+// ExamSessionLimitReached reports whether a new login of user must be
+// refused: they are a student, already hold the capped number of sessions
+// and an exam of their branch and semester is running.
+func ExamSessionLimitReached(ctx context.Context, user *models.User) (bool, error) {
+	limit := StudentExamSessionLimit()
+	if limit == 0 || user.Role != models.RoleStudent {
+		return false, nil
+	}
+
+	count, err := repository.CountLiveSessions(user.ID)
+	if err != nil || count < limit {
+		return false, err
+	}
+
+	student, err := repository.GetStudentByUserID(user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return examInProgress(ctx, user.InstitutionID, student.Branch, student.Semester)
+}
+
+// examInProgress asks management (MANAGEMENT_URI) whether an exam of branch
+// and semester is running
+func examInProgress(ctx context.Context, institutionID string, branch string, semester int) (bool, error) {
+	base := strings.TrimSuffix(os.Getenv("MANAGEMENT_URI"), "/")
+	if base == "" {
+		return false, fmt.Errorf("MANAGEMENT_URI not configured")
+	}
+
+	token, err := jwtutil.GenerateServiceToken(sessionsClientID, institutionID, models.ScopeExamsRead)
+	if err != nil {
+		return false, err
+	}
+
+	query := url.Values{}
+	query.Set("branch", branch)
+	query.Set("semester", strconv.Itoa(semester))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", base+"/internal/exams/in-progress?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := examClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("management returned %s", resp.Status)
+	}
+
+	var result struct {
+		InProgress bool `json:"in_progress"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+	return result.InProgress, nil
+}
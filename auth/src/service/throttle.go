@@ -0,0 +1,159 @@
+package service
+
+import (
+	"auth/src/models"
+	"auth/src/repository"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Login throttling: each failure is counted per account (email) and per
+// client IP. After a few failures the next attempt must wait an exponentially
+// growing delay, and after the scope's limit it is locked out entirely.
+const (
+	freeLoginFailures = 2               // failures allowed before backoff starts
+	maxLoginBackoff   = 5 * time.Minute // cap on the per-attempt delay
+)
+
+type loginLimit struct {
+	scope       string
+	maxFailures int
+}
+
+func envInt(name string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
+}
+
+func loginLimits() []loginLimit {
+	return []loginLimit{
+		{scope: models.LoginScopeAccount, maxFailures: envInt("LOGIN_MAX_ACCOUNT_FAILURES", 5)},
+		{scope: models.LoginScopeIP, maxFailures: envInt("LOGIN_MAX_IP_FAILURES", 20)},
+	}
+}
+
+// loginWindow is how long failures are remembered after the last one
+func loginWindow() time.Duration {
+	return time.Duration(envInt("LOGIN_FAILURE_WINDOW_MINUTES", 15)) * time.Minute
+}
+
+func loginLockout() time.Duration {
+	return time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute
+}
+
+// loginBackoff is the wait required after the given number of failures
+func loginBackoff(failures int) time.Duration {
+	if failures <= freeLoginFailures {
+		return 0
+	}
+	shift := failures - freeLoginFailures - 1
+	if shift > 16 {
+		return maxLoginBackoff
+	}
+	delay := time.Second << shift
+	if delay > maxLoginBackoff {
+		return maxLoginBackoff
+	}
+	return delay
+}
+
+// mailLimits bound the requests that mail an address, so the reset and
+// verification endpoints cannot be used to flood an inbox
+func mailLimits() []loginLimit {
+	return []loginLimit{
+		{scope: models.MailScopeAccount, maxFailures: envInt("MAIL_MAX_ACCOUNT_REQUESTS", 3)},
+		{scope: models.MailScopeIP, maxFailures: envInt("MAIL_MAX_IP_REQUESTS", 10)},
+	}
+}
+
+func loginKey(scope string, email string, ip string) string {
+	if scope == models.LoginScopeIP || scope == models.MailScopeIP {
+		return ip
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// LoginRetryAfter reports how long the caller must wait before another
+// attempt for this email/IP is evaluated; zero means go ahead.
+func LoginRetryAfter(email string, ip string) time.Duration {
+	return retryAfter(loginLimits(), email, ip)
+}
+
+// MailRetryAfter counts a request to mail email from ip and reports how long
+// the caller must wait instead; zero means the mail may be sent. Requests are
+// counted whether or not the email has an account.
+func MailRetryAfter(email string, ip string) time.Duration {
+	if wait := retryAfter(mailLimits(), email, ip); wait > 0 {
+		return wait
+	}
+	recordFailure(mailLimits(), email, ip)
+	return 0
+}
+
+func retryAfter(limits []loginLimit, email string, ip string) time.Duration {
+	var wait time.Duration
+	now := time.Now()
+
+	for _, limit := range limits {
+		failure, _ := repository.GetLoginFailure(limit.scope, loginKey(limit.scope, email, ip))
+		if d := failureWait(failure, now); d > wait {
+			wait = d
+		}
+	}
+
+	return wait
+}
+
+// failureWait is the wait one counter imposes at now. A lockout holds until
+// it ends even when it outlasts the failure window (LOGIN_LOCKOUT_MINUTES
+// longer than LOGIN_FAILURE_WINDOW_MINUTES); only the backoff expires with
+// the window.
+func failureWait(failure *models.LoginFailure, now time.Time) time.Duration {
+	if failure == nil {
+		return 0
+	}
+	if failure.LockedUntil != nil && failure.LockedUntil.After(now) {
+		return failure.LockedUntil.Sub(now)
+	}
+	if now.Sub(failure.LastFailureAt) > loginWindow() {
+		return 0
+	}
+	if d := failure.LastFailureAt.Add(loginBackoff(failure.Failures)).Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// RecordLoginFailure counts a failed attempt and locks the scope once it hits its limit
+func RecordLoginFailure(email string, ip string) {
+	recordFailure(loginLimits(), email, ip)
+}
+
+func recordFailure(limits []loginLimit, email string, ip string) {
+	for _, limit := range limits {
+		key := loginKey(limit.scope, email, ip)
+
+		failure, err := repository.RecordLoginFailure(limit.scope, key, loginWindow())
+		if err != nil {
+			log.Printf("failed to record login failure: %v", err)
+			continue
+		}
+
+		if failure.Failures >= limit.maxFailures {
+			if err := repository.LockLogin(limit.scope, key, time.Now().Add(loginLockout())); err != nil {
+				log.Printf("failed to lock login: %v", err)
+			}
+		}
+	}
+}
+
+// ResetLoginFailures clears the account counter after a successful login or an admin unlock
+func ResetLoginFailures(email string) error {
+	return repository.ClearLoginFailures(models.LoginScopeAccount, loginKey(models.LoginScopeAccount, email, ""))
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"auth/src/models"
+	"testing"
+	"time"
+)
+
+func TestFailureWait(t *testing.T) {
+	t.Setenv("LOGIN_FAILURE_WINDOW_MINUTES", "15")
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+
+	cases := []struct {
+		name    string
+		failure *models.LoginFailure
+		want    time.Duration
+	}{
+		{"no failures", nil, 0},
+		{"within free failures", &models.LoginFailure{Failures: 2, LastFailureAt: now.Add(-time.Second)}, 0},
+		{"backoff", &models.LoginFailure{Failures: 4, LastFailureAt: now.Add(-time.Second)}, time.Second},
+		{"backoff over", &models.LoginFailure{Failures: 4, LastFailureAt: now.Add(-time.Minute)}, 0},
+		{"locked", &models.LoginFailure{Failures: 5, LastFailureAt: now.Add(-time.Minute), LockedUntil: at(10 * time.Minute)}, 10 * time.Minute},
+		{"lockout outlasting the window", &models.LoginFailure{Failures: 5, LastFailureAt: now.Add(-20 * time.Minute), LockedUntil: at(40 * time.Minute)}, 40 * time.Minute},
+		{"lockout over", &models.LoginFailure{Failures: 5, LastFailureAt: now.Add(-20 * time.Minute), LockedUntil: at(-time.Minute)}, 0},
+	}
+	for _, c := range cases {
+		if got := failureWait(c.failure, now); got != c.want {
+			t.Errorf("%s: failureWait = %s, want %s", c.name, got, c.want)
+		}
+	}
+}
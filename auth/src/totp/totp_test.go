@@ -0,0 +1,102 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 appendix B, "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAtRFC6238(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1; the 6 digit code is the last 6 of the 8
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.code[len(tt.code)-Digits:]; got != want {
+			t.Errorf("CodeAt(%d) = %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestCodeAtAcceptsLowercaseSecret(t *testing.T) {
+	upper, _ := CodeAt(rfcSecret, 1)
+	lower, err := CodeAt(" "+strings.ToLower(rfcSecret)+" ", 1)
+	if err != nil || lower != upper {
+		t.Fatalf("CodeAt(lowercase) = %q, %v; want %q", lower, err, upper)
+	}
+	if _, err := CodeAt("not base32!", 1); err == nil {
+		t.Fatal("invalid secret accepted")
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	issued := time.Unix(1111111111, 0)
+	code, err := CodeAt(rfcSecret, Step(issued))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		ok   bool
+	}{
+		{"same step", issued, true},
+		{"one step later", issued.Add(Period), true},
+		{"one step earlier", issued.Add(-Period), true},
+		{"two steps later", issued.Add(2 * Period), false},
+		{"two steps earlier", issued.Add(-2 * Period), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, code, tt.now)
+			if ok != tt.ok {
+				t.Fatalf("Validate at %v = %v, want %v", tt.now, ok, tt.ok)
+			}
+			// The step returned is the code's, not the clock's, so a replay
+			// inside the window is recognised
+			if ok && step != Step(issued) {
+				t.Errorf("step = %d, want %d", step, Step(issued))
+			}
+		})
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := CodeAt(rfcSecret, Step(now))
+
+	if _, ok := Validate(rfcSecret, code[:3]+" "+code[3:], now); !ok {
+		t.Error("code typed with a space rejected")
+	}
+	for _, bad := range []string{"", code[:5], code + "0", "abcdef"} {
+		if _, ok := Validate(rfcSecret, bad, now); ok {
+			t.Errorf("Validate(%q) accepted", bad)
+		}
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("SECRET", "NeuroIQ", "ada@example.edu")
+	for _, part := range []string{"otpauth://totp/NeuroIQ:ada@example.edu?", "secret=SECRET", "issuer=NeuroIQ", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("%s lacks %q", uri, part)
+		}
+	}
+}
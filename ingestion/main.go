@@ -3,6 +3,8 @@ package main
 import (
 	"ingestion/src/config"
 	"ingestion/src/db"
+	"ingestion/src/service"
+	"ingestion/src/storage"
 	"os"
 
 	// "ingestion/src/kafka"
@@ -22,8 +24,10 @@ func main() {
 	// }
 	
 	db.InitDB()
-	config.InitCloudinary()
+	storage.Init()
 	config.UniPdfInit()
+	service.InitOCR()
+	service.StartJobWorkers()
 	// kafka.KafkaInit()
 
 	router := chi.NewRouter()
@@ -32,7 +36,7 @@ func main() {
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Last-Event-ID"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
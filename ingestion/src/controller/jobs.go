@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ingestion/src/db"
+	"ingestion/src/middleware"
+	"ingestion/src/model"
+	"ingestion/src/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// findJob loads a job of the caller's institution; only its uploader and
+// admins may follow it
+func findJob(w http.ResponseWriter, r *http.Request) (*model.Job, bool) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "invalid auth context", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	filter := bson.M{"_id": objID, "institution_id": authCtx.InstitutionID}
+	if authCtx.Role != "admin" {
+		filter["user_id"] = authCtx.UserID
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var job model.Job
+	err = db.GetJobCollection().FindOne(ctx, filter).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return &job, true
+}
+
+// GetJob reports how far an upload has got, with the questions of every unit
+// answered so far
+func GetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := findJob(w, r)
+	if !ok {
+		return
+	}
+
+	progress := map[string]int{"units_total": len(job.Units), "units_done": 0, "units_failed": 0}
+	for _, unit := range job.Units {
+		switch unit.Status {
+		case model.UnitDone:
+			progress["units_done"]++
+		case model.UnitFailed:
+			progress["units_failed"]++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"data":     job,
+		"progress": progress,
+	})
+}
+
+const (
+	// Streams re-read events this often, for events saved by other replicas
+	jobEventPoll = 2 * time.Second
+	// A comment line this often keeps proxies from closing an idle stream
+	jobEventHeartbeat = 15 * time.Second
+)
+
+// StreamJobEvents streams a job's events as Server-Sent Events until the job
+// is done or failed. Every event has its sequence number as ID; a client
+// reconnecting with Last-Event-ID (or ?last_event_id=) gets the events it
+// missed first, and a new client gets the whole history.
+func StreamJobEvents(w http.ResponseWriter, r *http.Request) {
+	job, ok := findJob(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var last int64
+	if lastID != "" {
+		var err error
+		last, err = strconv.ParseInt(lastID, 10, 64)
+		if err != nil || last < 0 {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Subscribe before the first read, so no event falls in between
+	wake, unsubscribe := service.SubscribeJobEvents(job.ID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(jobEventPoll)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(jobEventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		events, err := service.JobEventsAfter(r.Context(), job.ID, last)
+		if err != nil {
+			return
+		}
+		for _, event := range events {
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+			last = event.Seq
+			if event.Type == model.EventDone || event.Type == model.EventFailed {
+				flusher.Flush()
+				return
+			}
+		}
+		flusher.Flush()
+
+		// A job that ended without a final event (e.g. before events were
+		// recorded) has nothing more to send
+		if len(events) == 0 && (job.Status == model.JobDone || job.Status == model.JobFailed) {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wake:
+		case <-poll.C:
+			db.GetJobCollection().FindOne(r.Context(), bson.M{"_id": job.ID}).Decode(job)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"ingestion/src/db"
+	"ingestion/src/dto"
+	"ingestion/src/middleware"
+	"ingestion/src/model"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func decodePrivacySubject(w http.ResponseWriter, r *http.Request) (middleware.AuthContext, dto.PrivacySubject, bool) {
+	var subject dto.PrivacySubject
+
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "invalid auth context", http.StatusUnauthorized)
+		return authCtx, subject, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil || subject.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return authCtx, subject, false
+	}
+
+	return authCtx, subject, true
+}
+
+// ExportUserData returns the material a user uploaded and its ingestion jobs
+func ExportUserData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": subject.UserID, "institution_id": authCtx.InstitutionID}
+
+	materials := []model.Content{}
+	cursor, err := db.GetIngestionCollection().Find(ctx, filter)
+	if err == nil {
+		err = cursor.All(ctx, &materials)
+	}
+	if err != nil {
+		http.Error(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jobs := []model.Job{}
+	cursor, err = db.GetJobCollection().Find(ctx, filter)
+	if err == nil {
+		err = cursor.All(ctx, &jobs)
+	}
+	if err != nil {
+		http.Error(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"materials": materials,
+		"jobs":      jobs,
+	})
+}
+
+// EraseUserData moves a user's uploads to their pseudonym. The material is
+// course content and stays available.
+func EraseUserData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r)
+	if !ok {
+		return
+	}
+	if !strings.HasPrefix(subject.Pseudonym, "erased-") {
+		http.Error(w, "pseudonym is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": subject.UserID, "institution_id": authCtx.InstitutionID}
+	result, err := db.GetIngestionCollection().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"user_id": subject.Pseudonym}})
+	if err != nil {
+		http.Error(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jobs, err := db.GetJobCollection().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"user_id": subject.Pseudonym}})
+	if err != nil {
+		http.Error(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"materials": result.ModifiedCount,
+		"jobs":      jobs.ModifiedCount,
+	})
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"ingestion/src/db"
+	"ingestion/src/middleware"
+	"net/http"
+
+	"neuroiq/shared/tenancy"
+)
+
+// BackfillInstitution assigns syllabus documents uploaded before tenancy to
+// the institution of the calling token
+func BackfillInstitution(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "invalid auth context", http.StatusUnauthorized)
+		return
+	}
+	tenancy.Serve(w, r, authCtx.InstitutionID, db.AssignInstitution)
+}
@@ -1,9 +1,28 @@
 package db
 
-import "go.mongodb.org/mongo-driver/mongo"
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+)
 
 var ingestionCollection *mongo.Collection
+var jobCollection *mongo.Collection
+var jobEventCollection *mongo.Collection
+var uploadBucket *gridfs.Bucket
 
 func GetIngestionCollection() *mongo.Collection{
 	return ingestionCollection
-}
\ No newline at end of file
+}
+
+func GetJobCollection() *mongo.Collection {
+	return jobCollection
+}
+
+func GetJobEventCollection() *mongo.Collection {
+	return jobEventCollection
+}
+
+// GetUploadBucket holds uploaded files until their job is finished
+func GetUploadBucket() *gridfs.Bucket {
+	return uploadBucket
+}
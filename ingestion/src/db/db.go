@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -27,6 +28,15 @@ func InitDB() {
 	}
 	fmt.Println("✅ Connected to MongoDB!")
 
-	ingestionCollection = client.Database("NeuroIQIngestionDB").Collection("Syallabus")
+	database := client.Database("NeuroIQIngestionDB")
+	ingestionCollection = database.Collection("Syallabus")
+	jobCollection = database.Collection("IngestionJobs")
+	jobEventCollection = database.Collection("IngestionJobEvents")
 
-}
\ No newline at end of file
+	uploadBucket, err = gridfs.NewBucket(database, options.GridFSBucket().SetName("uploads"))
+	if err != nil {
+		log.Fatal("❌ GridFS error:", err)
+	}
+
+	ensureIndexes(ctx)
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes are created at startup; CreateMany is a no-op for
+// indexes that already exist with the same keys and options
+func collectionIndexes() map[*mongo.Collection][]mongo.IndexModel {
+	return map[*mongo.Collection][]mongo.IndexModel{
+		ingestionCollection: {
+			{Keys: bson.D{{Key: "institution_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		jobCollection: {
+			// Workers claim unfinished jobs whose lease ran out
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lease_until", Value: 1}}},
+			{Keys: bson.D{{Key: "institution_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		jobEventCollection: {
+			{Keys: bson.D{{Key: "job_id", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// Events are only replayed to reconnecting clients; a week is plenty
+			{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60)},
+		},
+	}
+}
+
+func ensureIndexes(ctx context.Context) {
+	for collection, indexes := range collectionIndexes() {
+		if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+			log.Fatalf("❌ Failed creating %s indexes: %v", collection.Name(), err)
+		}
+	}
+	fmt.Println("✅ Mongo indexes ready")
+}
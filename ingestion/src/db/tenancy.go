@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+
+	"neuroiq/shared/tenancy"
+)
+
+// AssignInstitution gives the syllabus documents owners uploaded before
+// tenancy their institution
+func AssignInstitution(ctx context.Context, institutionID string, owners tenancy.Owners) (tenancy.Result, error) {
+	var result tenancy.Result
+
+	if len(owners.UserIDs) > 0 {
+		res, err := ingestionCollection.UpdateMany(ctx, tenancy.Unassigned("user_id", owners.UserIDs), tenancy.Assign(institutionID))
+		if err != nil {
+			return result, err
+		}
+		result.Assigned = res.ModifiedCount
+	}
+
+	remaining, err := ingestionCollection.CountDocuments(ctx, tenancy.Untagged())
+	if err != nil {
+		return result, err
+	}
+	result.Remaining = remaining
+	return result, nil
+}
@@ -10,9 +10,18 @@ type Claim struct {
 	ID            string
 	Email         string 
 	Role          string 
+	InstitutionID string
+	Scope         string `json:"scope,omitempty"` // service tokens only, space separated
+	Actor         *ActorClaim `json:"act,omitempty"` // set when an admin impersonates the user
 	jwt.RegisteredClaims
 }
 
+// ActorClaim names the admin holding an impersonation token
+type ActorClaim struct {
+	ID    string `json:"sub"`
+	Email string `json:"email,omitempty"`
+}
+
 type TextChunkEvent struct {
 	ChunkID    string    `json:"chunk_id"`
 	Unit       string    `json:"unit"`
@@ -46,4 +55,11 @@ type Question struct {
 type LlmResponse struct {
 	Success 		bool				`json:"success"`
 	Questions		[]Question			`json:"questions"`
-}
\ No newline at end of file
+}
+// PrivacySubject names the user whose data auth exports or erases;
+// pseudonym replaces their ID on erasure
+type PrivacySubject struct {
+	UserID    string `json:"user_id"`
+	StudentID string `json:"student_id"`
+	Pseudonym string `json:"pseudonym"`
+}
@@ -6,21 +6,39 @@ import (
 	"context"
 	"net/http"
 	"strings"
+
+	"neuroiq/shared/authctx"
 )
 
 
+// AuthContext is the caller stored under AuthKey, with the claims of its token
 type AuthContext struct {
-	UserID string
-	Email  string
-	Role   string
+	authctx.Context
 	Claims *dto.Claim
 }
+
 type contextKey string
 
 const AuthKey contextKey = "auth_context"
 
 
+// AuthMiddleware admits signed-in users; service tokens are refused
 func AuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, func(a AuthContext) bool {
+		return !a.Service
+	})
+}
+
+// ServiceMiddleware admits service tokens granted scope and nothing else
+func ServiceMiddleware(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authenticate(next, func(a AuthContext) bool {
+			return a.Service && a.HasScope(scope)
+		})
+	}
+}
+
+func authenticate(next http.Handler, allowed func(a AuthContext) bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		authHeader := r.Header.Get("Authorization")
@@ -43,14 +61,34 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Every query is scoped to the caller's institution, so a token without one is useless
+		if claims.InstitutionID == "" {
+			http.Error(w, "token has no institution", http.StatusUnauthorized)
+			return
+		}
+
 		// Store multiple values in context
 		authCtx := AuthContext{
-			UserID: claims.ID,
-			Email:  claims.Email,
-			Role:   claims.Role,
+			Context: authctx.Context{
+				UserID:        claims.ID,
+				Email:         claims.Email,
+				Role:          claims.Role,
+				InstitutionID: claims.InstitutionID,
+				Service:       claims.Role == "service",
+				Scopes:        strings.Fields(claims.Scope),
+				ActorID:       actorID(claims),
+			},
 			Claims: claims,
 		}
 
+		if !allowed(authCtx) {
+			http.Error(w, "Not Authorized for the service", http.StatusUnauthorized)
+			return
+		}
+		if !allowImpersonation(w, r, authCtx, tokenString) {
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), AuthKey, authCtx)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
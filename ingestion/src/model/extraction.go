@@ -0,0 +1,19 @@
+package model
+
+// Extraction records how the text of an upload was read. It is only set for
+// PDFs, the one format whose pages may be scanned images.
+type Extraction struct {
+	Pages    int       `bson:"pages" json:"pages"`
+	OCRPages []OCRPage `bson:"ocr_pages,omitempty" json:"ocr_pages,omitempty"`
+	// Mean confidence of the OCR'd pages, weighted by their length, 0-100
+	OCRConfidence float64  `bson:"ocr_confidence,omitempty" json:"ocr_confidence,omitempty"`
+	Warnings      []string `bson:"warnings,omitempty" json:"warnings,omitempty"`
+}
+
+// OCRPage is a page that had no text layer and was read by OCR
+type OCRPage struct {
+	Page       int     `bson:"page" json:"page"` // 1-based
+	Confidence float64 `bson:"confidence" json:"confidence"`
+	Characters int     `bson:"characters" json:"characters"`
+	Error      string  `bson:"error,omitempty" json:"error,omitempty"`
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"ingestion/src/dto"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job states, in the order a job normally goes through them
+const (
+	JobQueued     = "queued"
+	JobExtracting = "extracting"
+	JobGenerating = "generating"
+	JobStoring    = "storing"
+	JobDone       = "done"
+	JobFailed     = "failed"
+)
+
+// Unit states within a job
+const (
+	UnitPending = "pending"
+	UnitDone    = "done"
+	UnitFailed  = "failed"
+)
+
+// Job is one uploaded syllabus on its way to becoming a Content. The upload
+// itself waits in GridFS (FileID) until the job finishes, so a worker can
+// pick the job up again after a restart.
+type Job struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+
+	Status string `bson:"status" json:"status"`
+	Error  string `bson:"error,omitempty" json:"error,omitempty"`
+
+	Subject    string `bson:"subject" json:"subject"`
+	Branch     string `bson:"branch,omitempty" json:"branch,omitempty"`
+	Semester   string `bson:"semester,omitempty" json:"semester,omitempty"`
+	Section    string `bson:"section,omitempty" json:"section,omitempty"`
+	Role       string `bson:"role" json:"role"`
+	Num3Marks  int    `bson:"num_3marks" json:"num_3marks"`
+	Num4Marks  int    `bson:"num_4marks" json:"num_4marks"`
+	Num10Marks int    `bson:"num_10marks" json:"num_10marks"`
+
+	UserID        string `bson:"user_id" json:"user_id"`
+	InstitutionID string `bson:"institution_id" json:"institution_id"`
+
+	FileName    string             `bson:"file_name" json:"file_name"`
+	ContentType string             `bson:"content_type,omitempty" json:"content_type,omitempty"` // sniffed from the file; empty means PDF
+	FileID      primitive.ObjectID `bson:"file_id" json:"-"`
+
+	Units      []JobUnit           `bson:"units" json:"units"`
+	Extraction *Extraction         `bson:"extraction,omitempty" json:"extraction,omitempty"` // how the text was read; warns of poor OCR
+	ContentID  *primitive.ObjectID `bson:"content_id,omitempty" json:"content_id,omitempty"` // chosen before the insert, so a retried insert cannot duplicate
+	FileKey    string              `bson:"file_key,omitempty" json:"-"`                      // set once the file is in the BlobStore
+
+	// A worker owns the job while LeaseUntil is in the future; LeaseOwner
+	// names its claim and every write of the worker is conditioned on it.
+	// Attempts counts claims.
+	Attempts   int        `bson:"attempts" json:"attempts"`
+	EventSeq   int64      `bson:"event_seq" json:"-"` // last JobEvent.Seq
+	LeaseOwner string     `bson:"lease_owner,omitempty" json:"-"`
+	LeaseUntil time.Time  `bson:"lease_until" json:"-"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// JobUnit is one unit of the syllabus and the questions generated for it
+type JobUnit struct {
+	Unit      string         `bson:"unit" json:"unit"`
+	Content   string         `bson:"content" json:"-"`
+	Status    string         `bson:"status" json:"status"`
+	Questions []dto.Question `bson:"questions,omitempty" json:"questions,omitempty"`
+	Error     string         `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// Job event types, streamed to the uploader as the job progresses
+const (
+	EventStatus         = "status"
+	EventUnitExtracted  = "unit_extracted"
+	EventUnitGenerating = "unit_generating"
+	EventUnitQuestions  = "unit_questions"
+	EventUnitFailed     = "unit_failed"
+	EventDone           = "done"
+	EventFailed         = "failed"
+)
+
+// JobEvent is one step of a job. Seq numbers the events of a job from 1 and
+// is the SSE event ID, so a reconnecting client resumes after the last one
+// it saw.
+type JobEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	JobID     primitive.ObjectID `bson:"job_id" json:"job_id"`
+	Seq       int64              `bson:"seq" json:"seq"`
+	Type      string             `bson:"type" json:"type"`
+	Data      interface{}        `bson:"data" json:"data"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
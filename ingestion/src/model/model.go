@@ -16,9 +16,18 @@ type Content struct {
 
 	// New Fields
 	UserID    string 				`bson:"user_id" json:"user_id"`
+	InstitutionID string 			`bson:"institution_id" json:"institution_id"`
 	Role      string             	`bson:"role" json:"role"`
 
-	PDFUrl    string 				`bson:"pdfurl" json:"pdfurl"`
+	PDFUrl    string 				`bson:"pdfurl" json:"pdfurl"` // public Cloudinary URL of older uploads; a signed URL when read
+
+	// Object in the configured BlobStore, named by the hash of its content
+	FileKey     string				`bson:"file_key,omitempty" json:"-"`
+	FileName    string				`bson:"file_name,omitempty" json:"file_name,omitempty"`
+	ContentType string				`bson:"content_type,omitempty" json:"content_type,omitempty"`
+
+	// Pages read by OCR and their confidence, for PDFs
+	Extraction  *Extraction			`bson:"extraction,omitempty" json:"extraction,omitempty"`
 
 	CreatedAt time.Time          	`bson:"created_at" json:"created_at"`
 }
@@ -3,6 +3,7 @@ package routes
 import (
 	"ingestion/src/controller"
 	"ingestion/src/middleware"
+	"neuroiq/shared/authctx"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -17,8 +18,22 @@ func SetupIngestionRoutes() chi.Router {
 		r.Post("/upload" , controller.UploadMaterial)
 		r.Get("/get/{id}" , controller.GetMaterialByID )
 		r.Get("/get" , controller.GetMaterialByUserID)
+		r.Get("/jobs/{id}" , controller.GetJob)
+		r.Get("/jobs/{id}/events" , controller.StreamJobEvents)
+		r.Get("/material/{id}/file" , controller.GetMaterialFile)
+		r.Get("/material/{id}/download-url" , controller.GetMaterialDownloadURL)
 	}) 
 
+	// Signed links of the local storage backend carry no token
+	router.Get("/blobs/*" , controller.ServeSignedBlob)
+
+	// Called by auth to export or erase a user's data
+	router.With(middleware.ServiceMiddleware(authctx.ScopePrivacyExport)).Post("/internal/privacy/export" , controller.ExportUserData)
+	router.With(middleware.ServiceMiddleware(authctx.ScopePrivacyErase)).Post("/internal/privacy/erase" , controller.EraseUserData)
+
+	// Called by auth to assign records written before tenancy
+	router.With(middleware.ServiceMiddleware(authctx.ScopeTenancyBackfill)).Post("/internal/tenancy/backfill" , controller.BackfillInstitution)
+
 
 
 	return router
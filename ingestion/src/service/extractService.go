@@ -0,0 +1,161 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"ingestion/src/model"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// MIME types of the material formats text can be extracted from
+const (
+	MimePDF      = "application/pdf"
+	MimeDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimePPTX     = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	MimeODT      = "application/vnd.oasis.opendocument.text"
+	MimeMarkdown = "text/markdown"
+	MimeHTML     = "text/html"
+	MimeText     = "text/plain"
+)
+
+// Extractor turns an uploaded file into plain text for utils.CleanText. The
+// Extraction says how the text was read, for formats where that varies.
+type Extractor func(ctx context.Context, data []byte) (string, *model.Extraction, error)
+
+// textExtractor adapts an extractor that always reads the text directly
+func textExtractor(extract func(data []byte) (string, error)) Extractor {
+	return func(ctx context.Context, data []byte) (string, *model.Extraction, error) {
+		text, err := extract(data)
+		return text, nil, err
+	}
+}
+
+type materialFormat struct {
+	ext     string // extension of the stored file
+	extract Extractor
+}
+
+// materialFormats is keyed by the type DetectContentType reports
+var materialFormats = map[string]materialFormat{
+	MimePDF:      {".pdf", ExtractPdfFromBytes},
+	MimeDOCX:     {".docx", textExtractor(extractDocx)},
+	MimePPTX:     {".pptx", textExtractor(extractPptx)},
+	MimeODT:      {".odt", textExtractor(extractOdt)},
+	MimeMarkdown: {".md", textExtractor(extractMarkdown)},
+	MimeHTML:     {".html", textExtractor(extractHTML)},
+	MimeText:     {".txt", textExtractor(extractPlainText)},
+}
+
+// ErrUnsupportedType is returned for files no extractor handles
+var ErrUnsupportedType = errors.New("unsupported file type")
+
+var (
+	reMarkdownHeading = regexp.MustCompile(`(?m)^#{1,6}[ \t]+\S`)
+	reMarkdownLink    = regexp.MustCompile(`!?\[[^\]\n]*\]\([^)\s]+\)`)
+	reMarkdownFence   = regexp.MustCompile("(?m)^[ \t]*(```|~~~)")
+	reMarkdownStrong  = regexp.MustCompile(`(\*\*|__)\S[^\n]*?(\*\*|__)`)
+)
+
+// DetectContentType sniffs the type of an upload from its bytes; the file
+// name and the type the browser sent are not trusted. Office documents are
+// told apart by the parts of their zip, and Markdown from plain text by its
+// syntax.
+func DetectContentType(data []byte) string {
+	mediaType, params, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	switch mediaType {
+	case "application/zip":
+		return detectZipType(data)
+	case MimeText:
+		if charset := strings.ToLower(params["charset"]); charset != "utf-8" {
+			// Only UTF-8 text is supported
+			return MimeText + "; charset=" + charset
+		}
+		if looksLikeMarkdown(data) {
+			return MimeMarkdown
+		}
+	}
+	return mediaType
+}
+
+func detectZipType(data []byte) string {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "application/zip"
+	}
+
+	for _, f := range archive.File {
+		switch f.Name {
+		case "mimetype":
+			// OpenDocument files start with their type, stored uncompressed
+			if mimetype, err := readZipFile(f); err == nil {
+				return strings.TrimSpace(string(mimetype))
+			}
+		case "word/document.xml":
+			return MimeDOCX
+		case "ppt/presentation.xml":
+			return MimePPTX
+		}
+	}
+	return "application/zip"
+}
+
+func looksLikeMarkdown(data []byte) bool {
+	return reMarkdownHeading.Match(data) || reMarkdownFence.Match(data) ||
+		reMarkdownLink.Match(data) || reMarkdownStrong.Match(data)
+}
+
+// SupportedContentType reports whether an extractor handles contentType
+func SupportedContentType(contentType string) bool {
+	_, ok := materialFormats[contentType]
+	return ok
+}
+
+// SupportedContentTypes lists the types uploads may have
+func SupportedContentTypes() []string {
+	return []string{MimePDF, MimeDOCX, MimePPTX, MimeODT, MimeMarkdown, MimeHTML, MimeText}
+}
+
+// FileExtension is the extension files of contentType are stored with
+func FileExtension(contentType string) string {
+	return materialFormats[contentType].ext
+}
+
+// ExtractText extracts the text of a file of the given type
+func ExtractText(ctx context.Context, contentType string, data []byte) (string, *model.Extraction, error) {
+	format, ok := materialFormats[contentType]
+	if !ok {
+		return "", nil, ErrUnsupportedType
+	}
+	return format.extract(ctx, data)
+}
+
+// maxZipPart bounds how much of one zip entry is read, so a small upload
+// cannot inflate into gigabytes
+const maxZipPart = 64 << 20
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipPart+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipPart {
+		return nil, errors.New(f.Name + " is too large")
+	}
+	return data, nil
+}
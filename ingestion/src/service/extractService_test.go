@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"pdf", []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n"), MimePDF},
+		{"docx", docxFixture(t), MimeDOCX},
+		{"pptx", pptxFixture(t), MimePPTX},
+		{"odt", odtFixture(t), MimeODT},
+		{"other zip", buildZip(t, zipPart{"notes.txt", "hello"}), "application/zip"},
+		{"markdown heading", []byte("# Cells\n\nThe cell is the unit of life.\n"), MimeMarkdown},
+		{"markdown link", []byte("See [the notes](https://example.com/notes) first.\n"), MimeMarkdown},
+		{"plain text", []byte("The cell is the unit of life.\n#biology is a hashtag, not a heading\n"), MimeText},
+		{"html", []byte("<!DOCTYPE html><html><body><p>Cells</p></body></html>"), MimeHTML},
+		{"utf-16 text", []byte("\xff\xfeC\x00e\x00l\x00l\x00"), MimeText + "; charset=utf-16le"},
+		{"binary", []byte{0x00, 0x01, 0x02, 0x03}, "application/octet-stream"},
+	}
+	for _, c := range cases {
+		if got := DetectContentType(c.data); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestTextExtractors(t *testing.T) {
+	cases := []struct {
+		name    string
+		extract func([]byte) (string, error)
+		data    string
+		want    string
+	}{
+		{"text", extractPlainText, "\ufeffline one\r\nline two\n", "line one\nline two\n"},
+		{
+			"markdown", extractMarkdown,
+			"# Cells #\n\n> The **nucleus** holds [DNA](https://example.com/dna) and `RNA`.\n\n```go\nx := 1\n```\n\n| Part | Role |\n|------|------|\n| wall | shape |\n",
+			"Cells\n\nThe nucleus holds DNA and RNA.\n\n\nx := 1\n\n\n Part Role \n\n wall shape \n",
+		},
+		{
+			"html", extractHTML,
+			"<html><head><title>Notes</title></head><body><!-- draft --><h1>Cells</h1><p>DNA &amp; RNA</p><script>track()</script></body></html>",
+			"\nCells\n\nDNA & RNA\n",
+		},
+	}
+	for _, c := range cases {
+		got, err := c.extract([]byte(c.data))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s:\n got %q\nwant %q", c.name, got, c.want)
+		}
+	}
+
+	for _, extract := range []func([]byte) (string, error){extractPlainText, extractMarkdown, extractHTML} {
+		if _, err := extract([]byte("caf\xe9")); err == nil {
+			t.Error("Latin-1 bytes: no error")
+		}
+	}
+}
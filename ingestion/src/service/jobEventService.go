@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"ingestion/src/db"
+	"ingestion/src/model"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Job events are saved to Mongo, so a client that reconnects (or is served by
+// another replica) can replay what it missed. Streams on this replica are
+// woken as soon as an event is saved; others notice on their next poll.
+var (
+	// emitMu keeps events in Seq order: a reader that has seen Seq n never
+	// finds n-1 appearing later
+	emitMu sync.Mutex
+
+	subscribersMu sync.Mutex
+	subscribers   = map[primitive.ObjectID]map[chan struct{}]bool{}
+)
+
+// emitJobEvent records an event of a job held by this worker. Failures are
+// only logged; the job's own state is what matters.
+func emitJobEvent(owned *model.Job, eventType string, data bson.M) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	emitMu.Lock()
+	defer emitMu.Unlock()
+
+	jobID := owned.ID
+	var job model.Job
+	err := db.GetJobCollection().FindOneAndUpdate(ctx,
+		leaseFilter(owned),
+		bson.M{"$inc": bson.M{"event_seq": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"event_seq": 1}),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return // the job has a new owner, which reports its own progress
+	}
+	if err != nil {
+		log.Printf("❌ failed to number event of ingestion job %s: %v", jobID.Hex(), err)
+		return
+	}
+
+	event := model.JobEvent{
+		JobID:     jobID,
+		Seq:       job.EventSeq,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	if _, err := db.GetJobEventCollection().InsertOne(ctx, event); err != nil {
+		log.Printf("❌ failed to save event of ingestion job %s: %v", jobID.Hex(), err)
+		return
+	}
+
+	subscribersMu.Lock()
+	for ch := range subscribers[jobID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	subscribersMu.Unlock()
+}
+
+// SubscribeJobEvents returns a channel that is signalled when this replica
+// saves an event of the job, and a function to stop listening
+func SubscribeJobEvents(jobID primitive.ObjectID) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	subscribersMu.Lock()
+	if subscribers[jobID] == nil {
+		subscribers[jobID] = map[chan struct{}]bool{}
+	}
+	subscribers[jobID][ch] = true
+	subscribersMu.Unlock()
+
+	return ch, func() {
+		subscribersMu.Lock()
+		delete(subscribers[jobID], ch)
+		if len(subscribers[jobID]) == 0 {
+			delete(subscribers, jobID)
+		}
+		subscribersMu.Unlock()
+	}
+}
+
+// JobEventsAfter returns the events of a job after seq, oldest first
+func JobEventsAfter(ctx context.Context, jobID primitive.ObjectID, seq int64) ([]model.JobEvent, error) {
+	events := []model.JobEvent{}
+
+	cursor, err := db.GetJobEventCollection().Find(ctx,
+		bson.M{"job_id": jobID, "seq": bson.M{"$gt": seq}},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &events)
+	return events, err
+}
@@ -0,0 +1,527 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"ingestion/src/db"
+	"ingestion/src/dto"
+	"ingestion/src/model"
+	"ingestion/src/storage"
+	"ingestion/src/utils"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Uploads are processed by a pool of workers that claim jobs from Mongo with
+// a lease. A worker keeps extending the lease while it works; if it dies, the
+// lease runs out and any worker (after a restart, or on another replica)
+// carries on from the last saved stage. Units already answered by the LLM are
+// not asked again. A worker that stalled past its lease finds its writes no
+// longer match the job's lease_owner and stops.
+const (
+	jobLease        = 2 * time.Minute
+	jobPollInterval = 5 * time.Second
+	jobRetryDelay   = time.Minute // before a job that hit a transient error is claimed again
+	maxJobAttempts  = 3
+	unitAttempts    = 3 // LLM calls per unit before the unit is marked failed
+)
+
+var unfinishedJobStates = []string{model.JobQueued, model.JobExtracting, model.JobGenerating, model.JobStoring}
+
+// jobWake lets a new upload start at once instead of at the next poll
+var jobWake = make(chan struct{}, 1)
+
+// errLeaseLost means another worker has claimed the job since
+var errLeaseLost = errors.New("lease lost to another worker")
+
+// permanentError fails the job; any other error is retried later
+type permanentError struct {
+	msg string
+}
+
+func (e *permanentError) Error() string {
+	return e.msg
+}
+
+// EnqueueJob saves the uploaded file and its job, and wakes a worker
+func EnqueueJob(ctx context.Context, job *model.Job, file []byte) error {
+	fileID := primitive.NewObjectID()
+	if err := db.GetUploadBucket().UploadFromStreamWithID(fileID, job.FileName, bytes.NewReader(file)); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	job.ID = primitive.NewObjectID()
+	job.FileID = fileID
+	job.Status = model.JobQueued
+	job.Units = []model.JobUnit{}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	if _, err := db.GetJobCollection().InsertOne(ctx, job); err != nil {
+		db.GetUploadBucket().Delete(fileID)
+		return err
+	}
+
+	select {
+	case jobWake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// StartJobWorkers starts INGESTION_WORKERS (default 2) workers; unfinished
+// jobs left by a previous run are picked up once their lease has expired
+func StartJobWorkers() {
+	workers, err := strconv.Atoi(os.Getenv("INGESTION_WORKERS"))
+	if err != nil || workers <= 0 {
+		workers = 2
+	}
+	for i := 0; i < workers; i++ {
+		go jobWorker()
+	}
+	log.Printf("✅ %d ingestion workers started", workers)
+}
+
+func jobWorker() {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			job, err := claimJob()
+			if err != nil {
+				log.Printf("❌ failed to claim ingestion job: %v", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			runJob(job)
+		}
+
+		select {
+		case <-jobWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimJob leases the oldest unfinished job nobody holds, or returns nil
+func claimJob() (*model.Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"status":      bson.M{"$in": unfinishedJobStates},
+		"lease_until": bson.M{"$lt": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"lease_owner": primitive.NewObjectID().Hex(), "lease_until": now.Add(jobLease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job model.Job
+	err := db.GetJobCollection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// runJob takes a claimed job through its remaining stages
+func runJob(job *model.Job) {
+	if job.Attempts > maxJobAttempts {
+		failJob(job, fmt.Sprintf("gave up after %d attempts", maxJobAttempts))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaseDone := make(chan struct{})
+	go func() {
+		defer close(leaseDone)
+		keepLease(ctx, cancel, job)
+	}()
+	// stopLease returns once keepLease has made its last write
+	stopLease := func() {
+		cancel()
+		<-leaseDone
+	}
+	defer stopLease()
+
+	for {
+		var err error
+		switch job.Status {
+		case model.JobQueued, model.JobExtracting:
+			err = extractJob(ctx, job)
+		case model.JobGenerating:
+			err = generateJob(ctx, job)
+		case model.JobStoring:
+			err = storeJob(ctx, job)
+		default:
+			return
+		}
+
+		var permanent *permanentError
+		if errors.Is(err, errLeaseLost) || (err != nil && ctx.Err() != nil) {
+			log.Printf("⚠️ ingestion job %s: %v, leaving it to its new owner", job.ID.Hex(), errLeaseLost)
+			return
+		}
+		if errors.As(err, &permanent) {
+			failJob(job, permanent.msg)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ ingestion job %s: %v (attempt %d)", job.ID.Hex(), err, job.Attempts)
+			// Stop extending first, or the next extension would undo the delay
+			stopLease()
+			updateJob(job, bson.M{"lease_until": time.Now().Add(jobRetryDelay), "error": err.Error()})
+			return
+		}
+	}
+}
+
+// keepLease extends the job's lease until ctx is done. If another worker
+// has claimed the job it cancels ctx, so this worker stops.
+func keepLease(ctx context.Context, cancel context.CancelFunc, job *model.Job) {
+	ticker := time.NewTicker(jobLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := updateJob(job, bson.M{"lease_until": time.Now().Add(jobLease)}); errors.Is(err, errLeaseLost) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// jobContentType is the type of the job's file; jobs queued before other
+// formats were accepted have none and are PDFs
+func jobContentType(job *model.Job) string {
+	if job.ContentType == "" {
+		return MimePDF
+	}
+	return job.ContentType
+}
+
+// extractJob turns the uploaded file into units
+func extractJob(ctx context.Context, job *model.Job) error {
+	if err := setJobStatus(job, model.JobExtracting, nil); err != nil {
+		return err
+	}
+
+	var file bytes.Buffer
+	if _, err := db.GetUploadBucket().DownloadToStream(job.FileID, &file); err != nil {
+		return err
+	}
+
+	rawText, extraction, err := ExtractText(ctx, jobContentType(job), file.Bytes())
+	if err != nil {
+		return &permanentError{"Unable to get text from file: " + err.Error()}
+	}
+	if extraction != nil {
+		for _, warning := range extraction.Warnings {
+			log.Printf("⚠️ ingestion job %s: %s", job.ID.Hex(), warning)
+		}
+	}
+
+	chunks := utils.SplitByUnits(utils.CleanText(rawText))
+	units := make([]model.JobUnit, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Content == "" {
+			continue
+		}
+		units = append(units, model.JobUnit{Unit: chunk.Unit, Content: chunk.Content, Status: model.UnitPending})
+	}
+	if len(units) == 0 {
+		if extraction != nil && len(extraction.Warnings) > 0 {
+			return &permanentError{"No text found in the file: " + strings.Join(extraction.Warnings, "; ")}
+		}
+		return &permanentError{"No text found in the file"}
+	}
+
+	job.Units = units
+	job.Extraction = extraction
+	if err := updateJob(job, bson.M{"units": units, "extraction": extraction}); err != nil {
+		return err
+	}
+	for i, unit := range units {
+		emitJobEvent(job, model.EventUnitExtracted, bson.M{"index": i, "unit": unit.Unit, "characters": len(unit.Content)})
+	}
+	return setJobStatus(job, model.JobGenerating, nil)
+}
+
+// generateJob asks the LLM for the questions of every unit not answered yet.
+// A failed unit is kept with its error; if every unit failed, the job is
+// retried later while the LLM service was unreachable or failing, and fails
+// when it rejected the units. Losing the lease stops the remaining units.
+func generateJob(ctx context.Context, job *model.Job) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr, unavailableErr, writeErr error
+
+	// saveUnit records a unit's outcome; a lost lease stops the other units
+	saveUnit := func(set bson.M) bool {
+		err := updateJob(job, set)
+		if err == nil {
+			return true
+		}
+		mu.Lock()
+		if writeErr == nil || errors.Is(err, errLeaseLost) {
+			writeErr = err
+		}
+		mu.Unlock()
+		if errors.Is(err, errLeaseLost) {
+			cancel()
+		}
+		return false
+	}
+
+	for i := range job.Units {
+		if job.Units[i].Status == model.UnitDone {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			unit := &job.Units[i]
+			questions, err := generateUnit(ctx, job, i)
+			if ctx.Err() != nil {
+				return
+			}
+
+			prefix := fmt.Sprintf("units.%d.", i)
+			if err != nil {
+				log.Printf("❌ ingestion job %s, %s: %v", job.ID.Hex(), unit.Unit, err)
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				if unavailableErr == nil && llmUnavailable(err) {
+					unavailableErr = err
+				}
+				mu.Unlock()
+				unit.Status = model.UnitFailed
+				unit.Error = err.Error()
+				if saveUnit(bson.M{prefix + "status": unit.Status, prefix + "error": unit.Error}) {
+					emitJobEvent(job, model.EventUnitFailed, bson.M{"index": i, "unit": unit.Unit, "error": unit.Error})
+				}
+				return
+			}
+			unit.Status = model.UnitDone
+			unit.Questions = questions
+			unit.Error = ""
+			if saveUnit(bson.M{prefix + "status": unit.Status, prefix + "questions": questions, prefix + "error": ""}) {
+				emitJobEvent(job, model.EventUnitQuestions, bson.M{"index": i, "unit": unit.Unit, "questions": questions})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// A unit not saved would be lost if the job moved on
+	if writeErr != nil {
+		return writeErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, unit := range job.Units {
+		if unit.Status == model.UnitDone {
+			return setJobStatus(job, model.JobStoring, nil)
+		}
+	}
+	if unavailableErr != nil {
+		return fmt.Errorf("failed to generate questions: %w", unavailableErr)
+	}
+	return &permanentError{"failed to generate questions: " + firstErr.Error()}
+}
+
+// generateUnit calls the LLM for one unit, retrying a few times
+func generateUnit(ctx context.Context, job *model.Job, index int) ([]dto.Question, error) {
+	unit := job.Units[index]
+	llmRequest := dto.LlmRequestBody{
+		Subject:      job.Subject,
+		Semester:     job.Semester,
+		UnitSyllabus: unit.Content,
+		Num3Marks:    job.Num3Marks,
+		Num4Marks:    job.Num4Marks,
+		Num10Marks:   job.Num10Marks,
+	}
+
+	var err error
+	for attempt := 1; attempt <= unitAttempts; attempt++ {
+		emitJobEvent(job, model.EventUnitGenerating, bson.M{"index": index, "unit": unit.Unit, "attempt": attempt})
+
+		var resp *dto.LlmResponse
+		resp, err = GenerateQuestions(ctx, llmRequest)
+		if err == nil {
+			return resp.Questions, nil
+		}
+		if attempt < unitAttempts {
+			select {
+			case <-time.After(time.Duration(attempt) * 2 * time.Second):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return nil, err
+}
+
+// storeJob puts the file in the BlobStore and saves the material. Each step is recorded, so
+// a retry skips what is already done.
+func storeJob(ctx context.Context, job *model.Job) error {
+	if job.ContentID == nil {
+		contentID := primitive.NewObjectID()
+		if err := updateJob(job, bson.M{"content_id": contentID}); err != nil {
+			return err
+		}
+		job.ContentID = &contentID
+	}
+
+	if job.FileKey == "" {
+		var file bytes.Buffer
+		if _, err := db.GetUploadBucket().DownloadToStream(job.FileID, &file); err != nil {
+			return err
+		}
+		contentType := jobContentType(job)
+		key := storage.ContentKey("materials", file.Bytes(), FileExtension(contentType))
+		if err := storage.Store().Put(ctx, key, file.Bytes(), storage.SafeContentType(contentType)); err != nil {
+			return fmt.Errorf("failed storing file: %w", err)
+		}
+		if err := updateJob(job, bson.M{"file_key": key}); err != nil {
+			return err
+		}
+		job.FileKey = key
+	}
+
+	unitsContent := make([]dto.UnitChunk, len(job.Units))
+	for i, unit := range job.Units {
+		unitsContent[i] = dto.UnitChunk{Unit: unit.Unit, Content: unit.Content}
+	}
+	doc := model.Content{
+		ID:            *job.ContentID,
+		Subject:       job.Subject,
+		Content:       unitsContent,
+		UserID:        job.UserID,
+		InstitutionID: job.InstitutionID,
+		Role:          job.Role,
+		FileKey:       job.FileKey,
+		FileName:      job.FileName,
+		ContentType:   jobContentType(job),
+		Extraction:    job.Extraction,
+		CreatedAt:     time.Now(),
+	}
+	if _, err := db.GetIngestionCollection().InsertOne(ctx, doc); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	now := time.Now()
+	if err := setJobStatus(job, model.JobDone, bson.M{"finished_at": now, "error": ""}); err != nil {
+		return err
+	}
+	deleteUpload(job)
+
+	unitsDone := 0
+	for _, unit := range job.Units {
+		if unit.Status == model.UnitDone {
+			unitsDone++
+		}
+	}
+	done := bson.M{
+		"content_id":   job.ContentID,
+		"file_url":     "/api/ingestion/material/" + job.ContentID.Hex() + "/file",
+		"units_done":   unitsDone,
+		"units_failed": len(job.Units) - unitsDone,
+	}
+	if job.Extraction != nil && len(job.Extraction.Warnings) > 0 {
+		done["warnings"] = job.Extraction.Warnings
+	}
+	emitJobEvent(job, model.EventDone, done)
+	return nil
+}
+
+// failJob ends the job with reason, keeping the units it got through
+func failJob(job *model.Job, reason string) {
+	log.Printf("❌ ingestion job %s failed: %s", job.ID.Hex(), reason)
+	if err := setJobStatus(job, model.JobFailed, bson.M{"error": reason, "finished_at": time.Now()}); err != nil {
+		log.Printf("❌ failed to mark ingestion job %s failed: %v", job.ID.Hex(), err)
+		return
+	}
+	deleteUpload(job)
+	emitJobEvent(job, model.EventFailed, bson.M{"error": reason})
+}
+
+func setJobStatus(job *model.Job, status string, set bson.M) error {
+	if set == nil {
+		set = bson.M{}
+	}
+	set["status"] = status
+	if err := updateJob(job, set); err != nil {
+		return err
+	}
+	if job.Status != status {
+		emitJobEvent(job, model.EventStatus, bson.M{"status": status})
+	}
+	job.Status = status
+	return nil
+}
+
+// updateJob writes to the job as long as this worker still holds its lease
+func updateJob(job *model.Job, set bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set["updated_at"] = time.Now()
+	result, err := db.GetJobCollection().UpdateOne(ctx, leaseFilter(job), bson.M{"$set": set})
+	if err != nil {
+		log.Printf("❌ failed to update ingestion job %s: %v", job.ID.Hex(), err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errLeaseLost
+	}
+	return nil
+}
+
+// leaseFilter matches the job while the claim that loaded it is current
+func leaseFilter(job *model.Job) bson.M {
+	return bson.M{"_id": job.ID, "lease_owner": job.LeaseOwner}
+}
+
+// deleteUpload drops the GridFS copy of a finished job's file
+func deleteUpload(job *model.Job) {
+	if err := db.GetUploadBucket().Delete(job.FileID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		log.Printf("❌ failed to delete upload of ingestion job %s: %v", job.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ingestion/src/dto"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// A unit's questions usually take well under a minute; the timeout only
+// stops a hung LLM call from holding a worker forever
+var llmClient = &http.Client{Timeout: 5 * time.Minute}
+
+// llmStatusError is a non-2xx answer of the LLM service
+type llmStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *llmStatusError) Error() string {
+	return fmt.Sprintf("llm service error | status=%d | response=%s", e.StatusCode, e.Body)
+}
+
+// llmUnavailable reports whether a GenerateQuestions error may go away by
+// itself: the LLM service could not be reached or answered 5xx or 429. Any
+// other answer means it rejected the unit, which asking again will not fix.
+func llmUnavailable(err error) bool {
+	var status *llmStatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= 500 || status.StatusCode == http.StatusTooManyRequests
+	}
+	var transport *url.Error
+	return errors.As(err, &transport)
+}
+
+// GenerateQuestions asks the LLM service for the questions of one unit
+func GenerateQuestions(ctx context.Context, llmRequest dto.LlmRequestBody) (*dto.LlmResponse, error) {
+	jsonBody, err := json.Marshal(&llmRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	llmEndPoint := os.Getenv("LLM_URI") + "/generate-questions"
+	req, err := http.NewRequestWithContext(ctx, "POST", llmEndPoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := llmClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("llm service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &llmStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var llmresponse dto.LlmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&llmresponse); err != nil {
+		return nil, fmt.Errorf("decode error: %w", err)
+	}
+	return &llmresponse, nil
+}
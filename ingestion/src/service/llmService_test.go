@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ingestion/src/dto"
+)
+
+func TestLLMUnavailable(t *testing.T) {
+	cases := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusUnprocessableEntity, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "no", c.status)
+		}))
+		t.Setenv("LLM_URI", server.URL)
+
+		_, err := GenerateQuestions(context.Background(), dto.LlmRequestBody{})
+		server.Close()
+		if err == nil {
+			t.Fatalf("status %d: no error", c.status)
+		}
+		if got := llmUnavailable(err); got != c.want {
+			t.Errorf("status %d: llmUnavailable = %v, want %v", c.status, got, c.want)
+		}
+	}
+
+	// Nothing listens once the server is closed
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	t.Setenv("LLM_URI", server.URL)
+	if _, err := GenerateQuestions(context.Background(), dto.LlmRequestBody{}); !llmUnavailable(err) {
+		t.Errorf("unreachable service: llmUnavailable(%v) = false", err)
+	}
+
+	if llmUnavailable(errors.New("decode error")) {
+		t.Error("a malformed answer counted as unavailable")
+	}
+}
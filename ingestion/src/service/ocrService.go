@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// OCREngine reads the text of a rendered page
+type OCREngine interface {
+	Recognize(ctx context.Context, img image.Image) (OCRText, error)
+}
+
+// OCRText is what an engine read, with its confidence from 0 to 100
+type OCRText struct {
+	Text       string
+	Confidence float64
+}
+
+// ocrEngine is nil when OCR is off; scanned pages are then skipped with a warning
+var ocrEngine OCREngine
+
+// InitOCR picks the engine named by OCR_ENGINE: tesseract (default) or none.
+// A missing tesseract binary only disables OCR.
+func InitOCR() {
+	switch engine := os.Getenv("OCR_ENGINE"); engine {
+	case "", "tesseract":
+		t, err := newTesseractEngine()
+		if err != nil {
+			log.Printf("⚠️ OCR disabled, scanned PDF pages will be skipped: %v", err)
+			return
+		}
+		ocrEngine = t
+		log.Printf("✅ OCR engine: tesseract (%s)", t.languages)
+	case "none":
+		log.Print("⚠️ OCR disabled by OCR_ENGINE=none")
+	default:
+		log.Fatalf("❌ unknown OCR_ENGINE %q", engine)
+	}
+}
+
+// ocrDPI is the resolution pages are rendered at for OCR (OCR_DPI, default 300)
+func ocrDPI() int {
+	dpi, err := strconv.Atoi(os.Getenv("OCR_DPI"))
+	if err != nil || dpi < 72 || dpi > 600 {
+		return 300
+	}
+	return dpi
+}
+
+// ocrMinConfidence is the confidence below which a page is reported as poorly
+// read (OCR_MIN_CONFIDENCE, default 60)
+func ocrMinConfidence() float64 {
+	confidence, err := strconv.ParseFloat(os.Getenv("OCR_MIN_CONFIDENCE"), 64)
+	if err != nil || confidence < 0 || confidence > 100 {
+		return 60
+	}
+	return confidence
+}
+
+// tesseractEngine runs the tesseract CLI, reading its TSV output for the
+// per-word confidences
+type tesseractEngine struct {
+	path      string
+	languages string // e.g. eng or eng+hin
+}
+
+func newTesseractEngine() (*tesseractEngine, error) {
+	path := os.Getenv("TESSERACT_PATH")
+	if path == "" {
+		path = "tesseract"
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	languages := os.Getenv("OCR_LANGUAGES")
+	if languages == "" {
+		languages = "eng"
+	}
+	return &tesseractEngine{path: resolved, languages: languages}, nil
+}
+
+func (t *tesseractEngine) Recognize(ctx context.Context, img image.Image) (OCRText, error) {
+	var input bytes.Buffer
+	if err := png.Encode(&input, img); err != nil {
+		return OCRText{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout",
+		"-l", t.languages, "--dpi", strconv.Itoa(ocrDPI()), "tsv")
+	cmd.Stdin = &input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return OCRText{}, fmt.Errorf("tesseract: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractTSV(stdout.String()), nil
+}
+
+// parseTesseractTSV rebuilds the lines and paragraphs of tesseract's TSV
+// output. The confidence is the mean of the words', weighted by length.
+func parseTesseractTSV(tsv string) OCRText {
+	var text strings.Builder
+	var weighted, characters float64
+	var lastParagraph, lastLine string
+
+	for _, row := range strings.Split(tsv, "\n") {
+		// level page block par line word left top width height conf text
+		fields := strings.Split(row, "\t")
+		if len(fields) < 12 || fields[0] != "5" {
+			continue
+		}
+		word := strings.TrimSpace(fields[11])
+		confidence, err := strconv.ParseFloat(fields[10], 64)
+		if word == "" || err != nil || confidence < 0 {
+			continue
+		}
+
+		paragraph := fields[2] + "." + fields[3]
+		line := paragraph + "." + fields[4]
+		switch {
+		case text.Len() == 0:
+		case paragraph != lastParagraph:
+			text.WriteString("\n\n")
+		case line != lastLine:
+			text.WriteString("\n")
+		default:
+			text.WriteString(" ")
+		}
+		lastParagraph, lastLine = paragraph, line
+
+		text.WriteString(word)
+		n := float64(utf8.RuneCountInString(word))
+		weighted += confidence * n
+		characters += n
+	}
+
+	result := OCRText{Text: text.String()}
+	if characters > 0 {
+		result.Confidence = weighted / characters
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// tsvRow is one row of tesseract's TSV output: level page block par line
+// word left top width height conf text
+func tsvRow(level, block, par, line, word string, conf, text string) string {
+	return strings.Join([]string{level, "1", block, par, line, word, "0", "0", "10", "10", conf, text}, "\t")
+}
+
+func TestParseTesseractTSV(t *testing.T) {
+	tsv := strings.Join([]string{
+		"level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext",
+		tsvRow("1", "0", "0", "0", "0", "-1", ""),
+		tsvRow("2", "1", "0", "0", "0", "-1", ""),
+		tsvRow("3", "1", "1", "0", "0", "-1", ""),
+		tsvRow("4", "1", "1", "1", "0", "-1", ""),
+		tsvRow("5", "1", "1", "1", "1", "90", "The"),
+		tsvRow("5", "1", "1", "1", "2", "80.5", "cell"),
+		tsvRow("4", "1", "1", "2", "0", "-1", ""),
+		tsvRow("5", "1", "1", "2", "1", "70", "wall"),
+		tsvRow("5", "1", "1", "2", "2", "95", " "), // blank words are dropped
+		tsvRow("5", "1", "1", "2", "3", "100", "naïve"),
+		tsvRow("3", "1", "2", "0", "0", "-1", ""),
+		tsvRow("5", "1", "2", "1", "1", "60", "Mitosis"),
+		tsvRow("5", "1", "2", "1", "2", "-1", "noise"), // no confidence
+		tsvRow("2", "2", "0", "0", "0", "-1", ""),
+		tsvRow("5", "2", "1", "1", "1", "50", "Done"),
+		"5\t1\t2\t1\t1", // truncated row
+		"",
+	}, "\n")
+
+	got := parseTesseractTSV(tsv)
+
+	if want := "The cell\nwall naïve\n\nMitosis\n\nDone"; got.Text != want {
+		t.Errorf("text:\n got %q\nwant %q", got.Text, want)
+	}
+	// Weighted by runes: naïve counts 5, not its 6 bytes
+	weighted := 90*3 + 80.5*4 + 70*4 + 100*5 + 60*7 + 50*4
+	if want := weighted / 27; math.Abs(got.Confidence-want) > 1e-9 {
+		t.Errorf("confidence %v, want %v", got.Confidence, want)
+	}
+}
+
+func TestParseTesseractTSVNoWords(t *testing.T) {
+	header := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n"
+	for _, tsv := range []string{"", header, header + tsvRow("1", "0", "0", "0", "0", "-1", "") + "\n"} {
+		if got := parseTesseractTSV(tsv); got != (OCRText{}) {
+			t.Errorf("parseTesseractTSV(%q) = %+v, want nothing", tsv, got)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// xmlTextRules says which elements of an office document's XML hold text
+type xmlTextRules struct {
+	text       map[string]bool   // elements whose character data is kept
+	paragraphs map[string]bool   // elements ended by a newline
+	inline     map[string]string // empty elements standing for a character
+}
+
+var (
+	docxRules = xmlTextRules{
+		text:       map[string]bool{"t": true},
+		paragraphs: map[string]bool{"p": true, "tr": true},
+		inline:     map[string]string{"tab": "\t", "br": "\n", "cr": "\n"},
+	}
+	pptxRules = xmlTextRules{
+		text:       map[string]bool{"t": true},
+		paragraphs: map[string]bool{"p": true},
+		inline:     map[string]string{"br": "\n"},
+	}
+	odtRules = xmlTextRules{
+		text:       map[string]bool{"p": true, "h": true},
+		paragraphs: map[string]bool{"p": true, "h": true},
+		inline:     map[string]string{"s": " ", "tab": "\t", "line-break": "\n"},
+	}
+)
+
+// xmlText collects the text of an XML document by rules. Elements are matched
+// by local name, the namespaces being fixed by each format.
+func xmlText(r io.Reader, rules xmlTextRules) (string, error) {
+	decoder := xml.NewDecoder(r)
+	var text strings.Builder
+	inText := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return text.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if rules.text[t.Name.Local] {
+				inText++
+			}
+			if s, ok := rules.inline[t.Name.Local]; ok {
+				text.WriteString(s)
+			}
+		case xml.EndElement:
+			if rules.text[t.Name.Local] && inText > 0 {
+				inText--
+			}
+			if rules.paragraphs[t.Name.Local] {
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText > 0 {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+func openZip(data []byte) (map[string]*zip.File, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	return files, nil
+}
+
+func zipPartText(f *zip.File, rules xmlTextRules) (string, error) {
+	data, err := readZipFile(f)
+	if err != nil {
+		return "", err
+	}
+	return xmlText(bytes.NewReader(data), rules)
+}
+
+// extractDocx reads the body of a Word document; headers, footers and
+// comments are left out
+func extractDocx(data []byte) (string, error) {
+	files, err := openZip(data)
+	if err != nil {
+		return "", err
+	}
+	document, ok := files["word/document.xml"]
+	if !ok {
+		return "", errors.New("word/document.xml is missing")
+	}
+	return zipPartText(document, docxRules)
+}
+
+// extractPptx reads the slides of a presentation in order, one block per
+// slide; speaker notes are left out
+func extractPptx(data []byte) (string, error) {
+	files, err := openZip(data)
+	if err != nil {
+		return "", err
+	}
+	slides, err := presentationSlides(files)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, slide := range slides {
+		slideText, err := zipPartText(slide, pptxRules)
+		if err != nil {
+			return "", err
+		}
+		text.WriteString(slideText)
+		text.WriteString("\n")
+	}
+	return text.String(), nil
+}
+
+// presentationSlides lists the slide parts in the order of the
+// presentation's sldIdLst. The numbers in slide file names are the order the
+// slides were created in, not the one they are shown in once moved.
+func presentationSlides(files map[string]*zip.File) ([]*zip.File, error) {
+	var presentation struct {
+		Slides []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := zipPartXML(files, "ppt/presentation.xml", &presentation); err != nil {
+		return nil, err
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := zipPartXML(files, "ppt/_rels/presentation.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		// Targets are relative to ppt/ unless absolute within the package
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(path.Clean(rel.Target), "/")
+		} else {
+			targets[rel.ID] = path.Join("ppt", rel.Target)
+		}
+	}
+
+	slides := make([]*zip.File, 0, len(presentation.Slides))
+	for _, s := range presentation.Slides {
+		name, ok := targets[s.RelID]
+		if !ok {
+			return nil, errors.New("slide relationship " + s.RelID + " is missing")
+		}
+		slide, ok := files[name]
+		if !ok {
+			return nil, errors.New(name + " is missing")
+		}
+		slides = append(slides, slide)
+	}
+	return slides, nil
+}
+
+// zipPartXML decodes the part name of a package into v
+func zipPartXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return errors.New(name + " is missing")
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, v)
+}
+
+// extractOdt reads the paragraphs and headings of an OpenDocument text
+func extractOdt(data []byte) (string, error) {
+	files, err := openZip(data)
+	if err != nil {
+		return "", err
+	}
+	content, ok := files["content.xml"]
+	if !ok {
+		return "", errors.New("content.xml is missing")
+	}
+	return zipPartText(content, odtRules)
+}
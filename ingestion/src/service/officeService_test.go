@@ -0,0 +1,144 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+const (
+	wordNS   = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	drawNS   = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"`
+	slideNS  = `xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+	relNS    = `xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	officeNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"`
+)
+
+// zipPart is one entry of a test package, written in order
+type zipPart struct {
+	name    string
+	content string
+}
+
+func buildZip(t *testing.T, parts ...zipPart) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, part := range parts {
+		// OpenDocument's mimetype must be stored uncompressed
+		method := zip.Deflate
+		if part.name == "mimetype" {
+			method = zip.Store
+		}
+		f, err := w.CreateHeader(&zip.FileHeader{Name: part.name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(part.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func docxFixture(t *testing.T) []byte {
+	return buildZip(t,
+		zipPart{"[Content_Types].xml", `<Types/>`},
+		zipPart{"word/document.xml", `<w:document ` + wordNS + `><w:body>` +
+			`<w:p><w:r><w:t>Cell</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">division </w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t>Mitosis</w:t><w:br/><w:t>Meiosis</w:t></w:r></w:p>` +
+			`<w:tbl><w:tr><w:tc><w:p><w:r><w:t>A</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>B</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+			`</w:body></w:document>`},
+		zipPart{"word/footer1.xml", `<w:ftr ` + wordNS + `><w:p><w:r><w:t>Page footer</w:t></w:r></w:p></w:ftr>`},
+	)
+}
+
+func slide(text string) string {
+	return `<p:sld ` + drawNS + ` ` + slideNS + `><p:cSld><p:spTree><p:sp><p:txBody>` +
+		`<a:p><a:r><a:t>` + text + `</a:t></a:r><a:br/><a:r><a:t>body</a:t></a:r></a:p>` +
+		`</p:txBody></p:sp></p:spTree></p:cSld></p:sld>`
+}
+
+// pptxFixture has three slides whose file names are not in show order:
+// slide3.xml comes first, then slide1.xml, then slide2.xml
+func pptxFixture(t *testing.T) []byte {
+	return buildZip(t,
+		zipPart{"[Content_Types].xml", `<Types/>`},
+		zipPart{"ppt/presentation.xml", `<p:presentation ` + slideNS + ` ` + relNS + `><p:sldIdLst>` +
+			`<p:sldId id="258" r:id="rId4"/><p:sldId id="256" r:id="rId2"/><p:sldId id="257" r:id="rId3"/>` +
+			`</p:sldIdLst></p:presentation>`},
+		zipPart{"ppt/_rels/presentation.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slideMaster" Target="slideMasters/slideMaster1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide1.xml"/>` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="/ppt/slides/slide2.xml"/>` +
+			`<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide3.xml"/>` +
+			`</Relationships>`},
+		zipPart{"ppt/slides/slide1.xml", slide("Second")},
+		zipPart{"ppt/slides/slide2.xml", slide("Third")},
+		zipPart{"ppt/slides/slide3.xml", slide("First")},
+		zipPart{"ppt/notesSlides/notesSlide1.xml", slide("Speaker notes")},
+	)
+}
+
+func odtFixture(t *testing.T) []byte {
+	return buildZip(t,
+		zipPart{"mimetype", MimeODT},
+		zipPart{"content.xml", `<office:document-content ` + officeNS + `><office:body><office:text>` +
+			`<text:h text:outline-level="1">Photosynthesis</text:h>` +
+			`<text:p>Light<text:s/>and<text:tab/>water<text:line-break/>make sugar</text:p>` +
+			`</office:text></office:body></office:document-content>`},
+		zipPart{"styles.xml", `<office:document-styles ` + officeNS + `/>`},
+	)
+}
+
+func TestOfficeExtractors(t *testing.T) {
+	cases := []struct {
+		name    string
+		extract func([]byte) (string, error)
+		data    []byte
+		want    string
+	}{
+		{"docx", extractDocx, docxFixture(t), "Cell\tdivision \nMitosis\nMeiosis\nA\nB\n\n"},
+		{"pptx", extractPptx, pptxFixture(t), "First\nbody\n\nSecond\nbody\n\nThird\nbody\n\n"},
+		{"odt", extractOdt, odtFixture(t), "Photosynthesis\nLight and\twater\nmake sugar\n"},
+	}
+	for _, c := range cases {
+		got, err := c.extract(c.data)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s:\n got %q\nwant %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestOfficeExtractorsMissingParts(t *testing.T) {
+	empty := buildZip(t, zipPart{"[Content_Types].xml", `<Types/>`})
+	danglingSlide := buildZip(t,
+		zipPart{"ppt/presentation.xml", `<p:presentation ` + slideNS + ` ` + relNS + `><p:sldIdLst><p:sldId id="256" r:id="rId2"/></p:sldIdLst></p:presentation>`},
+		zipPart{"ppt/_rels/presentation.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId2" Target="slides/slide1.xml"/></Relationships>`},
+	)
+
+	cases := []struct {
+		name    string
+		extract func([]byte) (string, error)
+		data    []byte
+	}{
+		{"docx without document.xml", extractDocx, empty},
+		{"pptx without presentation.xml", extractPptx, empty},
+		{"pptx with a missing slide", extractPptx, danglingSlide},
+		{"odt without content.xml", extractOdt, empty},
+		{"not a zip", extractDocx, []byte("plain text")},
+	}
+	for _, c := range cases {
+		if _, err := c.extract(c.data); err == nil {
+			t.Errorf("%s: no error", c.name)
+		}
+	}
+}
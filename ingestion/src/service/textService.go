@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// Markdown syntax, dropped so only the words reach the LLM
+	reMdFence      = regexp.MustCompile("(?m)^[ \t]*(```|~~~).*$")
+	reMdHeading    = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+(.*?)[ \t]*#*[ \t]*$`)
+	reMdSetext     = regexp.MustCompile(`(?m)^[ \t]*(=+|-+)[ \t]*$`)
+	reMdQuote      = regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`)
+	reMdTableRule  = regexp.MustCompile(`(?m)^[ \t]*\|?([ \t]*:?-+:?[ \t]*\|)+[ \t]*:?-*:?[ \t]*$`)
+	reMdImage      = regexp.MustCompile(`!\[([^\]\n]*)\]\([^)\n]*\)`)
+	reMdLink       = regexp.MustCompile(`\[([^\]\n]*)\]\([^)\n]*\)`)
+	reMdEmphasis   = regexp.MustCompile(`(\*{1,3}|_{2,3})(\S[^\n]*?)(\*{1,3}|_{2,3})`)
+	reMdInlineCode = regexp.MustCompile("`([^`\n]*)`")
+	reMdTablePipe  = regexp.MustCompile(`[ \t]*\|[ \t]*`)
+
+	// HTML: invisible elements are removed, block elements become line breaks
+	reHTMLInvisible = regexp.MustCompile(`(?is)<(script|style|head|noscript|template)\b.*?</(script|style|head|noscript|template)\s*>`)
+	reHTMLComment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	reHTMLBlock     = regexp.MustCompile(`(?i)<\s*/?\s*(p|div|br|hr|li|ul|ol|tr|table|section|article|header|footer|h[1-6]|pre|blockquote|dt|dd)\b[^>]*>`)
+	reHTMLTag       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+func extractPlainText(data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", errors.New("file is not UTF-8 text")
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	return strings.ReplaceAll(text, "\r\n", "\n"), nil
+}
+
+func extractMarkdown(data []byte) (string, error) {
+	text, err := extractPlainText(data)
+	if err != nil {
+		return "", err
+	}
+
+	text = reMdFence.ReplaceAllString(text, "")
+	text = reMdHeading.ReplaceAllString(text, "$1")
+	text = reMdTableRule.ReplaceAllString(text, "")
+	text = reMdSetext.ReplaceAllString(text, "")
+	text = reMdQuote.ReplaceAllString(text, "")
+	text = reMdImage.ReplaceAllString(text, "$1")
+	text = reMdLink.ReplaceAllString(text, "$1")
+	text = reMdEmphasis.ReplaceAllString(text, "$2")
+	text = reMdInlineCode.ReplaceAllString(text, "$1")
+	text = reMdTablePipe.ReplaceAllString(text, " ")
+	return text, nil
+}
+
+func extractHTML(data []byte) (string, error) {
+	text, err := extractPlainText(data)
+	if err != nil {
+		return "", err
+	}
+
+	text = reHTMLComment.ReplaceAllString(text, "")
+	text = reHTMLInvisible.ReplaceAllString(text, "")
+	text = reHTMLBlock.ReplaceAllString(text, "\n")
+	text = reHTMLTag.ReplaceAllString(text, "")
+	return html.UnescapeString(text), nil
+}
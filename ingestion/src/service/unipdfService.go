@@ -2,46 +2,183 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"ingestion/src/model"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/unidoc/unipdf/v4/extractor"
-	"github.com/unidoc/unipdf/v4/model"
+	pdfmodel "github.com/unidoc/unipdf/v4/model"
+	"github.com/unidoc/unipdf/v4/render"
 )
 
-func ExtractPdfFromBytes(fileBytes []byte) (string, error) {
+const (
+	// A page whose text layer has fewer letters than this and that carries
+	// images is taken for a scan; scans often hold a stray page number
+	minPageText = 20
+	// A page longer than this to render and read is given up on
+	ocrPageTimeout = 2 * time.Minute
+)
+
+// errRenderAbandoned is a page render that outlived its deadline. The render
+// goroutine keeps reading the document, which is not safe to share, so no
+// further page is read.
+var errRenderAbandoned = errors.New("rendering timed out")
+
+// ExtractPdfFromBytes reads the text layer of every page. Pages that are only
+// images (scans) are rendered and read by OCR instead; how each was read is
+// returned with the text.
+func ExtractPdfFromBytes(ctx context.Context, fileBytes []byte) (string, *model.Extraction, error) {
 	reader := bytes.NewReader(fileBytes)
 
-	pdfReader, err := model.NewPdfReader(reader)
+	pdfReader, err := pdfmodel.NewPdfReader(reader)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	numPages, err := pdfReader.GetNumPages()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
+	extraction := &model.Extraction{Pages: numPages}
+	var skipped, failed, unclear []string
+	var unread string
+	var weighted, characters float64
+
 	var fullText strings.Builder
 
 	for i := 1; i <= numPages; i++ {
 		page, err := pdfReader.GetPage(i)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 
 		ex, err := extractor.New(page)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 
 		pageText, err := ex.ExtractText()
 		if err != nil {
-			return "", err
+			return "", nil, err
+		}
+
+		if looksScanned(pageText, hasImages(ex)) {
+			if ocrEngine == nil {
+				skipped = append(skipped, strconv.Itoa(i))
+			} else {
+				ocr, err := ocrPdfPage(ctx, page)
+				if errors.Is(err, errRenderAbandoned) {
+					failed = append(failed, strconv.Itoa(i))
+					extraction.OCRPages = append(extraction.OCRPages, model.OCRPage{Page: i, Error: err.Error()})
+					if i < numPages {
+						unread = fmt.Sprintf("%d-%d", i+1, numPages)
+					}
+					break
+				}
+				if err != nil {
+					failed = append(failed, strconv.Itoa(i))
+					extraction.OCRPages = append(extraction.OCRPages, model.OCRPage{Page: i, Error: err.Error()})
+				} else {
+					pageText = ocr.Text
+					n := len([]rune(ocr.Text))
+					extraction.OCRPages = append(extraction.OCRPages, model.OCRPage{Page: i, Confidence: ocr.Confidence, Characters: n})
+					weighted += ocr.Confidence * float64(n)
+					characters += float64(n)
+					if ocr.Confidence < ocrMinConfidence() {
+						unclear = append(unclear, fmt.Sprintf("%d (%.0f%%)", i, ocr.Confidence))
+					}
+				}
+			}
 		}
 
 		fullText.WriteString(pageText)
 		fullText.WriteString("\n")
 	}
 
-	return fullText.String(), nil
+	if characters > 0 {
+		extraction.OCRConfidence = weighted / characters
+	}
+	if len(skipped) > 0 {
+		extraction.Warnings = append(extraction.Warnings, "pages "+strings.Join(skipped, ", ")+" are scanned images and were skipped: OCR is not available")
+	}
+	if len(failed) > 0 {
+		extraction.Warnings = append(extraction.Warnings, "OCR failed on pages "+strings.Join(failed, ", "))
+	}
+	if unread != "" {
+		extraction.Warnings = append(extraction.Warnings, "pages "+unread+" were not read: rendering a scanned page timed out")
+	}
+	if len(unclear) > 0 {
+		extraction.Warnings = append(extraction.Warnings, fmt.Sprintf(
+			"OCR confidence is below %.0f%% on pages %s; the extracted text and questions may contain errors",
+			ocrMinConfidence(), strings.Join(unclear, ", ")))
+	}
+
+	return fullText.String(), extraction, nil
+}
+
+// looksScanned tells a scanned page, an image with no text layer but maybe
+// a stray page number, from a page of text that also carries pictures
+func looksScanned(pageText string, images bool) bool {
+	return images && letters(pageText) < minPageText
+}
+
+// letters counts the letters, digits and marks of text; the vowel signs of
+// scripts such as Devanagari are marks
+func letters(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func hasImages(ex *extractor.Extractor) bool {
+	images, err := ex.ExtractPageImages(nil)
+	return err == nil && images != nil && len(images.Images) > 0
+}
+
+// ocrPdfPage renders a page at ocrDPI and reads it with the OCR engine
+func ocrPdfPage(ctx context.Context, page *pdfmodel.PdfPage) (OCRText, error) {
+	box, err := page.GetMediaBox()
+	if err != nil {
+		return OCRText{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ocrPageTimeout)
+	defer cancel()
+
+	// Render cannot be cancelled, so it runs aside and is abandoned at the
+	// deadline; it still holds the PDF reader (see errRenderAbandoned)
+	type rendered struct {
+		img image.Image
+		err error
+	}
+	done := make(chan rendered, 1)
+	go func() {
+		device := render.NewImageDevice()
+		device.OutputWidth = int(box.Width() / 72 * float64(ocrDPI()))
+		img, err := device.Render(page)
+		done <- rendered{img, err}
+	}()
+
+	var img image.Image
+	select {
+	case <-ctx.Done():
+		return OCRText{}, fmt.Errorf("%w: %v", errRenderAbandoned, ctx.Err())
+	case r := <-done:
+		if r.err != nil {
+			return OCRText{}, fmt.Errorf("rendering failed: %w", r.err)
+		}
+		img = r.img
+	}
+	return ocrEngine.Recognize(ctx, img)
 }
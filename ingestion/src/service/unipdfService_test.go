@@ -0,0 +1,41 @@
+package service
+
+import "testing"
+
+func TestLetters(t *testing.T) {
+	cases := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"  - 12 -\n", 2},
+		{"Cell wall, 3 layers.", 15},
+		{"कोशिका भित्ति", 12},
+	}
+	for _, c := range cases {
+		if got := letters(c.text); got != c.want {
+			t.Errorf("letters(%q) = %d, want %d", c.text, got, c.want)
+		}
+	}
+}
+
+func TestLooksScanned(t *testing.T) {
+	cases := []struct {
+		name   string
+		text   string
+		images bool
+		want   bool
+	}{
+		{"image only", "", true, true},
+		{"image with a page number", "\n  - 12 -\n", true, true},
+		{"image with a short caption", "Figure 3: a cell", true, true},
+		{"text with a picture", "The cell wall gives the plant cell its shape.", true, false},
+		{"blank page", "", false, false},
+		{"short text page", "Chapter 2", false, false},
+	}
+	for _, c := range cases {
+		if got := looksScanned(c.text, c.images); got != c.want {
+			t.Errorf("%s: looksScanned = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
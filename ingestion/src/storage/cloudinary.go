@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"ingestion/src/config"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// cloudinaryStore keeps objects as private raw assets, reachable only through
+// signed download URLs
+type cloudinaryStore struct {
+	cld *cloudinary.Cloudinary
+}
+
+var downloadClient = &http.Client{Timeout: 2 * time.Minute}
+
+func newCloudinaryStore() (*cloudinaryStore, error) {
+	config.InitCloudinary()
+	return &cloudinaryStore{cld: config.GetCloudinaryCloud()}, nil
+}
+
+func (s *cloudinaryStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	uploadParams := uploader.UploadParams{
+		ResourceType: api.File, // raw: stored as is
+		Type:         api.Private,
+		PublicID:     key,
+		Overwrite:    api.Bool(false),
+	}
+
+	result, err := s.cld.Upload.Upload(ctx, bytes.NewReader(data), uploadParams)
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return errors.New(result.Error.Message)
+	}
+	return nil
+}
+
+func (s *cloudinaryStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	signedURL, err := s.SignedURL(ctx, key, time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return httpGet(ctx, signedURL)
+}
+
+func (s *cloudinaryStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	expires := time.Now().Add(ttl)
+	return s.cld.Upload.PrivateDownloadURL(uploader.PrivateDownloadURLParams{
+		PublicID:     key,
+		DeliveryType: api.Private,
+		ResourceType: api.File,
+		ExpiresAt:    &expires,
+	})
+}
+
+// httpGet downloads rawURL, mapping a 404 to ErrNotFound
+func httpGet(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		resp.Body.Close()
+		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// OpenURL downloads a file by its public URL, for materials stored before
+// objects were kept by key
+func OpenURL(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	return httpGet(ctx, rawURL)
+}
@@ -0,0 +1,110 @@
+// Package storage keeps uploaded course material in the object store chosen
+// by STORAGE_BACKEND: "cloudinary", "local" (a directory) or "s3" (any
+// S3-compatible service, e.g. MinIO). Objects are addressed by the hash of
+// their content, so two uploads never overwrite each other.
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlobStore is an object store holding uploaded files
+type BlobStore interface {
+	// Put stores data under key; storing the same key again is harmless
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// SignedURL is a URL anyone can download the object from until it expires
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// ErrNotFound is returned by Get for a key that holds no object
+var ErrNotFound = errors.New("object not found")
+
+var store BlobStore
+
+// Init opens the configured backend. Without STORAGE_BACKEND, Cloudinary is
+// used when CLOUDINARY_URL is set, as before, and a local directory otherwise.
+func Init() {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = "local"
+		if os.Getenv("CLOUDINARY_URL") != "" {
+			backend = "cloudinary"
+		}
+	}
+
+	var err error
+	switch backend {
+	case "cloudinary":
+		store, err = newCloudinaryStore()
+	case "local":
+		store, err = newLocalStore()
+	case "s3":
+		store, err = newS3Store()
+	default:
+		log.Fatalf("❌ Unknown STORAGE_BACKEND %q (cloudinary, local or s3)", backend)
+	}
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize %s storage: %v", backend, err)
+	}
+
+	log.Printf("✅ Using %s storage", backend)
+}
+
+// Store returns the configured backend
+func Store() BlobStore {
+	return store
+}
+
+// ContentKey names data by its SHA-256 under prefix, e.g. materials/3f2a….pdf
+func ContentKey(prefix string, data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	return prefix + "/" + hex.EncodeToString(sum[:]) + ext
+}
+
+// URLTTL is how long signed download URLs stay valid (STORAGE_URL_TTL_MINUTES, default 15)
+func URLTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("STORAGE_URL_TTL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// inertTypes are served with their own type; they are documents a browser
+// downloads or shows in a viewer, never runs
+var inertTypes = map[string]bool{
+	"application/pdf": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/vnd.oasis.opendocument.text":                                   true,
+}
+
+// SafeContentType is the type a stored file may be served with. Uploads are
+// served from the app's own origin, so HTML (or anything a browser could run)
+// must never go out as such: text is served as text/plain, the rest as
+// application/octet-stream.
+func SafeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	switch {
+	case err != nil:
+		return "application/octet-stream"
+	case inertTypes[mediaType]:
+		return mediaType
+	case strings.HasPrefix(mediaType, "text/"):
+		return "text/plain; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,28 @@
+package storage
+
+import "testing"
+
+func TestSafeContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/pdf", "application/pdf"},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"text/html", "text/plain; charset=utf-8"},
+		{"text/html; charset=utf-8", "text/plain; charset=utf-8"},
+		{"TEXT/HTML", "text/plain; charset=utf-8"},
+		{"text/markdown", "text/plain; charset=utf-8"},
+		{"text/plain", "text/plain; charset=utf-8"},
+		{"image/svg+xml", "application/octet-stream"},
+		{"application/xhtml+xml", "application/octet-stream"},
+		{"application/javascript", "application/octet-stream"},
+		{"", "application/octet-stream"},
+		{"not a type", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := SafeContentType(tt.contentType); got != tt.want {
+			t.Errorf("SafeContentType(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
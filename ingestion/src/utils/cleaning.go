@@ -2,7 +2,6 @@ package utils
 
 import (
 	"ingestion/src/dto"
-	"log"
 	"regexp"
 	"strings"
 )
@@ -45,6 +44,11 @@ func SplitByUnits(text string) []dto.UnitChunk {
 
 	var chunks []dto.UnitChunk
 
+	// Blank text → no chunks, so callers never index into an empty result
+	if strings.TrimSpace(text) == "" {
+		return chunks
+	}
+
 	// No unit headings → return whole text as single chunk
 	if len(matches) == 0 {
 		chunks = append(chunks, dto.UnitChunk{
@@ -69,6 +73,5 @@ func SplitByUnits(text string) []dto.UnitChunk {
 			Content: content,
 		})
 	}
-	log.Print(chunks[0])
 	return chunks
 }
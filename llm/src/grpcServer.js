@@ -3,6 +3,7 @@ const protoLoader = require('@grpc/proto-loader');
 const path = require('path');
 const fs = require('fs');
 const { generateLLMResponse } = require('./service/service');
+const { verifyServiceToken } = require('./middleware/service.middleware');
 
 // Load proto file
 const PROTO_CANDIDATES = [
@@ -159,6 +160,26 @@ async function evaluateMCQAnswer(call, callback) {
   }
 }
 
+/**
+ * Wrap a unary handler so it only runs for callers holding an
+ * llm:evaluate service token in the authorization metadata
+ */
+function requireEvaluateScope(handler) {
+  return async (call, callback) => {
+    const [authHeader] = call.metadata.get('authorization');
+    try {
+      await verifyServiceToken(authHeader, 'llm:evaluate');
+    } catch (err) {
+      callback({
+        code: err.forbidden ? grpc.status.PERMISSION_DENIED : grpc.status.UNAUTHENTICATED,
+        message: err.message,
+      });
+      return;
+    }
+    return handler(call, callback);
+  };
+}
+
 /**
  * Start the gRPC server
  */
@@ -166,8 +187,8 @@ function startGrpcServer(port = 50051) {
   const server = new grpc.Server();
 
   server.addService(evaluationProto.EvaluationService.service, {
-    EvaluateTheoryAnswer: evaluateTheoryAnswer,
-    EvaluateMCQAnswer: evaluateMCQAnswer,
+    EvaluateTheoryAnswer: requireEvaluateScope(evaluateTheoryAnswer),
+    EvaluateMCQAnswer: requireEvaluateScope(evaluateMCQAnswer),
   });
 
   server.bindAsync(
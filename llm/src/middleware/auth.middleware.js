@@ -1,7 +1,7 @@
 // src/middleware/auth.middleware.js
 const { validateToken } = require("../util/jwt.util");
 
-function authMiddleware(req, res, next) {
+async function authMiddleware(req, res, next) {
     const authHeader = req.headers["authorization"];
 
     if (!authHeader) {
@@ -22,13 +22,23 @@ function authMiddleware(req, res, next) {
 
     let claims;
     try {
-        claims = validateToken(token);
+        claims = await validateToken(token);
     } catch (err) {
         return res.status(401).json({
             message: err.message,
         });
     }
 
+    // Impersonation tokens (an admin acting as the user) may only read
+    if (claims.act) {
+        reportImpersonation(token, claims, req.method, req.originalUrl.split("?")[0]);
+        if (req.method !== "GET" && req.method !== "HEAD") {
+            return res.status(403).json({
+                message: "Forbidden: impersonation is read-only",
+            });
+        }
+    }
+
     // ✅ FIXED LOGIC (teacher OR admin allowed)
     if (claims.Role !== "teacher" && claims.Role !== "admin") {
         return res.status(403).json({
@@ -47,4 +57,32 @@ function authMiddleware(req, res, next) {
     next();
 }
 
+// reportImpersonation sends a request made with an impersonation token to
+// auth's audit log, using the token itself. Failures are only logged here.
+function reportImpersonation(token, claims, method, path) {
+    const fail = (err) => console.error(
+        `Impersonated request ${method} ${path} by ${claims.act.sub} as ${claims.ID} not audited:`, err
+    );
+
+    if (!process.env.AUTH_URI) {
+        return fail("AUTH_URI not configured");
+    }
+
+    fetch(`${process.env.AUTH_URI}/impersonation/events`, {
+        method: "POST",
+        headers: {
+            Authorization: `Bearer ${token}`,
+            "Content-Type": "application/json",
+        },
+        body: JSON.stringify({ service: "llm", method, path }),
+        signal: AbortSignal.timeout(5000),
+    })
+        .then((res) => {
+            if (res.status !== 204) {
+                fail(`auth returned ${res.status}`);
+            }
+        })
+        .catch(fail);
+}
+
 module.exports = authMiddleware;
@@ -0,0 +1,50 @@
+// src/middleware/service.middleware.js
+const { validateToken } = require("../util/jwt.util");
+
+// Service tokens come from the auth service's client credentials grant: the
+// Role claim is "service" and scope lists the granted scopes.
+async function verifyServiceToken(authHeader, scope) {
+    if (!authHeader) {
+        throw new Error("Missing Authorization header");
+    }
+
+    const parts = authHeader.split(" ");
+    if (parts.length !== 2 || parts[0].toLowerCase() !== "bearer") {
+        throw new Error("Invalid Authorization header format");
+    }
+
+    const claims = await validateToken(parts[1]);
+
+    const scopes = (claims.scope || "").split(" ");
+    if (claims.Role !== "service" || !scopes.includes(scope)) {
+        const err = new Error("Not Authorized for the service");
+        err.forbidden = true;
+        throw err;
+    }
+
+    return claims;
+}
+
+// requireServiceScope admits only service tokens granted scope
+function requireServiceScope(scope) {
+    return async function (req, res, next) {
+        let claims;
+        try {
+            claims = await verifyServiceToken(req.headers["authorization"], scope);
+        } catch (err) {
+            return res.status(err.forbidden ? 403 : 401).json({
+                message: err.message,
+            });
+        }
+
+        req.auth = {
+            clientId: claims.ID,
+            scopes: claims.scope.split(" "),
+            claims,
+        };
+
+        next();
+    };
+}
+
+module.exports = { verifyServiceToken, requireServiceScope };
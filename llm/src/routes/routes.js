@@ -2,6 +2,7 @@
  const {generateTheoryQuestions , generateMCQQuestions , generateSeatingArrangement} = require('../controller/controller');
  const { evaluateTheoryAnswer, evaluateTheoryBatch } = require('../controller/evaluationController');
 const authMiddleware = require('../middleware/auth.middleware');
+const { requireServiceScope } = require('../middleware/service.middleware');
 
 const router = express.Router();
 
@@ -10,8 +11,8 @@ router.post("/generate/theory/questions", authMiddleware, generateTheoryQuestion
 router.post("/generate/mcq/questions", authMiddleware, generateMCQQuestions);
 router.post("/generate-seating-arrangement" , authMiddleware , generateSeatingArrangement);
 
-// Answer evaluation routes (can be called from Answer service)
-router.post("/evaluate", evaluateTheoryAnswer);
-router.post("/evaluate/batch", evaluateTheoryBatch);
+// Answer evaluation routes (Answer service, with an llm:evaluate service token)
+router.post("/evaluate", requireServiceScope("llm:evaluate"), evaluateTheoryAnswer);
+router.post("/evaluate/batch", requireServiceScope("llm:evaluate"), evaluateTheoryBatch);
 
 module.exports =  {router};
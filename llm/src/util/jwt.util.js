@@ -1,19 +1,72 @@
 // src/jwtutil/jwt.util.js
+const crypto = require("crypto");
 const jwt = require("jsonwebtoken");
 
-function validateToken(token) {
-    const secret = process.env.JWT_SECRET;
+// Access tokens are RS256-signed by the auth service; its public keys are
+// fetched from the JWKS endpoint and cached by kid.
+const JWKS_CACHE_TTL_MS = 10 * 60 * 1000;
+const JWKS_MIN_INTERVAL_MS = 30 * 1000;
 
-    if (!secret) {
-        throw new Error("missing JWT_SECRET");
+let jwksKeys = new Map();
+let jwksFetchedAt = 0;
+
+function jwksURL() {
+    if (process.env.JWKS_URL) return process.env.JWKS_URL;
+    if (process.env.AUTH_URI) return process.env.AUTH_URI + "/.well-known/jwks.json";
+    throw new Error("missing JWKS_URL");
+}
+
+async function fetchJWKS() {
+    const res = await fetch(jwksURL());
+    if (!res.ok) {
+        throw new Error(`fetch jwks: status ${res.status}`);
     }
+    const body = await res.json();
 
-    try {
-        const decoded = jwt.verify(token, secret, {
-            algorithms: ["HS256"],
-        });
+    const keys = new Map();
+    for (const jwk of body.keys || []) {
+        if (jwk.kty !== "RSA") continue;
+        keys.set(jwk.kid, crypto.createPublicKey({ key: jwk, format: "jwk" }));
+    }
+    return keys;
+}
+
+async function getPublicKey(kid) {
+    const age = Date.now() - jwksFetchedAt;
+    const cached = jwksKeys.get(kid);
+    if (cached && age < JWKS_CACHE_TTL_MS) {
+        return cached;
+    }
 
-        return decoded; // this is your Claim
+    if (age > JWKS_MIN_INTERVAL_MS) {
+        try {
+            jwksKeys = await fetchJWKS();
+            jwksFetchedAt = Date.now();
+        } catch (err) {
+            // keep serving cached keys if auth is briefly unreachable
+            if (cached) return cached;
+            throw err;
+        }
+    }
+
+    const key = jwksKeys.get(kid);
+    if (!key) {
+        throw new Error("unknown signing key");
+    }
+    return key;
+}
+
+async function validateToken(token) {
+    const decoded = jwt.decode(token, { complete: true });
+    if (!decoded) {
+        throw new Error("invalid token");
+    }
+
+    try {
+        const key = await getPublicKey(decoded.header.kid);
+        return jwt.verify(token, key, {
+            algorithms: ["RS256"],
+        }); // this is your Claim
     } catch (err) {
         throw new Error(err.message || "invalid token");
     }
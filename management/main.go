@@ -19,6 +19,15 @@ func main() {
 	// 	log.Fatal("⚠️ Error loading .env file:", err)
 	// }
 
+	// `management migrate up|down [steps]|status` manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db.Connect()
+		if err := db.RunMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatal("❌ Migration failed: ", err)
+		}
+		return
+	}
+
 	db.PSQLInit()
 	db.MongoDBInit()
 
@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"management/src/db"
 	"management/src/dto"
+	"management/src/middleware"
 	"management/src/models"
 	"management/src/repository"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -26,6 +30,12 @@ var validate = validator.New()
 // Register single room
 // ------------------------------------
 func RegisterRoom(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var room models.Room
 
 	if err := json.NewDecoder(r.Body).Decode(&room); err != nil {
@@ -38,7 +48,7 @@ func RegisterRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := repository.CreateRoom(r.Context(), room)
+	err := repository.CreateRoom(r.Context(), authCtx.InstitutionID, room)
 	if err != nil {
 		log.Println("CreateRoom error:", err)
 		http.Error(w, "Failed to register room", http.StatusInternalServerError)
@@ -55,6 +65,12 @@ func RegisterRoom(w http.ResponseWriter, r *http.Request) {
 // Register multiple rooms
 // ------------------------------------
 func RegisterMultipleRoom(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var rooms []models.Room
 
 	if err := json.NewDecoder(r.Body).Decode(&rooms); err != nil {
@@ -76,8 +92,8 @@ func RegisterMultipleRoom(w http.ResponseWriter, r *http.Request) {
 		}
 
 		query := `
-			INSERT INTO rooms (room_id, rows, columns, branch)
-			VALUES ($1, $2, $3, $4)
+			INSERT INTO rooms (room_id, rows, columns, branch, institution_id)
+			VALUES ($1, $2, $3, $4, $5)
 		`
 
 		_, err := tx.Exec(
@@ -87,6 +103,7 @@ func RegisterMultipleRoom(w http.ResponseWriter, r *http.Request) {
 			room.Rows,
 			room.Columns,
 			room.Branch,
+			authCtx.InstitutionID,
 		)
 
 		if err != nil {
@@ -111,7 +128,13 @@ func RegisterMultipleRoom(w http.ResponseWriter, r *http.Request) {
 // Get all rooms
 // ------------------------------------
 func GetRooms(w http.ResponseWriter, r *http.Request) {
-	rooms, err := repository.GetAllRooms(r.Context())
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rooms, err := repository.GetAllRooms(r.Context(), authCtx.InstitutionID)
 	if err != nil {
 		log.Println("GetAllRooms error:", err)
 		http.Error(w, "Failed to fetch rooms", http.StatusInternalServerError)
@@ -126,6 +149,12 @@ func GetRooms(w http.ResponseWriter, r *http.Request) {
 // Mark attendance
 // ------------------------------------
 func MarkAttendance(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var attendance models.Attendance
 
 	if err := json.NewDecoder(r.Body).Decode(&attendance); err != nil {
@@ -138,7 +167,7 @@ func MarkAttendance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := repository.MarkAttendance(r.Context(), attendance)
+	err := repository.MarkAttendance(r.Context(), authCtx.InstitutionID, attendance)
 	if err != nil {
 		log.Println("MarkAttendance error:", err)
 		http.Error(w, "Failed to mark attendance", http.StatusInternalServerError)
@@ -151,7 +180,72 @@ func MarkAttendance(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// fetchSeatingStudents pages through auth's student search for the cohort
+// being seated, forwarding the caller's token so auth scopes it to their
+// institution. Graduated and withdrawn students are left out. On failure it
+// also returns the status to answer with.
+func fetchSeatingStudents(ctx context.Context, r *http.Request, prefix dto.Prefix) ([]models.Student, int, error) {
+	authURI := os.Getenv("AUTH_URI")
+	if authURI == "" {
+		return nil, http.StatusInternalServerError, fmt.Errorf("AUTH_URI not configured")
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	params := url.Values{}
+	params.Set("roll_prefix", prefix.Prefix)
+	params.Set("branch", prefix.Branch)
+	params.Set("semester", strconv.Itoa(prefix.Semester))
+	params.Set("active", "true")
+	params.Set("academic_status", "enrolled,detained")
+	params.Set("limit", "500")
+
+	students := []models.Student{}
+	for {
+		authReq, err := http.NewRequestWithContext(ctx, "GET", authURI+"/students?"+params.Encode(), nil)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		authReq.Header.Set("Accept", "application/json")
+		authReq.Header.Set("Authorization", r.Header.Get("Authorization"))
+
+		authResp, err := client.Do(authReq)
+		if err != nil {
+			return nil, http.StatusBadGateway, err
+		}
+
+		var page struct {
+			Students   []models.Student `json:"students"`
+			NextCursor string           `json:"next_cursor"`
+		}
+		if authResp.StatusCode < 200 || authResp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(authResp.Body, 1024))
+			authResp.Body.Close()
+			// forward auth service status
+			return nil, authResp.StatusCode, fmt.Errorf("auth service returned %s: %s", authResp.Status, bytes.TrimSpace(body))
+		}
+		err = json.NewDecoder(authResp.Body).Decode(&page)
+		authResp.Body.Close()
+		if err != nil {
+			return nil, http.StatusBadGateway, err
+		}
+
+		students = append(students, page.Students...)
+		if page.NextCursor == "" {
+			return students, http.StatusOK, nil
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+}
+
 func GenerateSeatingArrangement(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	ctx := r.Context()
 
@@ -171,66 +265,15 @@ func GenerateSeatingArrangement(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	authURI := os.Getenv("AUTH_URI")
-	if authURI == "" {
-		log.Printf("AUTH_URI not configured")
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-	authURI = authURI + "/get/studentlist"
-
-	jsonPrefix, err := json.Marshal(prefix)
+	studentList, status, err := fetchSeatingStudents(ctx, r, prefix)
 	if err != nil {
-		log.Printf("failed to marshal prefix: %v", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// preserve your GET usage but attach context and headers, and handle errors properly
-	authReq, err := http.NewRequestWithContext(ctx, "GET", authURI, bytes.NewBuffer(jsonPrefix))
-	if err != nil {
-		log.Printf("error creating auth request: %v", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-	authReq.Header.Set("Accept", "application/json")
-	authReq.Header.Set("Content-Type", "application/json") // keep header so receivers that expect it can parse
-
-	authResp, err := client.Do(authReq)
-	if err != nil {
-		log.Printf("auth service request failed: %v", err)
-		http.Error(w, "auth service request failed", http.StatusBadGateway)
-		return
-	}
-	defer func() {
-		if cerr := authResp.Body.Close(); cerr != nil {
-			log.Printf("error closing auth response body: %v", cerr)
-		}
-	}()
-
-	if authResp.StatusCode < 200 || authResp.StatusCode >= 300 {
-		// forward auth service status and body
-		w.Header().Set("Content-Type", authResp.Header.Get("Content-Type"))
-		w.WriteHeader(authResp.StatusCode)
-		if _, err := io.Copy(w, authResp.Body); err != nil {
-			log.Printf("failed to forward auth error body: %v", err)
-		}
-		return
-	}
-
-	var studentList []models.Student
-	if err := json.NewDecoder(authResp.Body).Decode(&studentList); err != nil {
-		log.Printf("failed to decode student list from auth service: %v", err)
-		http.Error(w, "failed to decode student list", http.StatusInternalServerError)
+		log.Printf("failed to fetch students from auth service: %v", err)
+		http.Error(w, "failed to fetch students: "+err.Error(), status)
 		return
 	}
 
 	//postgres to get room list
-	rooms, err := repository.GetAllRooms(ctx)
+	rooms, err := repository.GetAllRooms(ctx, authCtx.InstitutionID)
 	if err != nil {
 		log.Printf("failed to fetch rooms: %v", err)
 		http.Error(w, "failed to fetch rooms", http.StatusInternalServerError)
@@ -250,6 +293,10 @@ func GenerateSeatingArrangement(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
 	llmURI := os.Getenv("LLM_URI")
 	if llmURI == "" {
 		log.Printf("LLM_URI not configured")
@@ -288,7 +335,12 @@ func GenerateSeatingArrangement(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = db.GetSeatingCollection().InsertOne(ctx, seatingArrangement)
+	_, err = db.GetSeatingCollection().InsertOne(ctx, models.SeatingArrangementList{
+		ID:            primitive.NewObjectID(),
+		InstitutionID: authCtx.InstitutionID,
+		SeatingList:   seatingArrangement,
+		CreatedAt:     time.Now(),
+	})
 	if err != nil {
 		log.Printf("failed to store seating list in mongodb: %v", err)
 		http.Error(w, "Failed to store seating arrangement in db", http.StatusInternalServerError)
@@ -308,6 +360,12 @@ func GenerateSeatingArrangement(w http.ResponseWriter, r *http.Request) {
 }
 
 func ScheduleExam(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var req dto.ScheduleExamRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -327,17 +385,24 @@ func ScheduleExam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Teachers only schedule exams of the courses they are assigned
+	if !middleware.RequireCourse(w, r, req.Subject, req.Branch, req.Semester, req.Section) {
+		return
+	}
+
 	exam := models.ScheduleExam{
-		ExamID:     objectExamID,
-		Title:      req.Title,
-		Subject:    req.Subject,
-		Branch:     req.Branch,
-		Semester:   req.Semester,
-		Date:       req.Date,
-		StartTime:  req.StartTime,
-		EndTime:    req.EndTime,
-		TotalMarks: req.TotalMarks,
-		CreatedAt:  time.Now(),
+		InstitutionID: authCtx.InstitutionID,
+		ExamID:        objectExamID,
+		Title:         req.Title,
+		Subject:       req.Subject,
+		Branch:        req.Branch,
+		Semester:      req.Semester,
+		Section:       req.Section,
+		Date:          req.Date,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		TotalMarks:    req.TotalMarks,
+		CreatedAt:     time.Now(),
 	}
 
 	collection := db.GetExamScheduleCollection()
@@ -356,6 +421,12 @@ func ScheduleExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetScheduledExams(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	collection := db.GetExamScheduleCollection()
 
 	branch := chi.URLParam(r, "branch")
@@ -364,7 +435,7 @@ func GetScheduledExams(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := collection.Find(ctx, bson.M{"branch": branch, "semester": semester})
+	cursor, err := collection.Find(ctx, bson.M{"institution_id": authCtx.InstitutionID, "branch": branch, "semester": semester})
 	if err != nil {
 		http.Error(w, "Failed to fetch exams", http.StatusInternalServerError)
 		return
@@ -382,7 +453,79 @@ func GetScheduledExams(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(exams)
 }
 
+// examLocation is EXAM_TIMEZONE (e.g. Asia/Kolkata), the zone exam start and
+// end times are written in; the server's zone when unset
+func examLocation() *time.Location {
+	if name := os.Getenv("EXAM_TIMEZONE"); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+		log.Printf("⚠️ Unknown EXAM_TIMEZONE %q, using local time", name)
+	}
+	return time.Local
+}
+
+// GetExamsInProgress lists the exams of a branch and semester running right
+// now. Auth asks before letting a student open another session.
+func GetExamsInProgress(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	branch := r.URL.Query().Get("branch")
+	semester := r.URL.Query().Get("semester")
+	if branch == "" || semester == "" {
+		http.Error(w, "branch and semester are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// Date holds the day only; look a day either side and check the times below
+	now := time.Now()
+	filter := bson.M{
+		"institution_id": authCtx.InstitutionID,
+		"branch":         branch,
+		"semester":       semester,
+		"date":           bson.M{"$gte": now.Add(-48 * time.Hour), "$lte": now.Add(24 * time.Hour)},
+	}
+
+	var exams []models.ScheduleExam
+	cursor, err := db.GetExamScheduleCollection().Find(ctx, filter)
+	if err == nil {
+		err = cursor.All(ctx, &exams)
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch exams", http.StatusInternalServerError)
+		return
+	}
+
+	loc := examLocation()
+	running := []models.ScheduleExam{}
+	for _, exam := range exams {
+		start, end, ok := exam.Window(loc)
+		if ok && !now.Before(start) && now.Before(end) {
+			running = append(running, exam)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"in_progress": len(running) > 0,
+		"exams":       running,
+	})
+}
+
 func GetExamDetails(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	scheduleID := chi.URLParam(r, "scheduleID")
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
@@ -397,7 +540,7 @@ func GetExamDetails(w http.ResponseWriter, r *http.Request) {
 	collection := db.GetExamScheduleCollection()
 
 	var exam models.ScheduleExam
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&exam)
+	err = collection.FindOne(ctx, bson.M{"_id": objectID, "institution_id": authCtx.InstitutionID}).Decode(&exam)
 	if err != nil {
 		http.Error(w, "Exam not found", http.StatusNotFound)
 		return
@@ -408,7 +551,24 @@ func GetExamDetails(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(exam)
 }
 
+// requireScheduleCourse loads the scheduled exam matching filter and checks
+// that the caller may manage its course; it writes 404 or 403 otherwise
+func requireScheduleCourse(ctx context.Context, w http.ResponseWriter, r *http.Request, filter bson.M) bool {
+	var exam models.ScheduleExam
+	if err := db.GetExamScheduleCollection().FindOne(ctx, filter).Decode(&exam); err != nil {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return false
+	}
+	return middleware.RequireCourse(w, r, exam.Subject, exam.Branch, exam.Semester, exam.Section)
+}
+
 func DeleteScheduledExam(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	scheduleID := chi.URLParam(r, "scheduleID")
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
@@ -421,8 +581,13 @@ func DeleteScheduledExam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	collection := db.GetExamScheduleCollection()
+	filter := bson.M{"_id": objectID, "institution_id": authCtx.InstitutionID}
+
+	if !requireScheduleCourse(ctx, w, r, filter) {
+		return
+	}
 
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	result, err := collection.DeleteOne(ctx, filter)
 	if err != nil {
 		http.Error(w, "Failed to delete schedule", http.StatusInternalServerError)
 		return
@@ -441,6 +606,12 @@ func DeleteScheduledExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func UpdateExamTime(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	scheduleID := chi.URLParam(r, "scheduleID")
 
 	objectID, err := primitive.ObjectIDFromHex(scheduleID)
@@ -462,6 +633,11 @@ func UpdateExamTime(w http.ResponseWriter, r *http.Request) {
 	}
 
 	collection := db.GetExamScheduleCollection()
+	filter := bson.M{"_id": objectID, "institution_id": authCtx.InstitutionID}
+
+	if !requireScheduleCourse(r.Context(), w, r, filter) {
+		return
+	}
 
 	update := bson.M{
 		"$set": bson.M{
@@ -473,7 +649,7 @@ func UpdateExamTime(w http.ResponseWriter, r *http.Request) {
 
 	result, err := collection.UpdateOne(
 		context.TODO(),
-		bson.M{"_id": objectID},
+		filter,
 		update,
 	)
 	if err != nil {
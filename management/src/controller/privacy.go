@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"management/src/db"
+	"management/src/dto"
+	"management/src/middleware"
+	"management/src/models"
+	"management/src/repository"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SeatRecord is one seat a student was given in a seating arrangement
+type SeatRecord struct {
+	ArrangementID string    `json:"arrangement_id"`
+	RoomID        string    `json:"room_id"`
+	Bench         int       `json:"bench"`
+	Side          string    `json:"side"` // left | right
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func decodePrivacySubject(w http.ResponseWriter, r *http.Request) (middleware.AuthContext, dto.PrivacySubject, bool) {
+	var subject dto.PrivacySubject
+
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return authCtx, subject, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return authCtx, subject, false
+	}
+	if err := validate.Struct(subject); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return authCtx, subject, false
+	}
+
+	return authCtx, subject, true
+}
+
+// seatedFilter matches arrangements that seat one of ids (see
+// PrivacySubject.SeatIDs); student_arragement is a list of benches, each a
+// list of seats
+func seatedFilter(institutionID string, ids []string) bson.M {
+	return bson.M{
+		"institution_id": institutionID,
+		"seating_list.student_arragement": bson.M{
+			"$elemMatch": bson.M{"$elemMatch": bson.M{"$in": ids}},
+		},
+	}
+}
+
+// ExportStudentData returns a student's attendance and seats
+func ExportStudentData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	ids := subject.IDs()
+	attendance, err := repository.GetAttendanceByStudent(ctx, authCtx.InstitutionID, ids)
+	if err != nil {
+		http.Error(w, "Failed to fetch attendance", http.StatusInternalServerError)
+		return
+	}
+
+	seatIDs := subject.SeatIDs()
+	var arrangements []models.SeatingArrangementList
+	cursor, err := db.GetSeatingCollection().Find(ctx, seatedFilter(authCtx.InstitutionID, seatIDs))
+	if err == nil {
+		err = cursor.All(ctx, &arrangements)
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch seating arrangements", http.StatusInternalServerError)
+		return
+	}
+
+	isStudent := map[string]bool{}
+	for _, id := range seatIDs {
+		isStudent[id] = true
+	}
+	seats := []SeatRecord{}
+	for _, a := range arrangements {
+		for _, room := range a.SeatingList {
+			for bench, row := range room.StudentArragement {
+				for side, id := range row {
+					if !isStudent[id] {
+						continue
+					}
+					seat := SeatRecord{
+						ArrangementID: a.ID.Hex(),
+						RoomID:        room.RoomID,
+						Bench:         bench + 1,
+						Side:          "left",
+						CreatedAt:     a.CreatedAt,
+					}
+					if side == 1 {
+						seat.Side = "right"
+					}
+					seats = append(seats, seat)
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attendance": attendance,
+		"seats":      seats,
+	})
+}
+
+// EraseStudentData moves a student's attendance rows and seats (by roll
+// number) to their pseudonym; attendance counts and seating plans stay as
+// they were
+func EraseStudentData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r)
+	if !ok {
+		return
+	}
+	if !strings.HasPrefix(subject.Pseudonym, "erased-") {
+		http.Error(w, "pseudonym is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	ids := subject.IDs()
+	attendance, err := repository.PseudonymiseAttendance(ctx, authCtx.InstitutionID, ids, subject.Pseudonym)
+	if err != nil {
+		http.Error(w, "Failed to erase attendance", http.StatusInternalServerError)
+		return
+	}
+
+	seatIDs := subject.SeatIDs()
+	result, err := db.GetSeatingCollection().UpdateMany(ctx,
+		seatedFilter(authCtx.InstitutionID, seatIDs),
+		bson.M{"$set": bson.M{"seating_list.$[].student_arragement.$[].$[seat]": subject.Pseudonym}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"seat": bson.M{"$in": seatIDs}}},
+		}),
+	)
+	if err != nil {
+		http.Error(w, "Failed to erase seating arrangements", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attendance":           attendance,
+		"seating_arrangements": result.ModifiedCount,
+	})
+}
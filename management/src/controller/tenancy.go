@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"management/src/db"
+	"management/src/middleware"
+	"net/http"
+
+	"neuroiq/shared/tenancy"
+)
+
+// BackfillInstitution assigns attendance, rooms, schedules and seating
+// plans written before tenancy to the institution of the calling token
+func BackfillInstitution(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	tenancy.Serve(w, r, authCtx.InstitutionID, db.AssignInstitution)
+}
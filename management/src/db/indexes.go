@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// collectionIndexes are created at startup; CreateMany is a no-op for
+// indexes that already exist with the same keys and options
+func collectionIndexes() map[*mongo.Collection][]mongo.IndexModel {
+	return map[*mongo.Collection][]mongo.IndexModel{
+		examScheduleCollection: {
+			{Keys: bson.D{{Key: "institution_id", Value: 1}, {Key: "branch", Value: 1}, {Key: "semester", Value: 1}, {Key: "date", Value: 1}}},
+		},
+		seatingCollection: {
+			{Keys: bson.D{{Key: "institution_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+	}
+}
+
+func ensureIndexes(ctx context.Context) {
+	for collection, indexes := range collectionIndexes() {
+		if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+			log.Fatalf("❌ Failed creating %s indexes: %v", collection.Name(), err)
+		}
+	}
+	fmt.Println("✅ Mongo indexes ready")
+}
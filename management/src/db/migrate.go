@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"embed"
+
+	"neuroiq/shared/migrate"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Migrations live in migrations/ and are run by the shared migrate package
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrMigrationLocked means another process holds the migration lock
+var ErrMigrationLocked = migrate.ErrLocked
+
+var migrator = &migrate.Migrator{Files: migrationFiles, Dir: "migrations", Store: pgxStore{}, Name: "management"}
+
+// MigrationStatus lists every known migration and whether it has been applied
+func MigrationStatus() ([]migrate.State, error) {
+	return migrator.Status()
+}
+
+// PendingMigrations returns the migrations not applied yet, failing if an
+// applied one was modified afterwards
+func PendingMigrations() ([]migrate.Migration, error) {
+	return migrator.Pending()
+}
+
+// MigrateUp applies every pending migration and returns how many were applied
+func MigrateUp() (int, error) {
+	return migrator.Up()
+}
+
+// RunMigrateCommand implements `management migrate up|down [steps]|status`
+func RunMigrateCommand(args []string) error {
+	return migrator.RunCommand(args)
+}
+
+// pgxStore runs the migrations on the pool
+type pgxStore struct{}
+
+func (pgxStore) Exec(query string, args ...interface{}) (int64, error) {
+	res, err := DB.Exec(context.Background(), query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func (pgxStore) ExecInTx(script string, record string, args ...interface{}) error {
+	ctx := context.Background()
+
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Without arguments pgx uses the simple protocol, which allows several statements
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (pgxStore) Applied() ([]migrate.Applied, error) {
+	rows, err := DB.Query(context.Background(), migrate.AppliedSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []migrate.Applied
+	for rows.Next() {
+		var a migrate.Applied
+		if err := rows.Scan(&a.Version, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+func (pgxStore) LockHolder() (string, error) {
+	var holder *string
+	err := DB.QueryRow(context.Background(), migrate.LockHolderSQL).Scan(&holder)
+	if err != nil && err != pgx.ErrNoRows {
+		return "", err
+	}
+	if holder == nil {
+		return "", nil
+	}
+	return *holder, nil
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS exam_attendance;
+DROP TABLE IF EXISTS rooms;
@@ -0,0 +1,33 @@
+-- Schema as it stood when versioned migrations were introduced. Every
+-- statement is idempotent so databases bootstrapped by the old startup DDL
+-- adopt it without changes.
+
+-- 1. Rooms
+CREATE TABLE IF NOT EXISTS rooms (
+	room_id VARCHAR(50) PRIMARY KEY,
+	capacity INT NOT NULL,
+	rows INT NOT NULL,
+	columns INT NOT NULL,
+	restricted_branch TEXT,        -- Students of this branch cannot sit here
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- 2. Attendance table
+CREATE TABLE IF NOT EXISTS exam_attendance (
+	id SERIAL PRIMARY KEY,
+	exam_id VARCHAR(50) NOT NULL,
+	student_id VARCHAR(50) NOT NULL,
+	room_id VARCHAR(50) NOT NULL,
+	seat_no INT NOT NULL,
+	status TEXT CHECK (status IN ('present', 'absent', 'malpractice')) NOT NULL,
+	answer_sheet_id TEXT,
+	timestamp TIMESTAMP DEFAULT NOW(),
+
+	UNIQUE(exam_id, student_id)
+);
+
+-- 3. Tenancy: rows belong to the institution of the user who wrote them
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS institution_id VARCHAR(50);
+ALTER TABLE exam_attendance ADD COLUMN IF NOT EXISTS institution_id VARCHAR(50);
+CREATE INDEX IF NOT EXISTS idx_rooms_institution ON rooms (institution_id);
+CREATE INDEX IF NOT EXISTS idx_exam_attendance_institution ON exam_attendance (institution_id, exam_id);
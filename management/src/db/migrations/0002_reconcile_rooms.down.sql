@@ -0,0 +1,12 @@
+-- Fails if two institutions use the same room id, since room_id becomes the key again
+ALTER TABLE rooms DROP CONSTRAINT IF EXISTS rooms_institution_room_key;
+ALTER TABLE rooms DROP COLUMN IF EXISTS id;
+ALTER TABLE rooms ADD PRIMARY KEY (room_id);
+
+ALTER TABLE rooms ADD COLUMN capacity INT;
+UPDATE rooms SET capacity = rows * columns;
+ALTER TABLE rooms ALTER COLUMN capacity SET NOT NULL;
+
+ALTER TABLE rooms ALTER COLUMN branch DROP NOT NULL;
+ALTER TABLE rooms ALTER COLUMN branch DROP DEFAULT;
+ALTER TABLE rooms RENAME COLUMN branch TO restricted_branch;
@@ -0,0 +1,27 @@
+-- The repository reads and writes rooms.branch (the branch that may not sit in
+-- the room) and never set capacity, so inserts failed on the NOT NULL column.
+-- restricted_branch becomes branch, capacity goes, and room ids only need to
+-- be unique within an institution.
+DO $$
+BEGIN
+	IF EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'rooms' AND column_name = 'restricted_branch'
+	) AND NOT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'rooms' AND column_name = 'branch'
+	) THEN
+		ALTER TABLE rooms RENAME COLUMN restricted_branch TO branch;
+	END IF;
+END $$;
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS branch TEXT;
+ALTER TABLE rooms DROP COLUMN IF EXISTS restricted_branch;
+ALTER TABLE rooms DROP COLUMN IF EXISTS capacity;
+UPDATE rooms SET branch = '' WHERE branch IS NULL;
+ALTER TABLE rooms ALTER COLUMN branch SET DEFAULT '';
+ALTER TABLE rooms ALTER COLUMN branch SET NOT NULL;
+
+ALTER TABLE rooms DROP CONSTRAINT IF EXISTS rooms_pkey;
+ALTER TABLE rooms ADD COLUMN id BIGSERIAL PRIMARY KEY;
+ALTER TABLE rooms ADD CONSTRAINT rooms_institution_room_key UNIQUE (institution_id, room_id);
@@ -0,0 +1,3 @@
+-- Fails if two institutions hold attendance for the same exam and student ids
+ALTER TABLE exam_attendance DROP CONSTRAINT IF EXISTS exam_attendance_institution_exam_student_key;
+ALTER TABLE exam_attendance ADD CONSTRAINT exam_attendance_exam_id_student_id_key UNIQUE (exam_id, student_id);
@@ -0,0 +1,4 @@
+-- Exam and student ids are only unique within an institution, so attendance
+-- is keyed on all three; otherwise one institution's row blocks another's
+ALTER TABLE exam_attendance DROP CONSTRAINT IF EXISTS exam_attendance_exam_id_student_id_key;
+ALTER TABLE exam_attendance ADD CONSTRAINT exam_attendance_institution_exam_student_key UNIQUE (institution_id, exam_id, student_id);
@@ -29,4 +29,6 @@ func MongoDBInit() {
 
 	seatingCollection = client.Database("NeuroIQ_ManagementDB").Collection("seating")
 	examScheduleCollection = client.Database("NeuroIQ_ManagementDB").Collection("exam_schedule")
-}
\ No newline at end of file
+
+	ensureIndexes(ctx)
+}
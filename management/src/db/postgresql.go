@@ -13,8 +13,31 @@ import (
 // Global DB Pool
 var DB *pgxpool.Pool
 
-// ConnectDB creates connection and initializes tables
+// PSQLInit connects and brings the schema up to date. With AUTO_MIGRATE=false
+// it only refuses to start while migrations are pending, leaving them to
+// `management migrate up`.
 func PSQLInit() {
+	Connect()
+
+	if os.Getenv("AUTO_MIGRATE") == "false" {
+		pending, err := PendingMigrations()
+		if err != nil {
+			log.Fatalf("❌ Failed to check migrations: %v", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("❌ %d pending migrations; run `management migrate up`", len(pending))
+		}
+	} else {
+		applied, err := MigrateUp()
+		if err != nil {
+			log.Fatalf("❌ Failed to run migrations: %v", err)
+		}
+		fmt.Printf("✅ Schema up to date (%d migrations applied)\n", applied)
+	}
+}
+
+// Connect creates the pool without touching the schema
+func Connect() {
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
 		log.Fatal("DATABASE_URL is not set")
@@ -36,47 +59,4 @@ func PSQLInit() {
 
 	DB = pool
 	fmt.Println("✅ Connected to PostgreSQL")
-
-	createTables()
-}
-
-// Create required tables
-func createTables() {
-	tables := []string{
-		
-		// 1. Rooms
-		`CREATE TABLE IF NOT EXISTS rooms (
-			room_id VARCHAR(50) PRIMARY KEY,
-			capacity INT NOT NULL,
-			rows INT NOT NULL,
-			columns INT NOT NULL,
-			restricted_branch TEXT,        -- Students of this branch cannot sit here
-			created_at TIMESTAMP DEFAULT NOW()
-		);`,
-
-	
-
-		// 2. Attendance table
-		`CREATE TABLE IF NOT EXISTS exam_attendance (
-			id SERIAL PRIMARY KEY,
-			exam_id VARCHAR(50) NOT NULL,
-			student_id VARCHAR(50) NOT NULL,
-			room_id VARCHAR(50) NOT NULL,
-			seat_no INT NOT NULL,
-			status TEXT CHECK (status IN ('present', 'absent', 'malpractice')) NOT NULL,
-			answer_sheet_id TEXT,
-			timestamp TIMESTAMP DEFAULT NOW(),
-
-			UNIQUE(exam_id, student_id)
-		);`,
-	}
-
-	for _, query := range tables {
-		_, err := DB.Exec(context.Background(), query)
-		if err != nil {
-			log.Fatalf("❌ Failed creating table: %v", err)
-		}
-	}
-
-	fmt.Println("✅ All tables initialized")
 }
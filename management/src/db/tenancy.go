@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+
+	"neuroiq/shared/tenancy"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Rooms, schedules and seating plans have no owner. Each follows the
+// attendance rows that use it, and is left untagged while those rows belong
+// to more than one institution or to none yet.
+const (
+	assignAttendanceSQL = `
+		UPDATE exam_attendance SET institution_id = $1
+		WHERE institution_id IS NULL AND student_id = ANY($2)
+	`
+	assignRoomsSQL = `
+		UPDATE rooms r SET institution_id = $1
+		WHERE r.institution_id IS NULL
+		AND EXISTS (SELECT 1 FROM exam_attendance a WHERE a.room_id = r.room_id AND a.institution_id = $1)
+		AND NOT EXISTS (SELECT 1 FROM exam_attendance a WHERE a.room_id = r.room_id AND a.institution_id IS DISTINCT FROM $1)
+	`
+	institutionExamsSQL = `
+		SELECT exam_id FROM exam_attendance WHERE institution_id = $1
+		EXCEPT
+		SELECT exam_id FROM exam_attendance WHERE institution_id IS DISTINCT FROM $1
+	`
+	institutionRoomsSQL = `
+		SELECT room_id FROM rooms WHERE institution_id = $1
+		EXCEPT
+		SELECT room_id FROM rooms WHERE institution_id IS DISTINCT FROM $1
+	`
+	untaggedRowsSQL = `
+		SELECT (SELECT COUNT(*) FROM exam_attendance WHERE institution_id IS NULL)
+		     + (SELECT COUNT(*) FROM rooms WHERE institution_id IS NULL)
+	`
+)
+
+// AssignInstitution gives the attendance of owners' student records written
+// before tenancy their institution, then the rooms, exam schedules and
+// seating plans that only that institution's attendance refers to
+func AssignInstitution(ctx context.Context, institutionID string, owners tenancy.Owners) (tenancy.Result, error) {
+	var result tenancy.Result
+
+	if len(owners.StudentIDs) > 0 {
+		res, err := DB.Exec(ctx, assignAttendanceSQL, institutionID, owners.StudentIDs)
+		if err != nil {
+			return result, err
+		}
+		result.Assigned += res.RowsAffected()
+	}
+
+	res, err := DB.Exec(ctx, assignRoomsSQL, institutionID)
+	if err != nil {
+		return result, err
+	}
+	result.Assigned += res.RowsAffected()
+
+	examIDs, err := selectIDs(ctx, institutionExamsSQL, institutionID)
+	if err != nil {
+		return result, err
+	}
+	exams := []primitive.ObjectID{}
+	for _, id := range examIDs {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			exams = append(exams, oid)
+		}
+	}
+	if len(exams) > 0 {
+		filter := bson.M{"institution_id": bson.M{"$exists": false}, "exam_id": bson.M{"$in": exams}}
+		res, err := examScheduleCollection.UpdateMany(ctx, filter, tenancy.Assign(institutionID))
+		if err != nil {
+			return result, err
+		}
+		result.Assigned += res.ModifiedCount
+	}
+
+	rooms, err := selectIDs(ctx, institutionRoomsSQL, institutionID)
+	if err != nil {
+		return result, err
+	}
+	if len(rooms) > 0 {
+		filter := bson.M{
+			"institution_id":       bson.M{"$exists": false},
+			"seating_list.room_id": bson.M{"$in": rooms},
+			"seating_list":         bson.M{"$not": bson.M{"$elemMatch": bson.M{"room_id": bson.M{"$nin": rooms}}}},
+		}
+		res, err := seatingCollection.UpdateMany(ctx, filter, tenancy.Assign(institutionID))
+		if err != nil {
+			return result, err
+		}
+		result.Assigned += res.ModifiedCount
+	}
+
+	if err := DB.QueryRow(ctx, untaggedRowsSQL).Scan(&result.Remaining); err != nil {
+		return result, err
+	}
+	for _, collection := range []*mongo.Collection{seatingCollection, examScheduleCollection} {
+		remaining, err := collection.CountDocuments(ctx, tenancy.Untagged())
+		if err != nil {
+			return result, err
+		}
+		result.Remaining += remaining
+	}
+	return result, nil
+}
+
+// selectIDs runs a query returning one text column
+func selectIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -8,12 +8,21 @@ import (
 )
 
 type Claim struct {
-	ID    string
-	Email string
-	Role  string
+	ID            string
+	Email         string
+	Role          string
+	InstitutionID string
+	Scope         string `json:"scope,omitempty"` // service tokens only, space separated
+	Actor         *ActorClaim `json:"act,omitempty"` // set when an admin impersonates the user
 	jwt.RegisteredClaims
 }
 
+// ActorClaim names the admin holding an impersonation token
+type ActorClaim struct {
+	ID    string `json:"sub"`
+	Email string `json:"email,omitempty"`
+}
+
 type Prefix struct {
 	Prefix   string `json:"prefix" validate:"required"`
 	Branch   string `json:"branch" validate:"required"`
@@ -31,6 +40,7 @@ type ScheduleExamRequest struct {
 	Subject    string    `json:"subject" validate:"required"`
 	Branch     string    `json:"branch" validate:"required"`
 	Semester   string    `json:"semester" validate:"required"`
+	Section    string    `json:"section"` // empty for every section
 	Date       time.Time `json:"date" validate:"required"` // YYYY-MM-DD
 	StartTime  string    `json:"start_time" validate:"required"`
 	EndTime    string    `json:"end_time" validate:"required"`
@@ -42,3 +52,30 @@ type UpdateExamTimeRequest struct {
 	StartTime string    `json:"start_time" validate:"required"`
 	EndTime   string    `json:"end_time" validate:"required"`
 }
+
+// PrivacySubject names the user whose data auth exports or erases.
+// Attendance holds the student record ID and seating the roll number; the
+// pseudonym replaces both on erasure.
+type PrivacySubject struct {
+	UserID     string `json:"user_id" validate:"required"`
+	StudentID  string `json:"student_id"`
+	RollNumber string `json:"roll_number"`
+	Pseudonym  string `json:"pseudonym"`
+}
+
+// IDs are the values a student may be recorded under
+func (s PrivacySubject) IDs() []string {
+	if s.StudentID == "" {
+		return []string{s.UserID}
+	}
+	return []string{s.UserID, s.StudentID}
+}
+
+// SeatIDs are the values a student may be seated under: the LLM fills
+// seats with roll numbers, older arrangements may hold IDs
+func (s PrivacySubject) SeatIDs() []string {
+	if s.RollNumber == "" {
+		return s.IDs()
+	}
+	return append(s.IDs(), s.RollNumber)
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"neuroiq/shared/courses"
+)
+
+// RequireCourse lets admins through and checks that a teacher is assigned to
+// the course; subject, branch and semester are required, section may be empty
+// for every section. Anyone else is refused. It writes the error response and
+// returns false when the caller may not proceed.
+func RequireCourse(w http.ResponseWriter, r *http.Request, subject string, branch string, semester string, section string) bool {
+	authCtx, ok := r.Context().Value(AuthKey).(AuthContext)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return courses.Require(w, r, authCtx.Role, authCtx.UserID, subject, branch, semester, section)
+}
@@ -7,21 +7,49 @@ import (
 	"net/http"
 	"strings"
 
+	"neuroiq/shared/authctx"
+
 )
 
 
+// AuthContext is the caller stored under AuthKey, with the claims of its token
 type AuthContext struct {
-	UserID string
-	Email  string
-	Role   string
+	authctx.Context
 	Claims *dto.Claim
 }
+
 type contextKey string
 
 const AuthKey contextKey = "auth_context"
 
+// ScopeExamsRead is the scope of the token auth uses to check for running exams
+const ScopeExamsRead = "exams:read"
+
 
+// AuthMiddleware admits admins and teachers, who run rooms, seating and schedules
 func AuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, func(a AuthContext) bool {
+		return a.Role == "admin" || a.Role == "teacher"
+	})
+}
+
+// AnyRoleMiddleware admits every signed-in user, e.g. students reading their exam schedule
+func AnyRoleMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, func(a AuthContext) bool {
+		return !a.Service
+	})
+}
+
+// ServiceMiddleware admits service tokens granted scope and nothing else
+func ServiceMiddleware(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authenticate(next, func(a AuthContext) bool {
+			return a.Service && a.HasScope(scope)
+		})
+	}
+}
+
+func authenticate(next http.Handler, allowed func(a AuthContext) bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		authHeader := r.Header.Get("Authorization")
@@ -44,19 +72,34 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		if claims.Role != "admin" && claims.Role != "teacher" {
-			http.Error(w , "Not Authorized for the service" , http.StatusUnauthorized)
+		// Every query is scoped to the caller's institution, so a token without one is useless
+		if claims.InstitutionID == "" {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
 
 		// Store multiple values in context
 		authCtx := AuthContext{
-			UserID: claims.ID,
-			Email:  claims.Email,
-			Role:   claims.Role,
+			Context: authctx.Context{
+				UserID:        claims.ID,
+				Email:         claims.Email,
+				Role:          claims.Role,
+				InstitutionID: claims.InstitutionID,
+				Service:       claims.Role == "service",
+				Scopes:        strings.Fields(claims.Scope),
+				ActorID:       actorID(claims),
+			},
 			Claims: claims,
 		}
 
+		if !allowed(authCtx) {
+			http.Error(w , "Not Authorized for the service" , http.StatusUnauthorized)
+			return
+		}
+		if !allowImpersonation(w, r, authCtx, tokenString) {
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), AuthKey, authCtx)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
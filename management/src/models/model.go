@@ -31,8 +31,10 @@ type SeatingArragement struct {
 }
 
 type SeatingArrangementList struct {
-	ID          primitive.ObjectID  `bson:"_id" json:"_id"`
-	SeatingList []SeatingArragement `bson:"seating_list" josn:"seating_list"`
+	ID            primitive.ObjectID  `bson:"_id" json:"_id"`
+	InstitutionID string              `bson:"institution_id" json:"institution_id"`
+	SeatingList   []SeatingArragement `bson:"seating_list" josn:"seating_list"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
 }
 
 type Student struct {
@@ -57,14 +59,35 @@ type Student struct {
 }
 
 type ScheduleExam struct {
-	ExamID     primitive.ObjectID `bson:"exam_id" json:"exam_id"`
-	Title      string             `bson:"title" json:"title"`
-	Subject    string             `bson:"subject" json:"subject"`
-	Branch     string             `bson:"branch" json:"branch"`
-	Semester   string             `bson:"semester" json:"semester"`
-	Date       time.Time          `bson:"date" json:"date"`
-	StartTime  string             `bson:"start_time" json:"start_time"`
-	EndTime    string             `bson:"end_time" json:"end_time"`
-	TotalMarks int                `bson:"total_marks" json:"total_marks"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	InstitutionID string             `bson:"institution_id" json:"institution_id"`
+	ExamID        primitive.ObjectID `bson:"exam_id" json:"exam_id"`
+	Title         string             `bson:"title" json:"title"`
+	Subject       string             `bson:"subject" json:"subject"`
+	Branch        string             `bson:"branch" json:"branch"`
+	Semester      string             `bson:"semester" json:"semester"`
+	Section       string             `bson:"section,omitempty" json:"section,omitempty"` // empty for every section
+	Date          time.Time          `bson:"date" json:"date"`
+	StartTime     string             `bson:"start_time" json:"start_time"`
+	EndTime       string             `bson:"end_time" json:"end_time"`
+	TotalMarks    int                `bson:"total_marks" json:"total_marks"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Window is when the exam runs: the calendar day of Date with StartTime and
+// EndTime ("15:04") in loc. ok is false when either time does not parse.
+func (e ScheduleExam) Window(loc *time.Location) (start time.Time, end time.Time, ok bool) {
+	day := time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, loc)
+
+	from, err := time.ParseInLocation("15:04", e.StartTime, loc)
+	if err != nil {
+		return start, end, false
+	}
+	to, err := time.ParseInLocation("15:04", e.EndTime, loc)
+	if err != nil {
+		return start, end, false
+	}
+
+	start = day.Add(time.Duration(from.Hour())*time.Hour + time.Duration(from.Minute())*time.Minute)
+	end = day.Add(time.Duration(to.Hour())*time.Hour + time.Duration(to.Minute())*time.Minute)
+	return start, end, end.After(start)
 }
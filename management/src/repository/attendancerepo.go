@@ -6,31 +6,32 @@ import (
 	"management/src/models"
 )
 
-// Mark attendance
-func MarkAttendance(ctx context.Context, a models.Attendance) error {
+// Mark attendance; rows are keyed per institution, so a write only ever
+// overwrites its own institution's row
+func MarkAttendance(ctx context.Context, institutionID string, a models.Attendance) error {
 	query := `
-		INSERT INTO exam_attendance (exam_id, student_id, room_id, seat_no, status, answer_sheet_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (exam_id, student_id)
+		INSERT INTO exam_attendance (exam_id, student_id, room_id, seat_no, status, answer_sheet_id, institution_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (institution_id, exam_id, student_id)
 		DO UPDATE SET status = EXCLUDED.status, answer_sheet_id = EXCLUDED.answer_sheet_id
 	`
 
 	_, err := db.DB.Exec(ctx, query,
-		a.ExamID, a.StudentID, a.RoomID, a.SeatNo, a.Status, a.AnswerSheetID,
+		a.ExamID, a.StudentID, a.RoomID, a.SeatNo, a.Status, a.AnswerSheetID, institutionID,
 	)
 	return err
 }
 
 // Room-wise attendance
-func GetAttendanceByRoom(ctx context.Context, examID, roomID string) ([]models.Attendance, error) {
+func GetAttendanceByRoom(ctx context.Context, institutionID, examID, roomID string) ([]models.Attendance, error) {
 	query := `
 		SELECT id, exam_id, student_id, room_id, seat_no, status, answer_sheet_id
 		FROM exam_attendance
-		WHERE exam_id = $1 AND room_id = $2
+		WHERE exam_id = $1 AND room_id = $2 AND institution_id = $3
 		ORDER BY seat_no
 	`
 
-	rows, err := db.DB.Query(ctx, query, examID, roomID)
+	rows, err := db.DB.Query(ctx, query, examID, roomID, institutionID)
 	if err != nil {
 		return nil, err
 	}
@@ -51,15 +52,15 @@ func GetAttendanceByRoom(ctx context.Context, examID, roomID string) ([]models.A
 }
 
 // Full attendance summary
-func GetAttendanceByExam(ctx context.Context, examID string) ([]models.Attendance, error) {
+func GetAttendanceByExam(ctx context.Context, institutionID, examID string) ([]models.Attendance, error) {
 	query := `
 		SELECT id, exam_id, student_id, room_id, seat_no, status, answer_sheet_id
 		FROM exam_attendance
-		WHERE exam_id = $1
+		WHERE exam_id = $1 AND institution_id = $2
 		ORDER BY room_id, seat_no
 	`
 
-	rows, err := db.DB.Query(ctx, query, examID)
+	rows, err := db.DB.Query(ctx, query, examID, institutionID)
 	if err != nil {
 		return nil, err
 	}
@@ -78,3 +79,47 @@ func GetAttendanceByExam(ctx context.Context, examID string) ([]models.Attendanc
 
 	return list, nil
 }
+
+// GetAttendanceByStudent lists every attendance row recorded under one of studentIDs
+func GetAttendanceByStudent(ctx context.Context, institutionID string, studentIDs []string) ([]models.Attendance, error) {
+	query := `
+		SELECT id, exam_id, student_id, room_id, seat_no, status, answer_sheet_id
+		FROM exam_attendance
+		WHERE student_id = ANY($1) AND institution_id = $2
+		ORDER BY timestamp
+	`
+
+	rows, err := db.DB.Query(ctx, query, studentIDs, institutionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.Attendance{}
+
+	for rows.Next() {
+		var a models.Attendance
+		err := rows.Scan(&a.ID, &a.ExamID, &a.StudentID, &a.RoomID, &a.SeatNo, &a.Status, &a.AnswerSheetID)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+
+	return list, rows.Err()
+}
+
+// PseudonymiseAttendance moves a student's attendance rows to pseudonym
+func PseudonymiseAttendance(ctx context.Context, institutionID string, studentIDs []string, pseudonym string) (int64, error) {
+	query := `
+		UPDATE exam_attendance
+		SET student_id = $3
+		WHERE student_id = ANY($1) AND institution_id = $2
+	`
+
+	tag, err := db.DB.Exec(ctx, query, studentIDs, institutionID, pseudonym)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
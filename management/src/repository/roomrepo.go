@@ -10,23 +10,23 @@ import (
 )
 
 // Insert room
-func CreateRoom(ctx context.Context, room models.Room) error {
+func CreateRoom(ctx context.Context, institutionID string, room models.Room) error {
 	query := `
-		INSERT INTO rooms (room_id, rows, columns, branch)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO rooms (room_id, rows, columns, branch, institution_id)
+		VALUES ($1, $2, $3, $4, $5)
 	`
 
 	_, err := db.DB.Exec(ctx, query,
-		room.RoomID, room.Rows, room.Columns, room.Branch,
+		room.RoomID, room.Rows, room.Columns, room.Branch, institutionID,
 	)
 	return err
 }
 
-// Get all rooms
-func GetAllRooms(ctx context.Context) ([]models.Room, error) {
-	query := `SELECT room_id, rows, columns, branch FROM rooms`
+// Get all rooms of an institution
+func GetAllRooms(ctx context.Context, institutionID string) ([]models.Room, error) {
+	query := `SELECT room_id, rows, columns, branch FROM rooms WHERE institution_id = $1`
 
-	rows, err := db.DB.Query(ctx, query)
+	rows, err := db.DB.Query(ctx, query, institutionID)
 	if err != nil {
 		return nil, err
 	}
@@ -47,14 +47,14 @@ func GetAllRooms(ctx context.Context) ([]models.Room, error) {
 }
 
 // Get room by ID
-func GetRoomByID(ctx context.Context, roomID string) (*models.Room, error) {
+func GetRoomByID(ctx context.Context, institutionID string, roomID string) (*models.Room, error) {
 	query := `
 		SELECT room_id, rows, columns, branch 
-		FROM rooms WHERE room_id = $1
+		FROM rooms WHERE room_id = $1 AND institution_id = $2
 	`
 
 	var room models.Room
-	err := db.DB.QueryRow(ctx, query, roomID).Scan(
+	err := db.DB.QueryRow(ctx, query, roomID, institutionID).Scan(
 		&room.RoomID, &room.Rows, &room.Columns, &room.Branch   ,
 	)
 
@@ -3,22 +3,25 @@ package routes
 import (
 	"management/src/controller"
 	"management/src/middleware"
+	"neuroiq/shared/authctx"
 
 	"github.com/go-chi/chi"
 )
 
 func SetupManagementRoutes() chi.Router{
 	router := chi.NewRouter()
-	
-	router.Post("/register/room" , controller.RegisterRoom)
-	router.Post("/register/multiple-room" , controller.RegisterMultipleRoom)
-	router.Get("/get/rooms" , controller.GetRooms)
-	router.Post("/mark/attendance" , controller.MarkAttendance)
-	router.Get("/get/scheduled-exams/branch/{branch}/semester/{semester}" , controller.GetScheduledExams)
 
+	router.Group(func(r chi.Router){
+		r.Use(middleware.AnyRoleMiddleware)
+		r.Get("/get/scheduled-exams/branch/{branch}/semester/{semester}" , controller.GetScheduledExams)
+	})
 
 	router.Group(func(r chi.Router){
 		r.Use(middleware.AuthMiddleware)
+		r.Post("/register/room" , controller.RegisterRoom)
+		r.Post("/register/multiple-room" , controller.RegisterMultipleRoom)
+		r.Get("/get/rooms" , controller.GetRooms)
+		r.Post("/mark/attendance" , controller.MarkAttendance)
 		r.Post("/generate-seating-arrangement" , controller.GenerateSeatingArrangement)
 		// schedule exam
 		r.Post("/schedule/exam" , controller.ScheduleExam)
@@ -27,5 +30,15 @@ func SetupManagementRoutes() chi.Router{
 		r.Put("/update/exam-time/{scheduleID}", controller.UpdateExamTime)
 	})
 
+	// Called by auth before opening another session for a student
+	router.With(middleware.ServiceMiddleware(middleware.ScopeExamsRead)).Get("/internal/exams/in-progress" , controller.GetExamsInProgress)
+
+	// Called by auth to export or erase a student's data
+	router.With(middleware.ServiceMiddleware(authctx.ScopePrivacyExport)).Post("/internal/privacy/export" , controller.ExportStudentData)
+	router.With(middleware.ServiceMiddleware(authctx.ScopePrivacyErase)).Post("/internal/privacy/erase" , controller.EraseStudentData)
+
+	// Called by auth to assign records written before tenancy
+	router.With(middleware.ServiceMiddleware(authctx.ScopeTenancyBackfill)).Post("/internal/tenancy/backfill" , controller.BackfillInstitution)
+
 	return router
-}
\ No newline at end of file
+}
@@ -6,7 +6,7 @@ const answerApi = createAxiosInstance(API_BASE_URLS.ANSWER);
 /**
  * Submit exam answers (theory/mcq arrays)
  * POST /api/answer/mixed/submit
- * Request: { exam_id, session_id, subject, semester, exam_type, theory_answers, mcq_answers }
+ * Request: { exam_id, session_id, subject, branch, semester, section, exam_type, theory_answers, mcq_answers }
  * Response: { success, message, submission_id }
  */
 export const submitExamAnswers = async (answerData) => {
@@ -27,7 +27,7 @@ export const getStudentExamSubmission = async (examId, studentId) => {
 /**
  * Evaluate one theory answer using AI
  * POST /api/answer/evaluate/theory
- * Request: { question_id, question_text, answer_text, subject, max_marks }
+ * Request: { question_id, question_text, answer_text, subject, branch, semester, section, max_marks }
  */
 export const evaluateTheoryAnswer = async (payload) => {
   const response = await answerApi.post('/api/answer/evaluate/theory', payload);
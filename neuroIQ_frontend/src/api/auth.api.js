@@ -59,12 +59,12 @@ export const bulkRegisterStudents = async (studentsData) => {
 };
 
 /**
- * Get student list with filters
- * GET /get/studentlist?branch=X&semester=Y
- * Response: { students: [{ id, name, email, roll_no, branch, semester }] }
+ * Search students of the caller's institution (admin/teacher)
+ * GET /students?branch=X&semester=Y&q=name&limit=100&cursor=...
+ * Response: { students: [{ id, first_name, last_name, roll_number, branch, semester, ... }], next_cursor }
  */
 export const getStudentList = async (filters = {}) => {
-  const response = await authApi.get('/api/auth/get/studentlist', { params: filters });
+  const response = await authApi.get('/api/auth/students', { params: filters });
   return response.data;
 };
 
@@ -1,5 +1,5 @@
 import createAxiosInstance from './axios';
-import { API_BASE_URLS } from '../utils/constants';
+import { API_BASE_URLS, STORAGE_KEYS } from '../utils/constants';
 
 const ingestionApi = createAxiosInstance(API_BASE_URLS.INGESTION);
 
@@ -7,16 +7,19 @@ const ingestionApi = createAxiosInstance(API_BASE_URLS.INGESTION);
  * Upload material (PDF)
  * POST /upload
  * Request: FormData with:
- *   - file: PDF file
- *   - subject: string
- *   - semester: string
- * Response: { message: string, material_id: string, cloudinary_url: string, extracted_text: string }
+ *   - file: PDF, DOCX, PPTX, ODT, Markdown, HTML or text file (415 for other types)
+ *   - subject, branch, semester: string
+ *   - section: string (optional, empty for every section)
+ * Response (202): { message: string, job_id: string, status: string, status_url: string }
+ * Processing continues in the background; poll getIngestionJob(job_id)
  */
-export const uploadMaterial = async (file, subject, semester, onUploadProgress) => {
+export const uploadMaterial = async (file, { subject, branch, semester, section }, onUploadProgress) => {
   const formData = new FormData();
   formData.append('file', file);
   formData.append('subject', subject);
+  formData.append('branch', branch);
   formData.append('semester', semester);
+  formData.append('section', section || '');
 
   const response = await ingestionApi.post('/api/ingestion/upload', formData, {
     headers: {
@@ -30,6 +33,71 @@ export const uploadMaterial = async (file, subject, semester, onUploadProgress)
   return response.data;
 };
 
+/**
+ * Get an upload's processing job
+ * GET /jobs/:id
+ * Response: { success, data: { id, status: queued|extracting|generating|storing|done|failed, error, units: [{ unit, status, questions, error }], content_id, extraction: { pages, ocr_pages, ocr_confidence, warnings } }, progress: { units_total, units_done, units_failed } }
+ */
+export const getIngestionJob = async (id) => {
+  const response = await ingestionApi.get(`/api/ingestion/jobs/${id}`);
+  return response.data;
+};
+
+/**
+ * Follow an upload's job as Server-Sent Events
+ * GET /jobs/:id/events
+ * Events: status, unit_extracted, unit_generating, unit_questions, unit_failed, done, failed.
+ * EventSource cannot send the Authorization header, so the stream is read with fetch.
+ * Pass the last seen event id to resume after a dropped connection.
+ * Resolves with the last event id once the job is done or failed, or the signal aborts.
+ */
+export const streamIngestionJob = async (id, onEvent, { lastEventId, signal } = {}) => {
+  const headers = { Authorization: `Bearer ${localStorage.getItem(STORAGE_KEYS.TOKEN)}` };
+  if (lastEventId) {
+    headers['Last-Event-ID'] = String(lastEventId);
+  }
+
+  const response = await fetch(`${API_BASE_URLS.INGESTION}/api/ingestion/jobs/${id}/events`, { headers, signal });
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+  let buffer = '';
+  let last = lastEventId;
+  try {
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) {
+        return last;
+      }
+      buffer += value;
+
+      let end;
+      while ((end = buffer.indexOf('\n\n')) >= 0) {
+        const block = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+
+        const event = { type: 'message', data: '' };
+        block.split('\n').forEach((line) => {
+          if (line.startsWith('id: ')) event.id = line.slice(4);
+          else if (line.startsWith('event: ')) event.type = line.slice(7);
+          else if (line.startsWith('data: ')) event.data += line.slice(6);
+        });
+        if (!event.id) continue; // heartbeat
+
+        last = event.id;
+        onEvent({ id: event.id, type: event.type, data: JSON.parse(event.data) });
+      }
+    }
+  } catch (err) {
+    if (signal?.aborted) {
+      return last;
+    }
+    throw err;
+  }
+};
+
 /**
  * Get material by ID
  * GET /get/:id
@@ -50,6 +118,16 @@ export const getAllMaterials = async () => {
   return response.data;
 };
 
+/**
+ * Get a time-limited link to a material's uploaded file
+ * GET /material/:id/download-url
+ * Response: { url, expires_at }
+ */
+export const getMaterialDownloadUrl = async (id) => {
+  const response = await ingestionApi.get(`/api/ingestion/material/${id}/download-url`);
+  return response.data;
+};
+
 /**
  * Delete material by ID
  * DELETE /delete/:id
@@ -62,7 +140,10 @@ export const deleteMaterial = async (id) => {
 
 export default {
   uploadMaterial,
+  getIngestionJob,
+  streamIngestionJob,
   getMaterial,
   getAllMaterials,
+  getMaterialDownloadUrl,
   deleteMaterial,
 };
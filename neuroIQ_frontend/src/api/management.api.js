@@ -104,8 +104,8 @@ export const getSeatingArrangement = async (examId) => {
 /**
  * Schedule a new exam
  * POST /api/management/schedule/exam
- * Request: { exam_id, title, subject, branch, semester, date, start_time, end_time, total_marks }
- * Response: { exam_id, title, subject, branch, semester, date, start_time, end_time, total_marks, created_at }
+ * Request: { exam_id, title, subject, branch, semester, section, date, start_time, end_time, total_marks }
+ * Response: { exam_id, title, subject, branch, semester, section, date, start_time, end_time, total_marks, created_at }
  */
 export const scheduleExam = async (examData) => {
   const response = await managementApi.post('/api/management/schedule/exam', examData);
@@ -8,7 +8,9 @@ const questionApi = createAxiosInstance(API_BASE_URLS.QUESTION);
  * POST /api/question/register/theory
  * Request: {
  *   subject: string,
+ *   branch: string,
  *   semester: string,
+ *   section?: string (empty for every section),
  *   theory_questions: [{ question: string, marks: int }]
  * }
  * Response: { message: string }
@@ -23,7 +25,9 @@ export const registerTheoryQuestions = async (data) => {
  * POST /api/question/register/mcq
  * Request: {
  *   subject: string,
+ *   branch: string,
  *   semester: string,
+ *   section?: string (empty for every section),
  *   mcq_questions: [{ question: string, options: [string], correct_option: string }]
  * }
  * Response: { message: string }
@@ -58,7 +62,9 @@ export const getQuestionById = async (questionId) => {
  * POST /exam/generate/theory
  * Request: {
  *   subject: string,
+ *   branch: string,
  *   semester: string,
+ *   section?: string (empty for every section),
  *   mcq_questions: [{ question_id: string, question: string, marks: int }]
  * }
  * Response: { exam_id: string, subject, semester, questions, total_marks, pdf_url?: string }
@@ -73,7 +79,9 @@ export const generateTheoryExam = async (data) => {
  * POST /exam/generate/mcq
  * Request: {
  *   subject: string,
+ *   branch: string,
  *   semester: string,
+ *   section?: string (empty for every section),
  *   mcq_questions: [{ question_id: string, question: string, options: [], correct_option: string }]
  * }
  * Response: { exam_id: string, subject, semester, questions, total_marks }
@@ -88,7 +96,9 @@ export const generateMCQExam = async (data) => {
  * POST /api/question/exam/generate/both
  * Request: {
  *   subject: string,
+ *   branch: string,
  *   semester: string,
+ *   section?: string (empty for every section),
  *   theory_questions: [{ question_id: string, question: string, marks: int }],
  *   mcq_questions: [{ question_id: string, question: string, options: [], correct_option: string }]
  * }
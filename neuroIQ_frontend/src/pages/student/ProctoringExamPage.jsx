@@ -772,7 +772,9 @@ const ProctoringExamPage = () => {
         exam_id: exam.question_bank_id || exam.id,
         session_id: sessionId,
         subject: exam.subject || '',
+        branch: exam.branch || '',
         semester: exam.semester || '',
+        section: exam.section || '',
         exam_type: 'ONLINE',
         theory_answers: separatedAnswers.theory_answers,
         mcq_answers: separatedAnswers.mcq_answers,
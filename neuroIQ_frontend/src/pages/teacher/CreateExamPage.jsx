@@ -10,7 +10,9 @@ const CreateExamPage = () => {
   const [examType, setExamType] = useState(location.state?.category || 'BOTH');
   const [examDetails, setExamDetails] = useState({
     subject: location.state?.subject || '',
+    branch: location.state?.branch || '',
     semester: location.state?.semester || '',
+    section: location.state?.section || '',
   });
   // All available questions from question bank
   const [allQuestions, setAllQuestions] = useState(location.state?.questions || []);
@@ -52,7 +54,7 @@ const CreateExamPage = () => {
   };
 
   const handleCreateExam = async () => {
-    if (!examDetails.subject || !examDetails.semester) {
+    if (!examDetails.subject || !examDetails.branch || !examDetails.semester) {
       setError('Please fill in all exam details');
       return;
     }
@@ -70,7 +72,9 @@ const CreateExamPage = () => {
       if (examType === 'BOTH') {
         await generateBothExam({
           subject: examDetails.subject,
+          branch: examDetails.branch,
           semester: examDetails.semester,
+          section: examDetails.section,
           theory_questions: theoryQuestions.map(q => ({
             question_id: q.question_id || q._id || q.id,
             marks: q.marks || 1,
@@ -86,7 +90,9 @@ const CreateExamPage = () => {
       } else if (examType === 'THEORY') {
         await generateTheoryExam({
           subject: examDetails.subject,
+          branch: examDetails.branch,
           semester: examDetails.semester,
+          section: examDetails.section,
           category: 'THEORY',
           mcq_questions: selectedQuestions.map(q => ({
             question_id: q.question_id || q._id || q.id,
@@ -97,7 +103,9 @@ const CreateExamPage = () => {
       } else {
         await generateMCQExam({
           subject: examDetails.subject,
+          branch: examDetails.branch,
           semester: examDetails.semester,
+          section: examDetails.section,
           category: 'MCQ',
           mcq_questions: selectedQuestions.map(q => ({
             question_id: q.question_id || q._id || q.id,
@@ -177,6 +185,14 @@ const CreateExamPage = () => {
               }
               placeholder="e.g., Data Structures"
             />
+            <Input
+              label="Branch"
+              value={examDetails.branch}
+              onChange={(e) =>
+                setExamDetails({ ...examDetails, branch: e.target.value })
+              }
+              placeholder="e.g., CSE"
+            />
             <Input
               label="Semester"
               value={examDetails.semester}
@@ -185,6 +201,14 @@ const CreateExamPage = () => {
               }
               placeholder="e.g., 4"
             />
+            <Input
+              label="Section"
+              value={examDetails.section}
+              onChange={(e) =>
+                setExamDetails({ ...examDetails, section: e.target.value })
+              }
+              placeholder="Leave empty for every section"
+            />
             <Select
               label="Exam Type"
               options={[
@@ -199,7 +223,7 @@ const CreateExamPage = () => {
           <div className="mt-6 flex justify-end">
             <Button
               onClick={() => setStep(2)}
-              disabled={!examDetails.subject || !examDetails.semester}
+              disabled={!examDetails.subject || !examDetails.branch || !examDetails.semester}
             >
               Next: Select Questions
             </Button>
@@ -318,9 +342,16 @@ const CreateExamPage = () => {
                 <span className="text-gray-500">Subject:</span>{' '}
                 <span className="font-medium">{examDetails.subject}</span>
               </p>
+              <p>
+                <span className="text-gray-500">Branch:</span>{' '}
+                <span className="font-medium">{examDetails.branch}</span>
+              </p>
               <p>
                 <span className="text-gray-500">Semester:</span>{' '}
-                <span className="font-medium">{examDetails.semester}</span>
+                <span className="font-medium">
+                  {examDetails.semester}
+                  {examDetails.section && ` (section ${examDetails.section})`}
+                </span>
               </p>
               <p>
                 <span className="text-gray-500">Type:</span>{' '}
@@ -154,6 +154,9 @@ const EvaluateSubmissionPage = () => {
         question_text: answer.question_text,
         answer_text: answer.answer_text,
         subject: submission?.subject || examSubject || 'General',
+        branch: submission?.branch || '',
+        semester: submission?.semester || '',
+        section: submission?.section || '',
         max_marks: answer.max_marks || 5,
       });
       
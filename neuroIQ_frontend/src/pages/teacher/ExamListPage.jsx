@@ -27,6 +27,7 @@ const ExamListPage = () => {
     subject: '',
     branch: '',        // ✅ NEW
     semester: '',
+    section: '',
     date: '',
     start_time: '',
     end_time: '',
@@ -41,6 +42,7 @@ const ExamListPage = () => {
       subject: exam.subject,
       branch: exam.branch || '', // ✅ NEW
       semester: exam.semester,
+      section: exam.section || '',
       date: '',
       start_time: '',
       end_time: '',
@@ -268,6 +270,7 @@ const ExamListPage = () => {
         subject: scheduleForm.subject,
         branch: scheduleForm.branch,
         semester: scheduleForm.semester,
+        section: scheduleForm.section,
         date: new Date(scheduleForm.date).toISOString(),
         start_time: scheduleForm.start_time,
         end_time: scheduleForm.end_time,
@@ -282,6 +285,7 @@ const ExamListPage = () => {
         subject: '',
         branch: '',
         semester: '',
+        section: '',
         date: '',
         start_time: '',
         end_time: '',
@@ -715,6 +719,15 @@ const ExamListPage = () => {
                 </div>
               </div>
 
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">Section</label>
+                <Input
+                  placeholder="Leave empty for every section"
+                  value={scheduleForm.section}
+                  onChange={(e) => setScheduleForm({ ...scheduleForm, section: e.target.value })}
+                />
+              </div>
+
               <div>
                 <label className="block text-sm font-medium text-gray-700 mb-1">Exam Date *</label>
                 <Input
@@ -40,7 +40,9 @@ const GeneratedMCQPage = () => {
   const navigate = useNavigate();
   const [questions, setQuestions] = useState([]);
   const [subject, setSubject] = useState('');
+  const [branch, setBranch] = useState('');
   const [semester, setSemester] = useState('');
+  const [section, setSection] = useState('');
   const [isSaving, setIsSaving] = useState(false);
   const [error, setError] = useState('');
   const [success, setSuccess] = useState('');
@@ -51,7 +53,9 @@ const GeneratedMCQPage = () => {
       const normalizedQuestions = location.state.questions.map(normalizeCorrectOption);
       setQuestions(normalizedQuestions);
       setSubject(location.state.subject || '');
+      setBranch(location.state.branch || '');
       setSemester(location.state.semester || '');
+      setSection(location.state.section || '');
     }
   }, [location.state]);
 
@@ -78,8 +82,8 @@ const GeneratedMCQPage = () => {
   };
 
   const handleSaveToBank = async () => {
-    if (!semester) {
-      setError('Please enter semester');
+    if (!branch || !semester) {
+      setError('Please enter branch and semester');
       return;
     }
 
@@ -89,7 +93,9 @@ const GeneratedMCQPage = () => {
     try {
       await registerMCQQuestions({
         subject,
+        branch,
         semester,
+        section,
         mcq_questions: questions.map((q) => ({
           question: q.question,
           options: q.options,
@@ -144,12 +150,24 @@ const GeneratedMCQPage = () => {
           </p>
         </div>
         <div className="flex items-center gap-4">
+          <Input
+            placeholder="Branch (e.g., CSE)"
+            value={branch}
+            onChange={(e) => setBranch(e.target.value)}
+            className="w-32"
+          />
           <Input
             placeholder="Semester (e.g., 4)"
             value={semester}
             onChange={(e) => setSemester(e.target.value)}
             className="w-32"
           />
+          <Input
+            placeholder="Section (optional)"
+            value={section}
+            onChange={(e) => setSection(e.target.value)}
+            className="w-32"
+          />
           <Button onClick={handleSaveToBank} loading={isSaving}>
             Save to Bank
           </Button>
@@ -9,7 +9,9 @@ const GeneratedQuestionsPage = () => {
   const navigate = useNavigate();
   const [questions, setQuestions] = useState([]);
   const [subject, setSubject] = useState('');
+  const [branch, setBranch] = useState('');
   const [semester, setSemester] = useState('');
+  const [section, setSection] = useState('');
   const [isSaving, setIsSaving] = useState(false);
   const [error, setError] = useState('');
   const [success, setSuccess] = useState('');
@@ -18,6 +20,9 @@ const GeneratedQuestionsPage = () => {
     if (location.state?.questions) {
       setQuestions(location.state.questions);
       setSubject(location.state.subject || '');
+      setBranch(location.state.branch || '');
+      setSemester(location.state.semester || '');
+      setSection(location.state.section || '');
     }
   }, [location.state]);
 
@@ -32,8 +37,8 @@ const GeneratedQuestionsPage = () => {
   };
 
   const handleSaveToBank = async () => {
-    if (!semester) {
-      setError('Please enter semester');
+    if (!branch || !semester) {
+      setError('Please enter branch and semester');
       return;
     }
 
@@ -43,7 +48,9 @@ const GeneratedQuestionsPage = () => {
     try {
       await registerTheoryQuestions({
         subject,
+        branch,
         semester,
+        section,
         theory_questions: questions.map((q) => ({
           marks: q.marks,
           question: q.question,
@@ -95,12 +102,24 @@ const GeneratedQuestionsPage = () => {
           </p>
         </div>
         <div className="flex items-center gap-4">
+          <Input
+            placeholder="Branch (e.g., CSE)"
+            value={branch}
+            onChange={(e) => setBranch(e.target.value)}
+            className="w-32"
+          />
           <Input
             placeholder="Semester (e.g., 4)"
             value={semester}
             onChange={(e) => setSemester(e.target.value)}
             className="w-32"
           />
+          <Input
+            placeholder="Section (optional)"
+            value={section}
+            onChange={(e) => setSection(e.target.value)}
+            className="w-32"
+          />
           <Button onClick={handleSaveToBank} loading={isSaving}>
             Save to Bank
           </Button>
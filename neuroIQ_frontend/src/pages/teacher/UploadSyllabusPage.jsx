@@ -5,6 +5,8 @@ import { uploadMaterial } from '../../api/ingestion.api';
 import { generateTheoryQuestions } from '../../api/llm.api';
 import { Button, Input, Card, CardTitle, Select } from '../../components/ui';
 
+const ACCEPTED_EXTENSIONS = ['.pdf', '.docx', '.pptx', '.odt', '.md', '.markdown', '.html', '.htm', '.txt'];
+
 const UploadSyllabusPage = () => {
   const navigate = useNavigate();
   const [selectedFile, setSelectedFile] = useState(null);
@@ -22,7 +24,9 @@ const UploadSyllabusPage = () => {
   } = useForm({
     defaultValues: {
       subject: '',
+      branch: '',
       semester: '',
+      section: '',
       num_3marks: 3,
       num_4marks: 3,
       num_10marks: 2,
@@ -31,18 +35,20 @@ const UploadSyllabusPage = () => {
 
   const handleFileChange = (e) => {
     const file = e.target.files[0];
-    if (file && file.type === 'application/pdf') {
+    // The server checks the actual file type; this only catches obvious mistakes
+    const extension = file?.name.slice(file.name.lastIndexOf('.')).toLowerCase();
+    if (file && ACCEPTED_EXTENSIONS.includes(extension)) {
       setSelectedFile(file);
       setError('');
     } else {
       setSelectedFile(null);
-      setError('Please select a valid PDF file');
+      setError('Please select a PDF, Word, PowerPoint, OpenDocument, Markdown, HTML or text file');
     }
   };
 
   const onSubmit = async (data) => {
     if (!selectedFile) {
-      setError('Please select a PDF file');
+      setError('Please select a syllabus file');
       return;
     }
 
@@ -55,8 +61,12 @@ const UploadSyllabusPage = () => {
       
       const uploadResult = await uploadMaterial(
         selectedFile,
-        data.subject,
-        data.semester,
+        {
+          subject: data.subject,
+          branch: data.branch,
+          semester: data.semester,
+          section: data.section,
+        },
         (progress) => setUploadProgress(progress)
       );
 
@@ -81,7 +91,9 @@ const UploadSyllabusPage = () => {
           questions: questionsResult.questions,
           materialId: uploadResult.material_id,
           subject: data.subject,
+          branch: data.branch,
           semester: data.semester,
+          section: data.section,
           pdfUrl: uploadResult.cloudinary_url,
           totalMarks: questionsResult.total_marks,
         },
@@ -101,7 +113,7 @@ const UploadSyllabusPage = () => {
       <div>
         <h1 className="text-2xl font-bold text-gray-900">Upload Syllabus</h1>
         <p className="text-gray-500 mt-1">
-          Upload a PDF and generate AI-powered questions
+          Upload a syllabus and generate AI-powered questions
         </p>
       </div>
 
@@ -114,7 +126,7 @@ const UploadSyllabusPage = () => {
             </div>
           )}
 
-          {/* Subject & Semester */}
+          {/* Course */}
           <div className="grid grid-cols-2 gap-4">
             <Input
               label="Subject"
@@ -134,13 +146,28 @@ const UploadSyllabusPage = () => {
                 required: 'Semester is required',
               })}
             />
+            <Input
+              label="Branch"
+              placeholder="e.g., CSE"
+              error={errors.branch?.message}
+              disabled={isProcessing}
+              {...register('branch', {
+                required: 'Branch is required',
+              })}
+            />
+            <Input
+              label="Section"
+              placeholder="Leave empty for every section"
+              disabled={isProcessing}
+              {...register('section')}
+            />
           </div>
 
 
           {/* File Upload */}
           <div className="space-y-2">
             <label className="block text-sm font-medium text-gray-700">
-              Syllabus PDF <span className="text-red-500">*</span>
+              Syllabus file <span className="text-red-500">*</span>
             </label>
             <div
               className={`
@@ -153,7 +180,7 @@ const UploadSyllabusPage = () => {
               <input
                 id="file-input"
                 type="file"
-                accept=".pdf"
+                accept={ACCEPTED_EXTENSIONS.join(',')}
                 className="hidden"
                 onChange={handleFileChange}
               />
@@ -195,7 +222,7 @@ const UploadSyllabusPage = () => {
                   <p className="text-sm text-gray-600">
                     Click to upload or drag and drop
                   </p>
-                  <p className="text-xs text-gray-500">PDF up to 20MB</p>
+                  <p className="text-xs text-gray-500">PDF, DOCX, PPTX, ODT, Markdown, HTML or TXT up to 20MB</p>
                 </div>
               )}
             </div>
@@ -250,7 +277,7 @@ const UploadSyllabusPage = () => {
                   {step > 1 ? '✓' : '1'}
                 </div>
                 <span className={step === 1 ? 'text-gray-900' : 'text-gray-500'}>
-                  Uploading PDF... {step === 1 && `${uploadProgress}%`}
+                  Uploading file... {step === 1 && `${uploadProgress}%`}
                 </span>
               </div>
               <div className="flex items-center gap-3">
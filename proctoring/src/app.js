@@ -5,6 +5,7 @@ const path = require('path');
 const examRoutes = require('./routes/examRoutes');
 const proctorRoutes = require('./routes/proctorRoutes');
 const submissionRoutes = require('./routes/submissionRoutes');
+const privacyRoutes = require('./routes/privacyRoutes');
 const { setupSocketIO } = require('./websocket/proctoring');
 const { connectDB } = require('./db/mongo');
 const morgan = require("morgan");
@@ -32,6 +33,7 @@ function createApp() {
     app.use('/api/proctoring/exam', examRoutes);
     app.use('/api/proctoring/proctor', proctorRoutes);
     app.use('/api/proctoring/submission', submissionRoutes);
+    app.use('/api/proctoring/internal/privacy', privacyRoutes);
 
     app.get("/api/proctoring/download-agent", (req, res) => {
         const filePath = path.join(__dirname, "..", "downloads", "neuroiq-proctor.zip");
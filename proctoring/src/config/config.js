@@ -8,8 +8,10 @@ const settings = {
     SERVICE_PORT: parseInt(process.env.SERVICE_PORT, 10) || 8000,
     ENV: process.env.ENV || 'development',
 
-    JWT_SECRET: process.env.JWT_SECRET,
-    JWT_ALGORITHM: process.env.JWT_ALGORITHM || 'HS256',
+    JWKS_URL: process.env.JWKS_URL
+        || (process.env.AUTH_URI ? process.env.AUTH_URI + '/.well-known/jwks.json' : undefined),
+    JWT_ALGORITHM: process.env.JWT_ALGORITHM || 'RS256',
+    AUTH_URI: process.env.AUTH_URI,
 
     MONGO_URI: process.env.MONGO_URI,
     MONGO_DB: process.env.MONGO_DB || 'NeuroIQ_ProctoringDB',
@@ -0,0 +1,58 @@
+const { ExamSession, Violation, ProctoringReport } = require('../models/proctor');
+
+/**
+ * Every exam session, violation and report of a student (by user ID)
+ */
+async function exportStudentData(req, res) {
+    try {
+        const { user_id } = req.body;
+        if (!user_id) {
+            return res.status(400).json({ detail: 'user_id is required' });
+        }
+
+        const [sessions, violations, reports] = await Promise.all([
+            ExamSession.find({ student_id: user_id }).sort({ start_time: 1 }).lean(),
+            Violation.find({ student_id: user_id }).sort({ timestamp: 1 }).lean(),
+            ProctoringReport.find({ student_id: user_id }).sort({ created_at: 1 }).lean(),
+        ]);
+
+        return res.status(200).json({ sessions, violations, reports });
+    } catch (err) {
+        console.error('Privacy export error:', err);
+        return res.status(500).json({ detail: 'Failed to export proctoring data' });
+    }
+}
+
+/**
+ * Move a student's sessions, violations and reports to their pseudonym.
+ * Violation metadata (captured details) is dropped; counts, probabilities
+ * and timings stay so exam statistics are unchanged.
+ */
+async function eraseStudentData(req, res) {
+    try {
+        const { user_id, pseudonym } = req.body;
+        if (!user_id || !pseudonym || !pseudonym.startsWith('erased-')) {
+            return res.status(400).json({ detail: 'user_id and pseudonym are required' });
+        }
+
+        const [sessions, violations, reports] = await Promise.all([
+            ExamSession.updateMany({ student_id: user_id }, { $set: { student_id: pseudonym } }),
+            Violation.updateMany({ student_id: user_id }, { $set: { student_id: pseudonym, metadata: {} } }),
+            ProctoringReport.updateMany({ student_id: user_id }, { $set: { student_id: pseudonym } }),
+        ]);
+
+        return res.status(200).json({
+            sessions: sessions.modifiedCount,
+            violations: violations.modifiedCount,
+            reports: reports.modifiedCount,
+        });
+    } catch (err) {
+        console.error('Privacy erase error:', err);
+        return res.status(500).json({ detail: 'Failed to erase proctoring data' });
+    }
+}
+
+module.exports = {
+    exportStudentData,
+    eraseStudentData,
+};
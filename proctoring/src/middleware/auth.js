@@ -1,16 +1,23 @@
 /**
  * JWT authentication middleware
- * Compatible with Go auth service (HS256)
+ * Compatible with Go auth service (RS256, keys from its JWKS)
  */
 const jwt = require('jsonwebtoken');
 const settings = require('../config/config');
+const { getPublicKey } = require('../utils/jwks');
 
 /**
  * Verify JWT token from Authorization header
  */
-function verifyToken(token) {
+async function verifyToken(token) {
     try {
-        const payload = jwt.verify(token, settings.JWT_SECRET, {
+        const decoded = jwt.decode(token, { complete: true });
+        if (!decoded) {
+            return { error: 'Invalid token', status: 401 };
+        }
+
+        const key = await getPublicKey(decoded.header.kid);
+        const payload = jwt.verify(token, key, {
             algorithms: [settings.JWT_ALGORITHM],
         });
 
@@ -27,6 +34,9 @@ function verifyToken(token) {
             userId,
             role,
             email,
+            institutionId: payload.InstitutionID,
+            scopes: (payload.scope || '').split(' ').filter(Boolean),
+            actorId: payload.act ? payload.act.sub : null,
         };
     } catch (err) {
         if (err.name === 'TokenExpiredError') {
@@ -42,7 +52,7 @@ function verifyToken(token) {
 /**
  * Express middleware for protected routes
  */
-function authMiddleware(req, res, next) {
+async function authMiddleware(req, res, next) {
     const authHeader = req.headers.authorization;
 
     if (!authHeader || !authHeader.startsWith('Bearer ')) {
@@ -52,7 +62,7 @@ function authMiddleware(req, res, next) {
     }
 
     const token = authHeader.substring(7);
-    const result = verifyToken(token);
+    const result = await verifyToken(token);
 
     if (result.error) {
         return res.status(result.status || 401).json({
@@ -60,11 +70,83 @@ function authMiddleware(req, res, next) {
         });
     }
 
+    // Impersonation tokens (an admin acting as the user) may only read
+    if (result.actorId) {
+        reportImpersonation(token, result, req.method, req.originalUrl.split('?')[0]);
+        if (req.method !== 'GET' && req.method !== 'HEAD') {
+            return res.status(403).json({
+                detail: 'Forbidden: impersonation is read-only',
+            });
+        }
+    }
+
     req.currentUser = result;
     next();
 }
 
+/**
+ * Report a request made with an impersonation token to auth's audit log,
+ * using the token itself. Failures are only logged here.
+ */
+function reportImpersonation(token, user, method, path) {
+    const fail = (err) => console.error(
+        `Impersonated request ${method} ${path} by ${user.actorId} as ${user.userId} not audited:`, err
+    );
+
+    if (!settings.AUTH_URI) {
+        return fail('AUTH_URI not configured');
+    }
+
+    fetch(`${settings.AUTH_URI}/impersonation/events`, {
+        method: 'POST',
+        headers: {
+            Authorization: `Bearer ${token}`,
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ service: 'proctoring', method, path }),
+        signal: AbortSignal.timeout(5000),
+    })
+        .then((res) => {
+            if (res.status !== 204) {
+                fail(`auth returned ${res.status}`);
+            }
+        })
+        .catch(fail);
+}
+
+/**
+ * Middleware admitting only service tokens granted scope, for endpoints other
+ * services call on their own behalf (role "service", see auth's /oauth/token)
+ */
+function requireServiceScope(scope) {
+    return async function (req, res, next) {
+        const authHeader = req.headers.authorization;
+
+        if (!authHeader || !authHeader.startsWith('Bearer ')) {
+            return res.status(401).json({
+                detail: 'Missing or invalid authorization header',
+            });
+        }
+
+        const result = await verifyToken(authHeader.substring(7));
+        if (result.error) {
+            return res.status(result.status || 401).json({
+                detail: result.error,
+            });
+        }
+        if (result.role !== 'service' || !result.scopes.includes(scope)) {
+            return res.status(403).json({
+                detail: 'Not authorized for this service',
+            });
+        }
+
+        req.currentService = result;
+        next();
+    };
+}
+
 module.exports = {
     verifyToken,
     authMiddleware,
+    requireServiceScope,
 };
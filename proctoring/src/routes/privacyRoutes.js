@@ -0,0 +1,10 @@
+const express = require('express');
+const router = express.Router();
+const { requireServiceScope } = require('../middleware/auth');
+const { exportStudentData, eraseStudentData } = require('../controllers/privacyController');
+
+// Called by auth to export or erase a student's data
+router.post('/export', requireServiceScope('privacy:export'), exportStudentData);
+router.post('/erase', requireServiceScope('privacy:erase'), eraseStudentData);
+
+module.exports = router;
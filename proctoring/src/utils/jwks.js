@@ -0,0 +1,65 @@
+/**
+ * JWKS key cache for verifying auth service access tokens (RS256)
+ */
+const crypto = require('crypto');
+const settings = require('../config/config');
+
+const JWKS_CACHE_TTL_MS = 10 * 60 * 1000;
+const JWKS_MIN_INTERVAL_MS = 30 * 1000; // floor between refetches for unknown kids
+
+let jwksKeys = new Map();
+let jwksFetchedAt = 0;
+
+/**
+ * Fetch and parse the auth service JWKS into KeyObjects by kid
+ */
+async function fetchJWKS() {
+    if (!settings.JWKS_URL) {
+        throw new Error('missing JWKS_URL');
+    }
+
+    const res = await fetch(settings.JWKS_URL);
+    if (!res.ok) {
+        throw new Error(`fetch jwks: status ${res.status}`);
+    }
+    const body = await res.json();
+
+    const keys = new Map();
+    for (const jwk of body.keys || []) {
+        if (jwk.kty !== 'RSA') continue;
+        keys.set(jwk.kid, crypto.createPublicKey({ key: jwk, format: 'jwk' }));
+    }
+    return keys;
+}
+
+/**
+ * Resolve the public key for kid, refetching when stale or unknown
+ */
+async function getPublicKey(kid) {
+    const age = Date.now() - jwksFetchedAt;
+    const cached = jwksKeys.get(kid);
+    if (cached && age < JWKS_CACHE_TTL_MS) {
+        return cached;
+    }
+
+    if (age > JWKS_MIN_INTERVAL_MS) {
+        try {
+            jwksKeys = await fetchJWKS();
+            jwksFetchedAt = Date.now();
+        } catch (err) {
+            // keep serving cached keys if auth is briefly unreachable
+            if (cached) return cached;
+            throw err;
+        }
+    }
+
+    const key = jwksKeys.get(kid);
+    if (!key) {
+        throw new Error('unknown signing key');
+    }
+    return key;
+}
+
+module.exports = {
+    getPublicKey,
+};
@@ -39,6 +39,11 @@ func RegisterTheoryQuestionSet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Teachers only write to the courses they are assigned
+	if !middleware.RequireCourse(w, r, questions.Subject, questions.Branch, questions.Semester, questions.Section) {
+		return
+	}
+
 	for i := range questions.QuestionList {
 		questions.QuestionList[i].ID = primitive.NewObjectID()
 	}
@@ -46,8 +51,11 @@ func RegisterTheoryQuestionSet(w http.ResponseWriter, r *http.Request) {
 
 	questionList := models.TheoryQuestions{
 		UserID:    authCtx.UserID,
+		InstitutionID: authCtx.InstitutionID,
 		Subject:   strings.ToLower(strings.TrimSpace(questions.Subject)),
 		Semester:  questions.Semester,
+		Branch:    questions.Branch,
+		Section:   questions.Section,
 		Category:  models.CategoryTheory,
 		Questions: questions.QuestionList,
 	}
@@ -89,14 +97,21 @@ func RegisterMCQQuestionSet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !middleware.RequireCourse(w, r, questions.Subject, questions.Branch, questions.Semester, questions.Section) {
+		return
+	}
+
 	for i := range questions.QuestionList {
 		questions.QuestionList[i].ID = primitive.NewObjectID()
 	}
 
 	questionList := models.MCQQuestions{
 		UserID:    authCtx.UserID,
+		InstitutionID: authCtx.InstitutionID,
 		Subject:   strings.ToLower(strings.TrimSpace(questions.Subject)),
 		Semester:  questions.Semester,
+		Branch:    questions.Branch,
+		Section:   questions.Section,
 		Category:  models.CategoryMCQ,
 		Questions: questions.QuestionList,
 	}
@@ -117,6 +132,11 @@ func RegisterMCQQuestionSet(w http.ResponseWriter, r *http.Request) {
 }
 
 func RegisterMCQExam(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 	var examRequest dto.MCQExam
 
 	err := json.NewDecoder(r.Body).Decode(&examRequest)
@@ -131,10 +151,17 @@ func RegisterMCQExam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !middleware.RequireCourse(w, r, examRequest.Subject, examRequest.Branch, examRequest.Semester, examRequest.Section) {
+		return
+	}
+
 	mongoRes, err := db.GetExamCollection().InsertOne(r.Context(), models.MCQExam{
 		ID : primitive.NewObjectID(),
+		InstitutionID: authCtx.InstitutionID,
 		Subject:      examRequest.Subject,
 		Semester:     examRequest.Semester,
+		Branch:       examRequest.Branch,
+		Section:      examRequest.Section,
 		Category:     models.Category(examRequest.Category),
 		QuestionList: examRequest.QuestionList,
 	})
@@ -152,6 +179,11 @@ func RegisterMCQExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func RegisterTheoryExam(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 	var examRequest dto.TheoryExam
 
 	err := json.NewDecoder(r.Body).Decode(&examRequest)
@@ -166,11 +198,18 @@ func RegisterTheoryExam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !middleware.RequireCourse(w, r, examRequest.Subject, examRequest.Branch, examRequest.Semester, examRequest.Section) {
+		return
+	}
+
 
 	mongoRes, err := db.GetExamCollection().InsertOne(r.Context(), models.TheoryExam{
 		ID : primitive.NewObjectID(),
+		InstitutionID: authCtx.InstitutionID,
 		Subject:      examRequest.Subject,
 		Semester:     examRequest.Semester,
+		Branch:       examRequest.Branch,
+		Section:      examRequest.Section,
 		Category:     models.Category(examRequest.Category),
 		QuestionList: examRequest.QuestionList,
 	})
@@ -188,11 +227,11 @@ func RegisterTheoryExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func RegisterTheoryAndMCQExam(w http.ResponseWriter, r *http.Request) {
-	// authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
-	// if !ok {
-	// 	http.Error(w, "Error in auth context", http.StatusUnauthorized)
-	// 	return
-	// }
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 	var examRequest dto.BothQuestionsExam
 	err := json.NewDecoder(r.Body).Decode(&examRequest)
 	if err != nil {
@@ -205,10 +244,17 @@ func RegisterTheoryAndMCQExam(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+
+	if !middleware.RequireCourse(w, r, examRequest.Subject, examRequest.Branch, examRequest.Semester, examRequest.Section) {
+		return
+	}
 	mongoRes, err := db.GetExamCollection().InsertOne(r.Context(), models.BothQuestionsExam{
 		ID:             primitive.NewObjectID(),
+		InstitutionID:  authCtx.InstitutionID,
 		Subject:        examRequest.Subject,
 		Semester:       examRequest.Semester,
+		Branch:         examRequest.Branch,
+		Section:        examRequest.Section,
 		Category:       models.CategoryBoth,
 		TheoryQuestions: examRequest.QuestionListTheory,
 		MCQQuestions:    examRequest.QuestionListMCQ,
@@ -227,6 +273,11 @@ func RegisterTheoryAndMCQExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetTheoryAndMCQExam(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 	subject := chi.URLParam(r, "subject")
 	semester := chi.URLParam(r, "semester")
 
@@ -236,6 +287,7 @@ func GetTheoryAndMCQExam(w http.ResponseWriter, r *http.Request) {
 	collection := db.GetExamCollection()
 
 	filter := bson.M{
+		"institution_id" : authCtx.InstitutionID,
 		"subject" : subject,
 		"semester" : semester,
 	}
@@ -261,6 +313,11 @@ func GetTheoryAndMCQExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetTheoryExam(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 	subject := chi.URLParam(r, "subject")
 	semester := chi.URLParam(r, "semester")
 
@@ -270,6 +327,7 @@ func GetTheoryExam(w http.ResponseWriter, r *http.Request) {
 	collection := db.GetExamCollection()
 
 	filter := bson.M{
+		"institution_id" : authCtx.InstitutionID,
 		"subject" : subject,
 		"semester" : semester,
 	}
@@ -295,6 +353,11 @@ func GetTheoryExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetMCQExam(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 	subject := chi.URLParam(r, "subject")
 	semester := chi.URLParam(r, "semester")
 
@@ -304,6 +367,7 @@ func GetMCQExam(w http.ResponseWriter, r *http.Request) {
 	collection := db.GetExamCollection()
 
 	filter := bson.M{
+		"institution_id" : authCtx.InstitutionID,
 		"subject" : subject,
 		"semester" : semester,
 	}
@@ -329,6 +393,11 @@ func GetMCQExam(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetExamByID(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 	examIDParam := chi.URLParam(r, "id")
 
 	// 🔥 Convert string to ObjectID
@@ -344,7 +413,7 @@ func GetExamByID(w http.ResponseWriter, r *http.Request) {
 	collection := db.GetExamCollection()
 
 	var exam map[string]interface{}
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&exam)
+	err = collection.FindOne(ctx, bson.M{"_id": objectID, "institution_id": authCtx.InstitutionID}).Decode(&exam)
 	if err != nil {
 		http.Error(w, "Exam not found", http.StatusNotFound)
 		return
@@ -363,6 +432,11 @@ func GetExamByID(w http.ResponseWriter, r *http.Request) {
 	
 
 func GetQuestion(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
 
 	query := r.URL.Query()
 
@@ -372,6 +446,7 @@ func GetQuestion(w http.ResponseWriter, r *http.Request) {
 
 	// ✅ Build MongoDB filter
 	filter := bson.M{
+		"institution_id": authCtx.InstitutionID,
 		"subject":  subject,
 		"semester": semester,
 		"category": category,
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"questionbank/src/db"
+	"questionbank/src/dto"
+	"questionbank/src/middleware"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func decodePrivacySubject(w http.ResponseWriter, r *http.Request) (middleware.AuthContext, dto.PrivacySubject, bool) {
+	var subject dto.PrivacySubject
+
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return authCtx, subject, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
+		http.Error(w, "request body not able to get decoded", http.StatusBadRequest)
+		return authCtx, subject, false
+	}
+	if err := validator.New().Struct(&subject); err != nil {
+		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return authCtx, subject, false
+	}
+
+	return authCtx, subject, true
+}
+
+// ExportUserData returns the question sets a user wrote
+func ExportUserData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	filter := bson.M{"user_id": subject.UserID, "institution_id": authCtx.InstitutionID}
+
+	questionSets := []bson.M{}
+	cursor, err := db.GetQuestionbankCollection().Find(ctx, filter)
+	if err == nil {
+		err = cursor.All(ctx, &questionSets)
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch question sets", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"question_sets": questionSets,
+	})
+}
+
+// EraseUserData moves a user's question sets to their pseudonym. The
+// questions themselves are course material and stay in the bank.
+func EraseUserData(w http.ResponseWriter, r *http.Request) {
+	authCtx, subject, ok := decodePrivacySubject(w, r)
+	if !ok {
+		return
+	}
+	if !strings.HasPrefix(subject.Pseudonym, "erased-") {
+		http.Error(w, "pseudonym is required", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	filter := bson.M{"user_id": subject.UserID, "institution_id": authCtx.InstitutionID}
+	result, err := db.GetQuestionbankCollection().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"user_id": subject.Pseudonym}})
+	if err != nil {
+		http.Error(w, "Failed to erase question sets", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"question_sets": result.ModifiedCount,
+	})
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"net/http"
+	"questionbank/src/db"
+	"questionbank/src/middleware"
+
+	"neuroiq/shared/tenancy"
+)
+
+// BackfillInstitution assigns question sets and exams written before
+// tenancy to the institution of the calling token
+func BackfillInstitution(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := r.Context().Value(middleware.AuthKey).(middleware.AuthContext)
+	if !ok {
+		http.Error(w, "Error in auth context", http.StatusUnauthorized)
+		return
+	}
+	tenancy.Serve(w, r, authCtx.InstitutionID, db.AssignInstitution)
+}
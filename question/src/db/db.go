@@ -30,4 +30,5 @@ func MongoInit() {
 	questionbankCollection = client.Database("NeuroIQ_QuestionDB").Collection("questionbank")
 	examCollection = client.Database("NeuroIQ_QuestionDB").Collection("exam")
 
-}
\ No newline at end of file
+	ensureIndexes(ctx)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+
+	"neuroiq/shared/tenancy"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AssignInstitution gives the question sets owners wrote before tenancy
+// their institution. Exams have no owner; an untagged exam follows the
+// institution whose question sets its questions were drawn from.
+func AssignInstitution(ctx context.Context, institutionID string, owners tenancy.Owners) (tenancy.Result, error) {
+	var result tenancy.Result
+
+	if len(owners.UserIDs) > 0 {
+		res, err := questionbankCollection.UpdateMany(ctx, tenancy.Unassigned("user_id", owners.UserIDs), tenancy.Assign(institutionID))
+		if err != nil {
+			return result, err
+		}
+		result.Assigned += res.ModifiedCount
+	}
+
+	cursor, err := examCollection.Find(ctx, tenancy.Untagged())
+	if err != nil {
+		return result, err
+	}
+	var exams []struct {
+		ID     primitive.ObjectID `bson:"_id"`
+		MCQ    []examQuestionID   `bson:"mcq_questions"`
+		Theory []examQuestionID   `bson:"theory_questions"`
+	}
+	if err := cursor.All(ctx, &exams); err != nil {
+		return result, err
+	}
+
+	for _, exam := range exams {
+		ids := []primitive.ObjectID{}
+		for _, q := range append(exam.MCQ, exam.Theory...) {
+			ids = append(ids, q.ID)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		source := bson.M{
+			"institution_id": institutionID,
+			"$or": []bson.M{
+				{"mcq_questions.question_id": bson.M{"$in": ids}},
+				{"theory_questions.question_id": bson.M{"$in": ids}},
+			},
+		}
+		count, err := questionbankCollection.CountDocuments(ctx, source)
+		if err != nil {
+			return result, err
+		}
+		if count == 0 {
+			continue
+		}
+
+		res, err := examCollection.UpdateOne(ctx, bson.M{"_id": exam.ID, "institution_id": bson.M{"$exists": false}}, tenancy.Assign(institutionID))
+		if err != nil {
+			return result, err
+		}
+		result.Assigned += res.ModifiedCount
+	}
+
+	for _, collection := range []*mongo.Collection{questionbankCollection, examCollection} {
+		remaining, err := collection.CountDocuments(ctx, tenancy.Untagged())
+		if err != nil {
+			return result, err
+		}
+		result.Remaining += remaining
+	}
+	return result, nil
+}
+
+type examQuestionID struct {
+	ID primitive.ObjectID `bson:"question_id"`
+}
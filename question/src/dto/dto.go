@@ -7,16 +7,27 @@ import (
 )
 
 type Claim struct {
-	ID    string
-	Email string
-	Role  string
+	ID            string
+	Email         string
+	Role          string
+	InstitutionID string
+	Scope         string `json:"scope,omitempty"` // service tokens only, space separated
+	Actor         *ActorClaim `json:"act,omitempty"` // set when an admin impersonates the user
 	jwt.RegisteredClaims
 }
 
+// ActorClaim names the admin holding an impersonation token
+type ActorClaim struct {
+	ID    string `json:"sub"`
+	Email string `json:"email,omitempty"`
+}
+
 
 type TheoryQuestions struct {
 	Subject				string						`json:"subject" validate:"required"`
 	Semester			string						`json:"semester" validate:"required"`
+	Branch				string						`json:"branch" validate:"required"`
+	Section				string						`json:"section"` // empty for every section
 	// Category			models.Category				`json:"category" validate:"required"`
 	QuestionList		[]models.TheoryQuestion		`json:"theory_questions"  validate:"required"`
 }
@@ -24,6 +35,8 @@ type TheoryQuestions struct {
 type MCQQuestions struct {
 	Subject				string					`json:"subject" validate:"required"`
 	Semester			string					`json:"semester" validate:"required"`
+	Branch				string					`json:"branch" validate:"required"`
+	Section				string					`json:"section"` // empty for every section
 	// Category			models.Category			`json:"category" validate:"required"`
 	QuestionList		[]models.MCQQuestion	`json:"mcq_questions"  validate:"required"`
 }
@@ -31,6 +44,8 @@ type MCQQuestions struct {
 type BothQuestionsExam struct {
 	Subject				string						`json:"subject" validate:"required"`
 	Semester			string						`json:"semester" validate:"required"`
+	Branch				string						`json:"branch" validate:"required"`
+	Section				string						`json:"section"` // empty for every section
 	QuestionListTheory	[]models.TheoryQuestion		`json:"theory_questions"  validate:"required"`
 	QuestionListMCQ		[]models.MCQQuestion		`json:"mcq_questions"  validate:"required"`
 }
@@ -64,6 +79,8 @@ type LlmMCQResponse struct {
 type MCQExam struct {
 	Subject				string					`json:"subject" validate:"required"`
 	Semester			string					`json:"semester" validate:"required"`
+	Branch				string					`json:"branch" validate:"required"`
+	Section				string					`json:"section"` // empty for every section
 	Category			Category					`json:"category" validate:"required"`
 	QuestionList		[]models.MCQQuestion	`json:"mcq_questions" validate:"required"`
 }
@@ -71,6 +88,8 @@ type MCQExam struct {
 type TheoryExam struct {
 	Subject				string					`json:"subject" validate:"required"`
 	Semester			string					`json:"semester" validate:"required"`
+	Branch				string					`json:"branch" validate:"required"`
+	Section				string					`json:"section"` // empty for every section
 	Category			Category				`json:"category" validate:"required"`
 	QuestionList		[]models.TheoryQuestion	`json:"mcq_questions" validate:"required"`
 }
@@ -88,3 +107,10 @@ type QuestionRequest struct {
 }
 
 
+// PrivacySubject names the user whose data auth exports or erases;
+// pseudonym replaces their ID on erasure
+type PrivacySubject struct {
+	UserID    string `json:"user_id" validate:"required"`
+	StudentID string `json:"student_id"`
+	Pseudonym string `json:"pseudonym"`
+}
@@ -2,7 +2,7 @@ package jwtutil
 
 import (
 	"fmt"
-	"os"
+	"neuroiq/shared/jwks"
 	"questionbank/src/dto"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,11 +10,6 @@ import (
 
 
 func ValidateToken(tokenString string) (*dto.Claim, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, fmt.Errorf("missing JWT_SECRET")
-	}
-
 	var claim dto.Claim
 
 	// Parse the token into our custom claim struct
@@ -22,12 +17,14 @@ func ValidateToken(tokenString string) (*dto.Claim, error) {
 		tokenString,
 		&claim,
 		func(t *jwt.Token) (interface{}, error) {
-			// Check if signing method is HS256
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			// Check if signing method is RS256
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
 				return nil, fmt.Errorf("invalid signing method")
 			}
-			return []byte(secret), nil
+			kid, _ := t.Header["kid"].(string)
+			return jwks.PublicKey(kid)
 		},
+		jwt.WithValidMethods([]string{"RS256"}),
 	)
 
 	if err != nil {
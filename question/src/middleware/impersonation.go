@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"questionbank/src/dto"
+
+	"neuroiq/shared/impersonation"
+)
+
+// Impersonated requests are reported to auth's audit log under this name
+const serviceName = "question"
+
+func actorID(claims *dto.Claim) string {
+	if claims.Actor == nil {
+		return ""
+	}
+	return claims.Actor.ID
+}
+
+// allowImpersonation refuses anything but reads to impersonation tokens and
+// reports each request; other tokens pass untouched. It writes the error
+// response and returns false when the caller may not proceed.
+func allowImpersonation(w http.ResponseWriter, r *http.Request, authCtx AuthContext, token string) bool {
+	return impersonation.Allow(w, r, serviceName, authCtx.ActorID, authCtx.UserID, token)
+}
@@ -6,19 +6,45 @@ import (
 	"questionbank/src/dto"
 	"questionbank/src/jwtutil"
 	"strings"
+
+	"neuroiq/shared/authctx"
 )
 
+// AuthContext is the caller stored under AuthKey, with the claims of its token
 type AuthContext struct {
-	UserID string
-	Email  string
-	Role   string
+	authctx.Context
 	Claims *dto.Claim
 }
+
 type contextKey string
 
 const AuthKey contextKey = "auth_context"
 
+// AuthMiddleware admits teachers and admins, who manage the question bank
 func AuthMiddleware(next http.Handler) (http.Handler) {
+	return authenticate(next, func(a AuthContext) bool {
+		return a.Role == "teacher" || a.Role == "admin"
+	})
+}
+
+// ExamReaderMiddleware admits any signed-in user, so students can load the exam they sit
+func ExamReaderMiddleware(next http.Handler) (http.Handler) {
+	return authenticate(next, func(a AuthContext) bool {
+		return !a.Service
+	})
+}
+
+// ServiceMiddleware admits only service tokens granted scope, for endpoints
+// other services call on their own behalf. User tokens are refused.
+func ServiceMiddleware(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authenticate(next, func(a AuthContext) bool {
+			return a.Service && a.HasScope(scope)
+		})
+	}
+}
+
+func authenticate(next http.Handler, allowed func(a AuthContext) bool) (http.Handler) {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -40,19 +66,35 @@ func AuthMiddleware(next http.Handler) (http.Handler) {
 			return
 		}
 
-		if claims.Role != "teacher" && claims.Role != "admin" {
-			http.Error(w , "Not Authorized for the service" , http.StatusUnauthorized)
+		// Every query is scoped to the caller's institution, so a token without one is useless
+		if claims.InstitutionID == "" {
+			http.Error(w, "Token has no institution", http.StatusUnauthorized)
 			return
 		}
 
-		authctx := AuthContext{
-			UserID: 	claims.ID,
-			Email: 		claims.Email,
-			Role:  		claims.Role,
-			Claims: 	claims,
+		authCtx := AuthContext{
+			Context: authctx.Context{
+				UserID:        claims.ID,
+				Email:         claims.Email,
+				Role:          claims.Role,
+				InstitutionID: claims.InstitutionID,
+				Service:       claims.Role == "service",
+				Scopes:        strings.Fields(claims.Scope),
+				ActorID:       actorID(claims),
+			},
+			Claims: claims,
 		}
-		ctx := context.WithValue(r.Context() , AuthKey , authctx)
+
+		if !allowed(authCtx) {
+			http.Error(w , "Not Authorized for the service" , http.StatusUnauthorized)
+			return
+		}
+		if !allowImpersonation(w, r, authCtx, tokenString) {
+			return
+		}
+
+		ctx := context.WithValue(r.Context() , AuthKey , authCtx)
 
 		next.ServeHTTP(w , r.WithContext(ctx) )
 	})
-}
\ No newline at end of file
+}
@@ -4,8 +4,11 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type TheoryQuestions struct {
 	UserID    string           `json:"user_id" bson:"user_id" validate:"required"`
+	InstitutionID string       `json:"institution_id" bson:"institution_id"`
 	Subject   string           `json:"subject" bson:"subject" validate:"required"`
 	Semester  string           `json:"semester" bson:"semester" validate:"required"`
+	Branch    string           `json:"branch,omitempty" bson:"branch,omitempty"`
+	Section   string           `json:"section,omitempty" bson:"section,omitempty"`
 	Category  Category         `json:"category" bson:"category" validate:"required"`
 	Questions []TheoryQuestion `json:"theory_questions" bson:"theory_questions" validate:"required"`
 }
@@ -18,8 +21,11 @@ type TheoryQuestion struct {
 
 type MCQQuestions struct {
 	UserID    string        `json:"user_id" bson:"user_id" validate:"required"`
+	InstitutionID string    `json:"institution_id" bson:"institution_id"`
 	Subject   string        `json:"subject" bson:"subject" validate:"required"`
 	Semester  string        `json:"semester" bson:"semester" validate:"required"`
+	Branch    string        `json:"branch,omitempty" bson:"branch,omitempty"`
+	Section   string        `json:"section,omitempty" bson:"section,omitempty"`
 	Category  Category      `json:"category" bson:"category" validate:"required"`
 	Questions []MCQQuestion `json:"mcq_questions" bson:"mcq_questions" validate:"required"`
 }
@@ -41,24 +47,33 @@ const (
 
 type MCQExam struct {
 	ID 		primitive.ObjectID	`json:"_id" bson:"_id"`
+	InstitutionID string       `json:"institution_id" bson:"institution_id"`
 	Subject      string        `json:"subject" bson:"subject" validate:"required"`
 	Semester     string        `json:"semester" bson:"semester" validate:"required"`
+	Branch       string        `json:"branch,omitempty" bson:"branch,omitempty"`
+	Section      string        `json:"section,omitempty" bson:"section,omitempty"`
 	Category     Category      `json:"category" bson:"category" validate:"required"`
 	QuestionList []MCQQuestion `json:"mcq_questions" bson:"mcq_questions" validate:"required"`
 }
 
 type TheoryExam struct {
 	ID 			primitive.ObjectID	`json:"_id" bson:"_id,"`
+	InstitutionID string          `json:"institution_id" bson:"institution_id"`
 	Subject      string           `json:"subject" bson:"subject" validate:"required"`
 	Semester     string           `json:"semester" bson:"semester" validate:"required"`
-	Category     Category         `json"category" bson:"category" validate:"required"`
+	Branch       string           `json:"branch,omitempty" bson:"branch,omitempty"`
+	Section      string           `json:"section,omitempty" bson:"section,omitempty"`
+	Category     Category         `json:"category" bson:"category" validate:"required"`
 	QuestionList []TheoryQuestion `json:"mcq_questions" bson:"mcq_questions" validate:"required"`
 }
 
 type BothQuestionsExam struct {
 	ID              primitive.ObjectID 		`json:"_id" bson:"_id"`
+	InstitutionID   string           		`json:"institution_id" bson:"institution_id"`
 	Subject         string           		`json:"subject" bson:"subject" validate:"required"`
 	Semester        string           		`json:"semester" bson:"semester" validate:"required"`
+	Branch          string           		`json:"branch,omitempty" bson:"branch,omitempty"`
+	Section         string           		`json:"section,omitempty" bson:"section,omitempty"`
 	Category        Category         		`json:"category" bson:"category" validate:"required"`
 	TheoryQuestions []TheoryQuestion 		`json:"theory_questions" bson:"theory_questions" validate:"required"`
 	MCQQuestions    []MCQQuestion    		`json:"mcq_questions" bson:"mcq_questions" validate:"required"`
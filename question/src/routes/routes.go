@@ -3,6 +3,7 @@ package routes
 import (
 	"questionbank/src/controller"
 	"questionbank/src/middleware"
+	"neuroiq/shared/authctx"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -10,8 +11,11 @@ import (
 func SetupQuestionbankRoutes() chi.Router{
 	router := chi.NewRouter()
 
-	router.Get("/exam/{id}" , controller.GetExamByID)
-	
+	router.Group(func(r chi.Router){
+		r.Use(middleware.ExamReaderMiddleware)
+		r.Get("/exam/{id}" , controller.GetExamByID)
+	})
+
 	router.Group(func(r chi.Router){
 		r.Use(middleware.AuthMiddleware)
 		r.Post("/register/theory" , controller.RegisterTheoryQuestionSet)
@@ -26,5 +30,12 @@ func SetupQuestionbankRoutes() chi.Router{
 
 	})
 
+	// Called by auth to export or erase a user's data
+	router.With(middleware.ServiceMiddleware(authctx.ScopePrivacyExport)).Post("/internal/privacy/export" , controller.ExportUserData)
+	router.With(middleware.ServiceMiddleware(authctx.ScopePrivacyErase)).Post("/internal/privacy/erase" , controller.EraseUserData)
+
+	// Called by auth to assign records written before tenancy
+	router.With(middleware.ServiceMiddleware(authctx.ScopeTenancyBackfill)).Post("/internal/tenancy/backfill" , controller.BackfillInstitution)
+
 	return router
 } 
\ No newline at end of file
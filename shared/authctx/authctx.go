@@ -0,0 +1,34 @@
+// Package authctx describes the caller of a request once a service has
+// verified its access token.
+package authctx
+
+// Context is the verified caller; services store it in the request context
+type Context struct {
+	UserID        string
+	Email         string
+	Role          string
+	InstitutionID string
+	Service       bool     // token was issued to a service client; UserID is its client ID
+	Scopes        []string // granted scopes of a service token
+	ActorID       string   // admin impersonating the user; such tokens are read-only
+}
+
+// HasScope reports whether a service token was granted scope
+func (c Context) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes of the tokens auth uses to export or erase a user's data
+const (
+	ScopePrivacyExport = "privacy:export"
+	ScopePrivacyErase  = "privacy:erase"
+)
+
+// ScopeTenancyBackfill is signed by auth for itself to assign records written
+// before multi-tenancy to their owners' institution
+const ScopeTenancyBackfill = "tenancy:backfill"
@@ -0,0 +1,154 @@
+// Package courses checks teachers against the course assignments auth keeps
+// for them.
+package courses
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Teachers may only manage the courses auth has assigned them. Assignments
+// are fetched from auth's /assignments/me with the caller's token and cached
+// per user for cacheTTL: a new assignment is seen at once (a miss
+// refetches), but a removed one keeps working for up to a minute.
+const cacheTTL = time.Minute
+
+// Course is one teaching assignment of the current academic year; an empty
+// Section covers every section of the branch and semester
+type Course struct {
+	Subject  string `json:"subject"`
+	Branch   string `json:"branch"`
+	Semester int    `json:"semester"`
+	Section  string `json:"section"`
+}
+
+type cachedCourses struct {
+	courses   []Course
+	fetchedAt time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	byUser  = map[string]cachedCourses{}
+	client  = &http.Client{Timeout: 5 * time.Second}
+)
+
+// covers tells whether the assignment covers a course, comparing subject,
+// branch and section case-insensitively and the semester as a number (services
+// pass it as text). A course without a section (e.g. an exam for the whole
+// branch) is only covered by an assignment to every section.
+func (c Course) covers(subject string, branch string, semester string, section string) bool {
+	if !strings.EqualFold(strings.TrimSpace(c.Subject), strings.TrimSpace(subject)) {
+		return false
+	}
+	if !strings.EqualFold(strings.TrimSpace(c.Branch), strings.TrimSpace(branch)) {
+		return false
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(semester)); err != nil || n != c.Semester {
+		return false
+	}
+	assigned := strings.TrimSpace(c.Section)
+	return assigned == "" || strings.EqualFold(assigned, strings.TrimSpace(section))
+}
+
+// Require lets admins through and checks that a teacher is assigned to the
+// course; subject, branch and semester are required, section may be empty
+// for every section. Anyone else is refused. It writes the error response
+// and returns false when the caller may not proceed.
+func Require(w http.ResponseWriter, r *http.Request, role string, userID string, subject string, branch string, semester string, section string) bool {
+	if role == "admin" {
+		return true
+	}
+	if role != "teacher" {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	if strings.TrimSpace(subject) == "" || strings.TrimSpace(branch) == "" || strings.TrimSpace(semester) == "" {
+		http.Error(w, "subject, branch and semester are required", http.StatusBadRequest)
+		return false
+	}
+
+	courses, err := teacherCourses(r, userID, false)
+	if err == nil && !anyCovers(courses, subject, branch, semester, section) {
+		// The cache may predate a new assignment
+		courses, err = teacherCourses(r, userID, true)
+	}
+	if err != nil {
+		log.Printf("failed to load course assignments: %v", err)
+		http.Error(w, "Failed to load course assignments", http.StatusBadGateway)
+		return false
+	}
+
+	if anyCovers(courses, subject, branch, semester, section) {
+		return true
+	}
+
+	http.Error(w, "Forbidden: you are not assigned to this course", http.StatusForbidden)
+	return false
+}
+
+func anyCovers(courses []Course, subject string, branch string, semester string, section string) bool {
+	for _, c := range courses {
+		if c.covers(subject, branch, semester, section) {
+			return true
+		}
+	}
+	return false
+}
+
+// teacherCourses returns the caller's assignments, from the cache unless
+// refresh is set
+func teacherCourses(r *http.Request, userID string, refresh bool) ([]Course, error) {
+	cacheMu.Lock()
+	cached, ok := byUser[userID]
+	cacheMu.Unlock()
+	if !refresh && ok && time.Since(cached.fetchedAt) < cacheTTL {
+		return cached.courses, nil
+	}
+
+	authURI := os.Getenv("AUTH_URI")
+	if authURI == "" {
+		return nil, fmt.Errorf("AUTH_URI not configured")
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), "GET", authURI+"/assignments/me", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", r.Header.Get("Authorization"))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("assignments request returned %s", resp.Status)
+	}
+
+	var body struct {
+		Assignments []Course `json:"assignments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	cacheMu.Lock()
+	for id, c := range byUser {
+		if time.Since(c.fetchedAt) >= cacheTTL {
+			delete(byUser, id)
+		}
+	}
+	byUser[userID] = cachedCourses{courses: body.Assignments, fetchedAt: time.Now()}
+	cacheMu.Unlock()
+
+	return body.Assignments, nil
+}
@@ -0,0 +1,37 @@
+package courses
+
+import "testing"
+
+func TestCourseCovers(t *testing.T) {
+	sectionA := Course{Subject: "Data Structures", Branch: "CSE", Semester: 3, Section: "A"}
+	allSections := Course{Subject: "Data Structures", Branch: "CSE", Semester: 3}
+
+	cases := []struct {
+		name     string
+		course   Course
+		subject  string
+		branch   string
+		semester string
+		section  string
+		want     bool
+	}{
+		{"exact", sectionA, "Data Structures", "CSE", "3", "A", true},
+		{"case-insensitive", sectionA, "data structures", "cse", "3", "a", true},
+		{"surrounding spaces", sectionA, " Data Structures ", "CSE ", " 3", " A", true},
+		{"other subject", sectionA, "Algorithms", "CSE", "3", "A", false},
+		{"other branch", sectionA, "Data Structures", "ECE", "3", "A", false},
+		{"other section", sectionA, "Data Structures", "CSE", "3", "B", false},
+		{"whole branch needs every section", sectionA, "Data Structures", "CSE", "3", "", false},
+		{"every section covers one", allSections, "Data Structures", "CSE", "3", "B", true},
+		{"every section covers the branch", allSections, "Data Structures", "CSE", "3", "", true},
+		{"semester with a leading zero", sectionA, "Data Structures", "CSE", "03", "A", true},
+		{"other semester", sectionA, "Data Structures", "CSE", "4", "A", false},
+		{"semester not a number", sectionA, "Data Structures", "CSE", "III", "A", false},
+		{"semester missing", sectionA, "Data Structures", "CSE", "", "A", false},
+	}
+	for _, c := range cases {
+		if got := c.course.covers(c.subject, c.branch, c.semester, c.section); got != c.want {
+			t.Errorf("%s: covers = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module neuroiq/shared
+
+go 1.24.0
@@ -0,0 +1,100 @@
+// Package impersonation handles tokens that let an admin see what a user
+// sees. They may only read, and every request made with one is reported to
+// auth's audit log, using the token itself, under the service's name.
+package impersonation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+var client = &http.Client{Timeout: 5 * time.Second}
+
+// reportAttempts bounds the tries to reach auth for one request
+const reportAttempts = 4
+
+// unauditedRequests counts impersonated requests auth never recorded
+var unauditedRequests atomic.Int64
+
+// Allow refuses anything but reads to a request of service made with an
+// impersonation token (actorID set) and reports it; other requests pass
+// untouched. It writes the error response and returns false when the caller
+// may not proceed.
+func Allow(w http.ResponseWriter, r *http.Request, service string, actorID string, userID string, token string) bool {
+	if actorID == "" {
+		return true
+	}
+
+	method, path := r.Method, r.URL.Path
+	go func() {
+		if err := report(service, token, method, path); err != nil {
+			// Keep a trace here when auth cannot take it
+			n := unauditedRequests.Add(1)
+			log.Printf("⚠️ Impersonated request %s %s by %s as %s not audited (%d so far): %v", method, path, actorID, userID, n, err)
+		}
+	}()
+
+	if method != http.MethodGet && method != http.MethodHead {
+		http.Error(w, "Forbidden: impersonation is read-only", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// report posts the request to auth's audit log, retrying while auth is
+// unreachable or failing
+func report(service string, token string, method string, path string) error {
+	var err error
+	for attempt := 1; attempt <= reportAttempts; attempt++ {
+		var retry bool
+		retry, err = postEvent(service, token, method, path)
+		if err == nil || !retry {
+			return err
+		}
+		if attempt < reportAttempts {
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+		}
+	}
+	return err
+}
+
+// postEvent makes one attempt; retry tells whether another could succeed
+func postEvent(service string, token string, method string, path string) (retry bool, err error) {
+	authURI := os.Getenv("AUTH_URI")
+	if authURI == "" {
+		return false, fmt.Errorf("AUTH_URI not configured")
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"service": service,
+		"method":  method,
+		"path":    path,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest("POST", authURI+"/impersonation/events", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return resp.StatusCode >= 500, fmt.Errorf("auth returned %s", resp.Status)
+	}
+	return false, nil
+}
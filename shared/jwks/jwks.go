@@ -0,0 +1,164 @@
+// Package jwks verifies auth's access tokens for the other services: the
+// public keys are fetched from auth's JWKS endpoint and cached, keyed by kid.
+package jwks
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	cacheTTL    = 10 * time.Minute
+	minInterval = 30 * time.Second // floor between fetch attempts for unknown kids or while auth fails
+	maxStale    = time.Hour        // how long past cacheTTL keys are served while auth is unreachable
+)
+
+var (
+	cacheMu     sync.Mutex
+	cachedKeys  map[string]*rsa.PublicKey
+	fetchedAt   time.Time // last successful fetch
+	attemptedAt time.Time // last fetch, successful or not
+	lastErr     error     // of the last fetch
+	inflight    *flight
+	client      = &http.Client{Timeout: 5 * time.Second}
+)
+
+// flight is a fetch in progress; every caller needing new keys waits on the
+// same one instead of holding cacheMu across the request
+type flight struct {
+	done chan struct{}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// endpoint uses JWKS_URL, falling back to the well-known path under AUTH_URI
+func endpoint() string {
+	if u := os.Getenv("JWKS_URL"); u != "" {
+		return u
+	}
+	if authURI := os.Getenv("AUTH_URI"); authURI != "" {
+		return authURI + "/.well-known/jwks.json"
+	}
+	return ""
+}
+
+// PublicKey returns the verification key for kid, refetching the JWKS when
+// the cache is stale or the kid is new (auth has rotated keys). While auth
+// is unreachable the cached keys are served for up to maxStale more.
+func PublicKey(kid string) (*rsa.PublicKey, error) {
+	cacheMu.Lock()
+	key, ok := cachedKeys[kid]
+	if ok && time.Since(fetchedAt) <= cacheTTL {
+		cacheMu.Unlock()
+		return key, nil
+	}
+
+	if inflight != nil || time.Since(attemptedAt) > minInterval {
+		f := startFetch()
+		cacheMu.Unlock()
+		<-f.done
+		cacheMu.Lock()
+	}
+
+	key, ok = cachedKeys[kid]
+	age := time.Since(fetchedAt)
+	err := lastErr
+	cacheMu.Unlock()
+
+	switch {
+	case ok && age <= cacheTTL+maxStale:
+		return key, nil
+	case err != nil:
+		return nil, err
+	case ok:
+		return nil, fmt.Errorf("signing keys not refreshed for %s", age.Round(time.Second))
+	default:
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+}
+
+// startFetch joins the fetch in progress or starts one. cacheMu must be held.
+func startFetch() *flight {
+	if inflight != nil {
+		return inflight
+	}
+
+	f := &flight{done: make(chan struct{})}
+	inflight = f
+	attemptedAt = time.Now()
+
+	go func() {
+		keys, err := fetch()
+
+		cacheMu.Lock()
+		if err == nil {
+			cachedKeys = keys
+			fetchedAt = time.Now()
+		}
+		lastErr = err
+		inflight = nil
+		cacheMu.Unlock()
+
+		close(f.done)
+	}()
+	return f
+}
+
+func fetch() (map[string]*rsa.PublicKey, error) {
+	url := endpoint()
+	if url == "" {
+		return nil, fmt.Errorf("missing JWKS_URL")
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: bad modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: bad exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,198 @@
+package jwks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authServer serves the keys in set under their kids and counts requests;
+// while down is set it answers 503
+type authServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	set     map[string]*rsa.PublicKey
+	down    bool
+	fetches atomic.Int32
+}
+
+func newAuthServer(t *testing.T, keys map[string]*rsa.PublicKey) *authServer {
+	s := &authServer{set: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.down {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		var set jwkSet
+		for kid, key := range s.set {
+			set.Keys = append(set.Keys, jwk{
+				Kty: "RSA",
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.Close)
+	t.Setenv("JWKS_URL", s.URL)
+
+	// Start every test with an empty cache
+	cacheMu.Lock()
+	cachedKeys, fetchedAt, attemptedAt, lastErr, inflight = nil, time.Time{}, time.Time{}, nil, nil
+	cacheMu.Unlock()
+	return s
+}
+
+func (s *authServer) update(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+}
+
+var (
+	testKeysOnce sync.Once
+	testKeys     [2]*rsa.PublicKey
+)
+
+func publicKeys(t *testing.T) (*rsa.PublicKey, *rsa.PublicKey) {
+	testKeysOnce.Do(func() {
+		for i := range testKeys {
+			private, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testKeys[i] = &private.PublicKey
+		}
+	})
+	return testKeys[0], testKeys[1]
+}
+
+// age moves the last fetch and attempt back by d
+func age(d time.Duration) {
+	cacheMu.Lock()
+	fetchedAt = fetchedAt.Add(-d)
+	attemptedAt = attemptedAt.Add(-d)
+	cacheMu.Unlock()
+}
+
+func TestPublicKeyRotation(t *testing.T) {
+	first, second := publicKeys(t)
+	auth := newAuthServer(t, map[string]*rsa.PublicKey{"k1": first})
+
+	key, err := PublicKey("k1")
+	if err != nil || !key.Equal(first) {
+		t.Fatalf("k1 = %v, %v", key, err)
+	}
+	if _, err := PublicKey("k1"); err != nil || auth.fetches.Load() != 1 {
+		t.Fatalf("cached k1: %v after %d fetches", err, auth.fetches.Load())
+	}
+
+	auth.update(func() { auth.set = map[string]*rsa.PublicKey{"k1": first, "k2": second} })
+
+	// A new kid is looked up at most every minInterval
+	if _, err := PublicKey("k2"); err == nil || auth.fetches.Load() != 1 {
+		t.Fatalf("k2 right after a fetch: %v after %d fetches", err, auth.fetches.Load())
+	}
+	age(minInterval + time.Second)
+	key, err = PublicKey("k2")
+	if err != nil || !key.Equal(second) || auth.fetches.Load() != 2 {
+		t.Fatalf("k2 = %v, %v after %d fetches", key, err, auth.fetches.Load())
+	}
+}
+
+func TestPublicKeyUnknownKid(t *testing.T) {
+	first, _ := publicKeys(t)
+	auth := newAuthServer(t, map[string]*rsa.PublicKey{"k1": first})
+
+	for i := 0; i < 3; i++ {
+		_, err := PublicKey("forged")
+		if err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+			t.Fatalf("forged kid: %v", err)
+		}
+	}
+	if n := auth.fetches.Load(); n != 1 {
+		t.Errorf("%d fetches for repeated unknown kids, want 1", n)
+	}
+}
+
+func TestPublicKeyAuthDown(t *testing.T) {
+	first, _ := publicKeys(t)
+	auth := newAuthServer(t, map[string]*rsa.PublicKey{"k1": first})
+
+	if _, err := PublicKey("k1"); err != nil {
+		t.Fatal(err)
+	}
+	auth.update(func() { auth.down = true })
+
+	// Stale keys are still served, and the failed attempt holds off the next one
+	age(cacheTTL + time.Minute)
+	for i := 0; i < 3; i++ {
+		if key, err := PublicKey("k1"); err != nil || !key.Equal(first) {
+			t.Fatalf("stale k1 = %v, %v", key, err)
+		}
+	}
+	if n := auth.fetches.Load(); n != 2 {
+		t.Errorf("%d fetches while auth is down, want 2", n)
+	}
+
+	// but not for longer than maxStale
+	age(maxStale)
+	if _, err := PublicKey("k1"); err == nil {
+		t.Error("served keys past maxStale")
+	}
+
+	auth.update(func() { auth.down = false })
+	age(minInterval + time.Second)
+	if _, err := PublicKey("k1"); err != nil {
+		t.Errorf("after auth recovered: %v", err)
+	}
+}
+
+func TestPublicKeySingleFetch(t *testing.T) {
+	first, _ := publicKeys(t)
+	release := make(chan struct{})
+	auth := newAuthServer(t, map[string]*rsa.PublicKey{"k1": first})
+	auth.Config.Handler = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			next.ServeHTTP(w, r)
+		})
+	}(auth.Config.Handler)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := PublicKey("k1")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := auth.fetches.Load(); n != 1 {
+		t.Errorf("%d fetches for concurrent callers, want 1", n)
+	}
+}
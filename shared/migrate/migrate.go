@@ -0,0 +1,367 @@
+// Package migrate runs the versioned SQL migrations of the Postgres backed
+// services. Migrations live in a directory as NNNN_name.up.sql and
+// NNNN_name.down.sql. Applied versions are recorded in schema_migrations with
+// the checksum of their up script, so an edited migration is caught instead
+// of silently skipped. Each service supplies a Store over its own driver.
+package migrate
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const (
+	// lockTimeout frees the lock of a runner that died mid-migration
+	lockTimeout = 10 * time.Minute
+	// lockWait is how long a starting replica waits for another to finish
+	lockWait = 60 * time.Second
+)
+
+// ErrLocked means another process holds the migration lock
+var ErrLocked = errors.New("migrations are locked by another process")
+
+// The bookkeeping statements a Store runs
+const (
+	tablesSQL = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(200) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	-- Single row; locked_by is set while a runner is migrating
+	CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+		id INT PRIMARY KEY CHECK (id = 1),
+		locked_by VARCHAR(255),
+		locked_at TIMESTAMP
+	);
+
+	INSERT INTO schema_migrations_lock (id) VALUES (1) ON CONFLICT DO NOTHING;
+	`
+	lockSQL = `
+		UPDATE schema_migrations_lock
+		SET locked_by = $1, locked_at = NOW()
+		WHERE id = 1 AND (locked_by IS NULL OR locked_at < NOW() - make_interval(secs => $2))
+	`
+	unlockSQL     = `UPDATE schema_migrations_lock SET locked_by = NULL, locked_at = NULL WHERE id = 1 AND locked_by = $1`
+	recordUpSQL   = `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`
+	recordDownSQL = `DELETE FROM schema_migrations WHERE version = $1`
+
+	// AppliedSQL and LockHolderSQL are what Store.Applied and
+	// Store.LockHolder run
+	AppliedSQL    = `SELECT version, checksum, applied_at FROM schema_migrations`
+	LockHolderSQL = `SELECT locked_by FROM schema_migrations_lock WHERE id = 1`
+)
+
+// Store is the database a Migrator works on
+type Store interface {
+	// Exec runs a statement and returns how many rows it affected
+	Exec(query string, args ...interface{}) (int64, error)
+	// ExecInTx runs script (several statements, no arguments) and then
+	// record with args in one transaction
+	ExecInTx(script string, record string, args ...interface{}) error
+	// Applied returns the rows of AppliedSQL
+	Applied() ([]Applied, error)
+	// LockHolder returns the locked_by of LockHolderSQL, empty when free
+	LockHolder() (string, error)
+}
+
+// Applied is a row of schema_migrations
+type Applied struct {
+	Version   int
+	Checksum  string
+	AppliedAt time.Time
+}
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// State is a migration together with its record in schema_migrations
+type State struct {
+	Migration
+	AppliedAt       *time.Time
+	AppliedChecksum string
+}
+
+func (s State) Modified() bool {
+	return s.AppliedAt != nil && s.AppliedChecksum != s.Checksum
+}
+
+// Migrator applies the migrations in Dir of Files to Store
+type Migrator struct {
+	Files fs.FS
+	Dir   string
+	Store Store
+	Name  string // the binary, for usage messages
+}
+
+// Load reads and orders the migration files
+func (m *Migrator) Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(m.Files, m.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := fs.ReadFile(m.Files, path.Join(m.Dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, match[2])
+		}
+		if match[3] == "up" {
+			mig.Up = string(content)
+			mig.Checksum = fmt.Sprintf("%x", sha256.Sum256(content))
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status() ([]State, error) {
+	migrations, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Store.Exec(tablesSQL); err != nil {
+		return nil, err
+	}
+	applied, err := m.Store.Applied()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, len(migrations))
+	for i, mig := range migrations {
+		states[i].Migration = mig
+		for _, a := range applied {
+			if a.Version == mig.Version {
+				appliedAt := a.AppliedAt
+				states[i].AppliedAt = &appliedAt
+				states[i].AppliedChecksum = a.Checksum
+			}
+		}
+	}
+
+	// Rows without a file mean the binary is older than the database
+	for _, a := range applied {
+		found := false
+		for _, mig := range migrations {
+			found = found || mig.Version == a.Version
+		}
+		if !found {
+			return nil, fmt.Errorf("database has migration %d which this binary does not know", a.Version)
+		}
+	}
+
+	return states, nil
+}
+
+// Pending returns the migrations not applied yet, failing if an applied one
+// was modified afterwards
+func (m *Migrator) Pending() ([]Migration, error) {
+	states, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, s := range states {
+		if s.Modified() {
+			return nil, fmt.Errorf("migration %d_%s was modified after it was applied", s.Version, s.Name)
+		}
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns how many were applied
+func (m *Migrator) Up() (int, error) {
+	if _, err := m.Store.Exec(tablesSQL); err != nil {
+		return 0, err
+	}
+	release, err := m.lock(lockWait)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	pending, err := m.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, mig := range pending {
+		if err := m.Store.ExecInTx(mig.Up, recordUpSQL, mig.Version, mig.Name, mig.Checksum); err != nil {
+			return i, fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		fmt.Printf("✅ Applied migration %d_%s\n", mig.Version, mig.Name)
+	}
+
+	return len(pending), nil
+}
+
+// Down reverts the last steps applied migrations, newest first
+func (m *Migrator) Down(steps int) (int, error) {
+	if _, err := m.Store.Exec(tablesSQL); err != nil {
+		return 0, err
+	}
+	release, err := m.lock(0)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	states, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for i := len(states) - 1; i >= 0 && reverted < steps; i-- {
+		mig := states[i]
+		if mig.AppliedAt == nil {
+			continue
+		}
+		if mig.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+		}
+
+		if err := m.Store.ExecInTx(mig.Down, recordDownSQL, mig.Version); err != nil {
+			return reverted, fmt.Errorf("reverting %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		fmt.Printf("✅ Reverted migration %d_%s\n", mig.Version, mig.Name)
+		reverted++
+	}
+
+	return reverted, nil
+}
+
+// lock takes the row in schema_migrations_lock, polling for up to wait while
+// another runner holds it. The returned func releases the lock.
+func (m *Migrator) lock(wait time.Duration) (func(), error) {
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s:%d", host, os.Getpid())
+	deadline := time.Now().Add(wait)
+
+	for {
+		n, err := m.Store.Exec(lockSQL, owner, lockTimeout.Seconds())
+		if err != nil {
+			return nil, err
+		}
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			holder, err := m.Store.LockHolder()
+			if err != nil {
+				return nil, err
+			}
+			if holder == "" {
+				return nil, ErrLocked
+			}
+			return nil, fmt.Errorf("%w (%s)", ErrLocked, holder)
+		}
+		time.Sleep(time.Second)
+	}
+
+	return func() {
+		m.Store.Exec(unlockSQL, owner)
+	}, nil
+}
+
+// RunCommand implements `<name> migrate up|down [steps]|status`
+func (m *Migrator) RunCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: " + m.Name + " migrate up | down [steps] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %d migrations applied\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := m.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %d migrations reverted\n", reverted)
+
+	case "status":
+		states, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			state := "pending"
+			if s.Modified() {
+				state = "MODIFIED since applied at " + s.AppliedAt.Format(time.RFC3339)
+			} else if s.AppliedAt != nil {
+				state = "applied at " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// memoryStore records what a Migrator runs instead of touching a database
+type memoryStore struct {
+	applied []Applied
+	scripts []string
+}
+
+func (s *memoryStore) Exec(query string, args ...interface{}) (int64, error) {
+	return 1, nil
+}
+
+func (s *memoryStore) ExecInTx(script string, record string, args ...interface{}) error {
+	s.scripts = append(s.scripts, script)
+	switch record {
+	case recordUpSQL:
+		s.applied = append(s.applied, Applied{Version: args[0].(int), Checksum: args[2].(string), AppliedAt: time.Now()})
+	case recordDownSQL:
+		for i, a := range s.applied {
+			if a.Version == args[0].(int) {
+				s.applied = append(s.applied[:i], s.applied[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Applied() ([]Applied, error) {
+	return s.applied, nil
+}
+
+func (s *memoryStore) LockHolder() (string, error) {
+	return "", nil
+}
+
+func testFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/0002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"migrations/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"migrations/0001_first.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+		"migrations/0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+	}
+}
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	m := &Migrator{Files: testFiles(), Dir: "migrations", Store: &memoryStore{}}
+
+	migrations, err := m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Name != "second" {
+		t.Fatalf("migrations = %+v", migrations)
+	}
+	if migrations[0].Down != "DROP TABLE a;" || len(migrations[0].Checksum) != 64 {
+		t.Errorf("first = %+v", migrations[0])
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	for name, files := range map[string]fstest.MapFS{
+		"stray file":    {"migrations/notes.txt": {}},
+		"no up script":  {"migrations/0001_first.down.sql": {Data: []byte("x")}},
+		"renamed twice": {"migrations/0001_a.up.sql": {Data: []byte("x")}, "migrations/0001_b.down.sql": {Data: []byte("x")}},
+	} {
+		m := &Migrator{Files: files, Dir: "migrations", Store: &memoryStore{}}
+		if _, err := m.Load(); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	store := &memoryStore{}
+	m := &Migrator{Files: testFiles(), Dir: "migrations", Store: store}
+
+	applied, err := m.Up()
+	if err != nil || applied != 2 {
+		t.Fatalf("Up = %d, %v", applied, err)
+	}
+	if applied, _ := m.Up(); applied != 0 {
+		t.Errorf("second Up applied %d", applied)
+	}
+
+	reverted, err := m.Down(1)
+	if err != nil || reverted != 1 {
+		t.Fatalf("Down = %d, %v", reverted, err)
+	}
+	if last := store.scripts[len(store.scripts)-1]; last != "DROP TABLE b;" {
+		t.Errorf("Down ran %q, want the newest migration reverted", last)
+	}
+
+	pending, err := m.Pending()
+	if err != nil || len(pending) != 1 || pending[0].Version != 2 {
+		t.Errorf("Pending = %+v, %v", pending, err)
+	}
+}
+
+func TestPendingRejectsModifiedMigration(t *testing.T) {
+	store := &memoryStore{applied: []Applied{{Version: 1, Checksum: strings.Repeat("0", 64), AppliedAt: time.Now()}}}
+	m := &Migrator{Files: testFiles(), Dir: "migrations", Store: store}
+
+	if _, err := m.Pending(); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("Pending err = %v", err)
+	}
+}
+
+func TestStatusRejectsUnknownVersion(t *testing.T) {
+	store := &memoryStore{applied: []Applied{{Version: 9, AppliedAt: time.Now()}}}
+	m := &Migrator{Files: testFiles(), Dir: "migrations", Store: store}
+
+	if _, err := m.Status(); err == nil {
+		t.Error("Status accepted a version this binary does not know")
+	}
+}
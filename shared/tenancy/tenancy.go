@@ -0,0 +1,82 @@
+// Package tenancy assigns records written before multi-tenancy to the
+// institution of the user who owns them. Only auth knows which institution
+// each user belongs to, so it drives the backfill: for every institution it
+// posts that institution's user and student IDs to each service's
+// /internal/tenancy/backfill, with a token scoped to the institution and to
+// authctx.ScopeTenancyBackfill.
+package tenancy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// MaxOwners bounds the IDs auth sends in one request
+const MaxOwners = 500
+
+// Owners are the users and student records of one institution. Services
+// key a record by whichever of the two it stores.
+type Owners struct {
+	UserIDs    []string `json:"user_ids"`
+	StudentIDs []string `json:"student_ids"`
+}
+
+// Result is a service's answer: how many records it assigned and how many
+// still have no institution once this request is done
+type Result struct {
+	Assigned  int64 `json:"assigned"`
+	Remaining int64 `json:"remaining"`
+}
+
+// Backfill assigns the records of owners that have no institution yet to
+// institutionID
+type Backfill func(ctx context.Context, institutionID string, owners Owners) (Result, error)
+
+// Serve decodes the owners of the request and runs backfill for
+// institutionID, the institution of the verified service token
+func Serve(w http.ResponseWriter, r *http.Request, institutionID string, backfill Backfill) {
+	if institutionID == "" {
+		http.Error(w, "Token has no institution", http.StatusForbidden)
+		return
+	}
+
+	var owners Owners
+	if err := json.NewDecoder(r.Body).Decode(&owners); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(owners.UserIDs)+len(owners.StudentIDs) > MaxOwners {
+		http.Error(w, fmt.Sprintf("At most %d IDs per request", MaxOwners), http.StatusBadRequest)
+		return
+	}
+
+	result, err := backfill(r.Context(), institutionID, owners)
+	if err != nil {
+		http.Error(w, "Failed to assign records: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Unassigned is the Mongo filter for documents whose field is one of ids and
+// that have no institution yet
+func Unassigned(field string, ids []string) map[string]interface{} {
+	return map[string]interface{}{
+		field:            map[string]interface{}{"$in": ids},
+		"institution_id": map[string]interface{}{"$exists": false},
+	}
+}
+
+// Untagged is the Mongo filter for every document without an institution
+func Untagged() map[string]interface{} {
+	return map[string]interface{}{"institution_id": map[string]interface{}{"$exists": false}}
+}
+
+// Assign is the Mongo update that sets the institution
+func Assign(institutionID string) map[string]interface{} {
+	return map[string]interface{}{"$set": map[string]interface{}{"institution_id": institutionID}}
+}
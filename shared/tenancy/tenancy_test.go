@@ -0,0 +1,67 @@
+package tenancy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	var got Owners
+	var gotInstitution string
+	backfill := func(ctx context.Context, institutionID string, owners Owners) (Result, error) {
+		gotInstitution, got = institutionID, owners
+		return Result{Assigned: 2, Remaining: 1}, nil
+	}
+
+	req := httptest.NewRequest("POST", "/internal/tenancy/backfill", strings.NewReader(`{"user_ids":["u1","u2"],"student_ids":["s1"]}`))
+	rec := httptest.NewRecorder()
+	Serve(rec, req, "inst-1", backfill)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	if gotInstitution != "inst-1" || len(got.UserIDs) != 2 || len(got.StudentIDs) != 1 {
+		t.Fatalf("backfill called with %q %+v", gotInstitution, got)
+	}
+	var result Result
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || result != (Result{Assigned: 2, Remaining: 1}) {
+		t.Fatalf("result = %+v, %v", result, err)
+	}
+}
+
+func TestServeRejects(t *testing.T) {
+	ids := make([]string, MaxOwners+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%q", fmt.Sprint(i))
+	}
+	tooMany := `{"user_ids":[` + strings.Join(ids, ",") + `]}`
+
+	cases := []struct {
+		name        string
+		institution string
+		body        string
+		status      int
+	}{
+		{"no institution", "", `{"user_ids":["u1"]}`, http.StatusForbidden},
+		{"invalid body", "inst-1", `{`, http.StatusBadRequest},
+		{"too many IDs", "inst-1", tooMany, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		called := false
+		backfill := func(ctx context.Context, institutionID string, owners Owners) (Result, error) {
+			called = true
+			return Result{}, nil
+		}
+
+		rec := httptest.NewRecorder()
+		Serve(rec, httptest.NewRequest("POST", "/", strings.NewReader(c.body)), c.institution, backfill)
+		if rec.Code != c.status || called {
+			t.Errorf("%s: status = %d, backfill called %v", c.name, rec.Code, called)
+		}
+	}
+}